@@ -0,0 +1,93 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/anicolao/emrys/internal/approval"
+)
+
+// runApprovals lists and answers queued approval requests
+func runApprovals(args []string) int {
+	usage := func() {
+		fmt.Fprintln(os.Stderr, "Usage:")
+		fmt.Fprintln(os.Stderr, "  emrys approvals [list]")
+		fmt.Fprintln(os.Stderr, "  emrys approvals approve [--always] <id>")
+		fmt.Fprintln(os.Stderr, "  emrys approvals deny [--never] <id>")
+	}
+
+	dir := approval.DefaultQueueDir()
+
+	if len(args) == 0 || args[0] == "list" {
+		requests, err := approval.ListPending(dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		if globals.JSON {
+			if requests == nil {
+				requests = []approval.Request{}
+			}
+			return printJSON(requests)
+		}
+		if len(requests) == 0 {
+			fmt.Println("No pending approval requests")
+			return 0
+		}
+		for _, req := range requests {
+			summary := req.Action.Facts.Summary
+			if summary == "" {
+				summary = string(req.Action.Arguments)
+			}
+			fmt.Printf("%s  %s  %s (%s)\n", req.ID, req.Created.Format(time.DateTime), req.Action.Tool, req.Rule)
+			fmt.Printf("    %s\n", summary)
+		}
+		return 0
+	}
+
+	var approved bool
+	switch args[0] {
+	case "approve":
+		approved = true
+	case "deny":
+		approved = false
+	default:
+		usage()
+		return 2
+	}
+
+	flags := flag.NewFlagSet("approvals "+args[0], flag.ContinueOnError)
+	remember := flags.Bool("always", false, "remember this approval for identical requests")
+	if !approved {
+		remember = flags.Bool("never", false, "remember this denial for identical requests")
+	}
+	if err := flags.Parse(args[1:]); err != nil || flags.NArg() != 1 {
+		usage()
+		return 2
+	}
+
+	id := flags.Arg(0)
+	if err := approval.Respond(dir, id, approval.Answer{Approved: approved, Remember: *remember}); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	if globals.JSON {
+		return printJSON(approvalResult{ID: id, Approved: approved, Remembered: *remember})
+	}
+	if approved {
+		fmt.Printf("✓ Approved %s\n", id)
+	} else {
+		fmt.Printf("✓ Denied %s\n", id)
+	}
+	return 0
+}
+
+// approvalResult is what 'emrys approvals approve' and 'deny' print as JSON
+type approvalResult struct {
+	ID         string `json:"id"`
+	Approved   bool   `json:"approved"`
+	Remembered bool   `json:"remembered"`
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/anicolao/emrys/internal/audit"
+)
+
+// runAudit verifies and displays the audit log
+func runAudit(args []string) int {
+	usage := func() {
+		fmt.Fprintln(os.Stderr, "Usage:")
+		fmt.Fprintln(os.Stderr, "  emrys audit verify [--file path]")
+		fmt.Fprintln(os.Stderr, "  emrys audit show [--file path] [--initiator prefix] [--command text] [--since 24h|2006-01-02] [--failed] [-n count] [--json]")
+	}
+
+	if len(args) == 0 {
+		usage()
+		return 2
+	}
+
+	switch args[0] {
+	case "verify":
+		flags := flag.NewFlagSet("audit verify", flag.ContinueOnError)
+		file := flags.String("file", audit.DefaultPath(), "audit log to verify")
+		if err := flags.Parse(args[1:]); err != nil {
+			return 2
+		}
+
+		result, err := audit.Verify(*file)
+		if errors.Is(err, fs.ErrNotExist) {
+			// A missing log is suspicious once Emrys has run any command
+			fmt.Fprintf(os.Stderr, "⚠ No audit log found at %s\n", *file)
+			return 1
+		}
+		if err != nil {
+			var tamperErr *audit.TamperError
+			if errors.As(err, &tamperErr) {
+				fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+				return 1
+			}
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+
+		if globals.JSON {
+			return printJSON(auditVerifyResult{Intact: true, Entries: result.Entries, HeadHash: result.HeadHash})
+		}
+		fmt.Printf("✓ Audit log intact: %d entries\n", result.Entries)
+		fmt.Printf("  Head hash: %s\n", result.HeadHash)
+		return 0
+
+	case "show":
+		flags := flag.NewFlagSet("audit show", flag.ContinueOnError)
+		file := flags.String("file", audit.DefaultPath(), "audit log to read")
+		initiator := flags.String("initiator", "", "only entries whose initiator starts with this prefix")
+		command := flags.String("command", "", "only entries whose command line contains this text")
+		since := flags.String("since", "", "only entries newer than a duration (24h) or date (2006-01-02)")
+		failed := flags.Bool("failed", false, "only failed commands")
+		limit := flags.Int("n", 50, "show at most this many of the newest entries (0 for all)")
+		asJSON := flags.Bool("json", globals.JSON, "print entries as JSON lines")
+		if err := flags.Parse(args[1:]); err != nil {
+			return 2
+		}
+
+		filter := audit.Filter{
+			Initiator: *initiator,
+			Command:   *command,
+			Failed:    *failed,
+			Limit:     *limit,
+		}
+		if *since != "" {
+			t, err := parseSince(*since)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return 2
+			}
+			filter.Since = t
+		}
+
+		entries, err := audit.Read(*file)
+		if errors.Is(err, fs.ErrNotExist) {
+			fmt.Println("No audit entries recorded yet")
+			return 0
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+
+		enc := json.NewEncoder(os.Stdout)
+		for _, entry := range filter.Apply(entries) {
+			if *asJSON {
+				enc.Encode(entry)
+				continue
+			}
+			status := "✓"
+			if entry.ExitCode != 0 || entry.Error != "" {
+				status = fmt.Sprintf("✗ exit %d", entry.ExitCode)
+			}
+			fmt.Printf("#%d %s [%s] %s  %s\n",
+				entry.Seq,
+				entry.Time.Local().Format(time.DateTime),
+				entry.Initiator,
+				status,
+				strings.Join(entry.Args, " "),
+			)
+		}
+		return 0
+
+	default:
+		usage()
+		return 2
+	}
+}
+
+// parseSince accepts a duration before now or a date
+func parseSince(value string) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, value, time.Local); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since value %q", value)
+}
+
+// auditVerifyResult is what 'emrys audit verify' prints as JSON
+type auditVerifyResult struct {
+	Intact   bool   `json:"intact"`
+	Entries  int64  `json:"entries"`
+	HeadHash string `json:"head_hash"`
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/anicolao/emrys/internal/bootstrap"
+	"github.com/anicolao/emrys/internal/nixdarwin"
+)
+
+// setupPhase is one step of setting up a Mac for Emrys
+type setupPhase struct {
+	Name     string
+	Title    string
+	Complete func() bool
+	Run      func() error
+}
+
+// setupPhases returns the setup steps in the order they must run
+func setupPhases() []setupPhase {
+	return []setupPhase{
+		{Name: "nix-darwin", Title: "nix-darwin", Complete: nixdarwin.IsInstalled, Run: installNixDarwin},
+		{Name: "1", Title: "Phase 1: Core packages", Complete: bootstrap.IsPhase1Complete, Run: bootstrap.RunPhase1},
+		{Name: "2", Title: "Phase 2: Ollama", Complete: bootstrap.IsPhase2Complete, Run: bootstrap.RunPhase2},
+		{Name: "3", Title: "Phase 3: Voice output", Complete: bootstrap.IsPhase3Complete, Run: bootstrap.RunPhase3},
+	}
+}
+
+// runBootstrap runs every incomplete setup phase without prompting, or
+// only the named phase
+func runBootstrap(args []string) int {
+	if len(args) > 1 {
+		fmt.Fprintln(os.Stderr, "Usage: emrys bootstrap [nix-darwin|1|2|3]")
+		return 2
+	}
+
+	if len(args) == 1 {
+		name := strings.TrimPrefix(strings.ToLower(args[0]), "phase")
+		for _, phase := range setupPhases() {
+			if phase.Name == name {
+				if err := phase.Run(); err != nil {
+					slog.Error("{phase} failed", "phase", phase.Title, "error", err)
+					return 1
+				}
+				return 0
+			}
+		}
+		fmt.Fprintf(os.Stderr, "Unknown phase: %s\n", args[0])
+		fmt.Fprintln(os.Stderr, "Usage: emrys bootstrap [nix-darwin|1|2|3]")
+		return 2
+	}
+
+	for _, phase := range setupPhases() {
+		if phase.Complete() {
+			fmt.Printf("✓ %s is complete\n", phase.Title)
+			continue
+		}
+
+		fmt.Println()
+		if err := phase.Run(); err != nil {
+			slog.Error("{phase} failed", "phase", phase.Title, "error", err)
+			return 1
+		}
+		if phase.Name == "nix-darwin" {
+			// darwin-rebuild is only on PATH in a new shell
+			fmt.Println()
+			fmt.Println("nix-darwin is installed. Open a new terminal and run 'emrys bootstrap' again to continue.")
+			return 0
+		}
+	}
+
+	fmt.Println()
+	fmt.Println("Emrys is ready to use.")
+	return 0
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/anicolao/emrys/internal/chat"
+	"github.com/anicolao/emrys/internal/config"
+	"github.com/anicolao/emrys/internal/voice"
+)
+
+// runChat holds a text conversation in the terminal, or answers a single
+// message given as arguments
+func runChat(args []string) int {
+	flags := flag.NewFlagSet("chat", flag.ContinueOnError)
+	speak := flags.Bool("speak", false, "also speak the replies")
+	if err := flags.Parse(args); err != nil {
+		fmt.Fprintln(os.Stderr, "Usage: emrys chat [--speak] [message]")
+		return 2
+	}
+
+	settings, err := config.Load(config.DefaultPath())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	engine := chat.NewEngine(settings.Client(), settings.ChatOptions())
+
+	var speaker *voice.Speaker
+	if *speak {
+		speaker = voice.NewSpeaker(settings.Voice)
+		defer speaker.Close()
+		defer waitForSpeech(speaker)
+		watcher, err := watchVoiceConfig(speaker)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		defer watcher.Close()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// send streams one reply to stdout, speaking it too if asked; a single
+	// message with --output=json prints only the JSON
+	quiet := globals.JSON && flags.NArg() > 0
+	send := func(text string) (string, error) {
+		if speaker != nil {
+			return engine.SendSpoken(ctx, text, speaker, voice.PriorityNormal)
+		}
+		return engine.SendStream(ctx, text, func(delta string) error {
+			if !quiet {
+				fmt.Print(delta)
+			}
+			return nil
+		})
+	}
+
+	if flags.NArg() > 0 {
+		reply, err := send(strings.Join(flags.Args(), " "))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		if globals.JSON {
+			return printJSON(map[string]string{"reply": reply})
+		}
+		if speaker != nil {
+			fmt.Print(reply)
+		}
+		fmt.Println()
+		return 0
+	}
+
+	interactive := false
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		interactive = true
+		fmt.Printf("Chatting with %s. Type /quit or press Ctrl-D to leave.\n", settings.Models.Chat)
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for {
+		if interactive {
+			fmt.Print("You: ")
+		}
+		if !scanner.Scan() {
+			break
+		}
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		if text == "/quit" || text == "/exit" {
+			break
+		}
+
+		fmt.Print("Emrys: ")
+		reply, err := send(text)
+		if err != nil {
+			fmt.Println()
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			if ctx.Err() != nil {
+				return 1
+			}
+			continue
+		}
+		if speaker != nil {
+			fmt.Print(reply)
+		}
+		fmt.Println()
+	}
+	if interactive {
+		fmt.Println()
+	}
+	return 0
+}
+
+// speechTimeout bounds how long emrys waits for queued speech before exiting
+const speechTimeout = 2 * time.Minute
+
+// waitForSpeech gives the speaker time to say what is queued, until it is
+// done, the user presses Ctrl-C or speechTimeout passes. Messages deferred
+// for quiet hours are not waited for.
+func waitForSpeech(speaker *voice.Speaker) error {
+	ctx, cancel := context.WithTimeout(context.Background(), speechTimeout)
+	defer cancel()
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+	return speaker.Wait(ctx)
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/anicolao/emrys/internal/config"
+)
+
+// globalOptions are the flags every command accepts
+type globalOptions struct {
+	Config  string // Path to config.yaml
+	JSON    bool   // Print machine-readable output
+	Verbose bool   // Log debug messages
+}
+
+// globals holds the global flags of this invocation
+var globals globalOptions
+
+// command is an emrys subcommand
+type command struct {
+	Name        string
+	Args        string   // Arguments shown in help
+	Summary     string   // One line for help
+	Subcommands []string // Offered by shell completion
+	NoLog       bool     // Never logs, so the log file is not opened
+	Run         func(args []string) int
+}
+
+// commands returns every subcommand in the order help lists them
+func commands() []command {
+	return []command{
+		{Name: "bootstrap", Args: "[phase]", Summary: "Run the remaining setup phases, or one phase (nix-darwin, 1, 2, 3)",
+			Subcommands: []string{"nix-darwin", "1", "2", "3"}, Run: runBootstrap},
+		{Name: "status", Summary: "Show setup progress and whether services are up", Run: runStatus},
+		{Name: "doctor", Summary: "Check the installation for problems", Run: runDoctor},
+		{Name: "diagnose", Args: "--bundle [path]", Summary: "Collect logs and state into a bundle for a bug report", Run: runDiagnose},
+		{Name: "model", Args: "list|pull|use", Summary: "List, download or choose Ollama models",
+			Subcommands: []string{"list", "pull", "use"}, Run: runModel},
+		{Name: "voice", Args: "say|test|voices", Summary: "Speak text, test speech output or list voices",
+			Subcommands: []string{"say", "test", "voices"}, Run: runVoice},
+		{Name: "config", Args: "get|set|edit|validate|show", Summary: "Read and change config.yaml",
+			Subcommands: []string{"get", "set", "edit", "validate", "show"}, Run: runConfig},
+		{Name: "chat", Summary: "Chat with the assistant in the terminal", Run: runChat},
+		{Name: "listen", Summary: "Hold a spoken conversation", Run: runListen},
+		{Name: "task", Args: "<task>", Summary: "Have the assistant carry out a task with shell commands", Run: runTask},
+		{Name: "run", Summary: "Run the assistant until stopped", Run: runAssistant},
+		{Name: "approvals", Args: "list|approve|deny", Summary: "Answer queued approval requests",
+			Subcommands: []string{"list", "approve", "deny"}, Run: runApprovals},
+		{Name: "audit", Args: "verify|show", Summary: "Verify and read the audit log",
+			Subcommands: []string{"verify", "show"}, Run: runAudit},
+		{Name: "migrate", Summary: "Upgrade files written by older versions", Run: runMigrate},
+		{Name: "completion", Args: "bash|zsh|fish", Summary: "Print a shell completion script",
+			Subcommands: []string{"bash", "zsh", "fish"}, NoLog: true, Run: runCompletion},
+	}
+}
+
+// parseGlobals reads the global flags that come before the command and
+// returns the command and its arguments, which are left for the command
+// to parse, so "emrys voice say -o out.aiff" keeps its own -o
+func parseGlobals(args []string) ([]string, error) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return args[i+1:], nil
+		}
+		if !strings.HasPrefix(arg, "-") {
+			return args[i:], nil
+		}
+
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		switch name {
+		case "config", "output", "o":
+			if !hasValue {
+				if i+1 >= len(args) {
+					return nil, fmt.Errorf("flag %s needs a value", arg)
+				}
+				i++
+				value = args[i]
+			}
+			if name == "config" {
+				globals.Config = value
+				continue
+			}
+			switch value {
+			case "json":
+				globals.JSON = true
+			case "text":
+				globals.JSON = false
+			default:
+				return nil, fmt.Errorf("--output must be text or json, got %q", value)
+			}
+		case "verbose", "v":
+			globals.Verbose = true
+		case "help", "h":
+			// runCommand answers help
+			return args[i:], nil
+		default:
+			return nil, fmt.Errorf("unknown flag %s; global flags go before the command", arg)
+		}
+	}
+	return nil, nil
+}
+
+// applyGlobals makes the global flags take effect for every package and
+// sets up logging unless the command in args never logs
+func applyGlobals(args []string) {
+	if globals.Config != "" {
+		// Every package finds config.yaml through config.DefaultPath
+		os.Setenv(config.PathEnv, globals.Config)
+	}
+	if len(args) == 0 || logs(args[0]) {
+		setupLogging()
+	}
+}
+
+// logs reports whether the named command may log; help does not
+func logs(name string) bool {
+	switch name {
+	case "help", "-h", "--help":
+		return false
+	}
+	for _, cmd := range commands() {
+		if cmd.Name == name {
+			return !cmd.NoLog
+		}
+	}
+	return true
+}
+
+// runCommand dispatches a subcommand and returns the process exit code
+func runCommand(name string, args []string) int {
+	switch name {
+	case "help", "-h", "--help":
+		printUsage(os.Stdout)
+		return 0
+	}
+	for _, cmd := range commands() {
+		if cmd.Name == name {
+			return cmd.Run(args)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Unknown command: %s\n", name)
+	printUsage(os.Stderr)
+	return 2
+}
+
+// printUsage lists the global flags and commands
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: emrys [--config path] [--output text|json] [--verbose] [command]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Without a command, emrys walks you through setting up this Mac.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands() {
+		fmt.Fprintf(w, "  %-34s %s\n", strings.TrimSpace(cmd.Name+" "+cmd.Args), cmd.Summary)
+	}
+}
+
+// printJSON writes v to stdout as indented JSON and returns the exit code
+func printJSON(v any) int {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
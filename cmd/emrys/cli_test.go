@@ -0,0 +1,305 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anicolao/emrys/internal/approval"
+	"github.com/anicolao/emrys/internal/audit"
+	"github.com/anicolao/emrys/internal/config"
+)
+
+// resetGlobals clears the global flags before and after a test
+func resetGlobals(t *testing.T) {
+	t.Helper()
+	globals = globalOptions{}
+	t.Cleanup(func() { globals = globalOptions{} })
+}
+
+// captureStdout runs fn with standard output redirected and returns what
+// it printed along with its exit code
+func captureStdout(t *testing.T, fn func() int) (string, int) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Pipe failed: %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	output := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		output <- string(data)
+	}()
+
+	code := fn()
+	w.Close()
+	return <-output, code
+}
+
+func TestParseGlobals(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string
+		globals globalOptions
+		wantErr bool
+	}{
+		{
+			name:    "output before the command",
+			args:    []string{"--output=json", "voice", "say", "-o", "x"},
+			want:    []string{"voice", "say", "-o", "x"},
+			globals: globalOptions{JSON: true},
+		},
+		{
+			name:    "short output with separate value",
+			args:    []string{"-o", "json", "status"},
+			want:    []string{"status"},
+			globals: globalOptions{JSON: true},
+		},
+		{
+			name:    "text output",
+			args:    []string{"-o", "json", "--output", "text", "status"},
+			want:    []string{"status"},
+			globals: globalOptions{},
+		},
+		{
+			name: "flags after the command are the command's",
+			args: []string{"voice", "--quiet", "say", "x"},
+			want: []string{"voice", "--quiet", "say", "x"},
+		},
+		{
+			name: "global flags after the command are the command's",
+			args: []string{"voice", "say", "--verbose", "x"},
+			want: []string{"voice", "say", "--verbose", "x"},
+		},
+		{
+			name:    "config and verbose",
+			args:    []string{"--config", "/tmp/c.yaml", "-v", "doctor"},
+			want:    []string{"doctor"},
+			globals: globalOptions{Config: "/tmp/c.yaml", Verbose: true},
+		},
+		{
+			name: "double dash ends the global flags",
+			args: []string{"--", "--odd"},
+			want: []string{"--odd"},
+		},
+		{
+			name: "help is left for the command",
+			args: []string{"--help"},
+			want: []string{"--help"},
+		},
+		{
+			name:    "no command",
+			args:    []string{"--verbose"},
+			want:    nil,
+			globals: globalOptions{Verbose: true},
+		},
+		{name: "unknown global flag", args: []string{"--quiet", "voice", "say", "x"}, wantErr: true},
+		{name: "unknown short flag", args: []string{"-q", "status"}, wantErr: true},
+		{name: "bad output format", args: []string{"--output=yaml", "status"}, wantErr: true},
+		{name: "missing value", args: []string{"--config"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetGlobals(t)
+			got, err := parseGlobals(tt.args)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseGlobals failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected args %q, got %q", tt.want, got)
+			}
+			if globals != tt.globals {
+				t.Errorf("Expected globals %+v, got %+v", tt.globals, globals)
+			}
+		})
+	}
+}
+
+func TestLogs(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"status", true},
+		{"completion", false},
+		{"help", false},
+		{"--help", false},
+		{"unknown", true},
+	}
+
+	for _, tt := range tests {
+		if got := logs(tt.name); got != tt.want {
+			t.Errorf("logs(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRunCommandDispatch(t *testing.T) {
+	output, code := captureStdout(t, func() int { return runCommand("help", nil) })
+	if code != 0 || !strings.Contains(output, "Commands:") {
+		t.Errorf("Expected usage with exit code 0, got %d: %q", code, output)
+	}
+
+	if code := runCommand("no-such-command", nil); code != 2 {
+		t.Errorf("Expected exit code 2 for an unknown command, got %d", code)
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		t.Run(shell, func(t *testing.T) {
+			output, code := captureStdout(t, func() int { return runCompletion([]string{shell}) })
+			if code != 0 {
+				t.Fatalf("Expected exit code 0, got %d", code)
+			}
+			for _, cmd := range commands() {
+				if !strings.Contains(output, cmd.Name) {
+					t.Errorf("Expected %s completion to offer %s", shell, cmd.Name)
+				}
+				for _, sub := range cmd.Subcommands {
+					if !strings.Contains(output, sub) {
+						t.Errorf("Expected %s completion to offer %s %s", shell, cmd.Name, sub)
+					}
+				}
+			}
+		})
+	}
+
+	if code := runCompletion([]string{"tcsh"}); code != 2 {
+		t.Errorf("Expected exit code 2 for an unknown shell, got %d", code)
+	}
+}
+
+// writeConfig points config.DefaultPath at a config.yaml with the given
+// contents
+func writeConfig(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	t.Setenv(config.PathEnv, path)
+	return path
+}
+
+// decodeJSON fails the test unless output is a single JSON value
+func decodeJSON(t *testing.T, output string, v any) {
+	t.Helper()
+	if err := json.Unmarshal([]byte(output), v); err != nil {
+		t.Fatalf("Expected JSON output, got %q: %v", output, err)
+	}
+}
+
+func TestJSONOutput(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(audit.PathEnv, filepath.Join(t.TempDir(), "audit.jsonl"))
+	audit.SetDefault(nil)
+	t.Cleanup(func() { audit.SetDefault(nil) })
+
+	today := time.Now()
+	quiet := "voice:\n  enabled: true\n  quiet_hours: true\n  quiet_policy: defer\n  holidays: " +
+		today.Format(time.DateOnly) + "," + today.AddDate(0, 0, 1).Format(time.DateOnly) + "\n"
+
+	tests := []struct {
+		name   string
+		config string
+		run    func() int
+		check  func(t *testing.T, output string)
+	}{
+		{
+			name:   "say while disabled",
+			config: "voice:\n  enabled: false\n",
+			run:    func() int { return runVoice([]string{"say", "hello"}) },
+			check: func(t *testing.T, output string) {
+				var result sayResult
+				decodeJSON(t, output, &result)
+				if result.Reason != "disabled" || result.Records == nil {
+					t.Errorf("Expected no records for disabled voice, got %+v", result)
+				}
+			},
+		},
+		{
+			name:   "say deferred for quiet hours",
+			config: quiet,
+			run:    func() int { return runVoice([]string{"say", "hello"}) },
+			check: func(t *testing.T, output string) {
+				var result sayResult
+				decodeJSON(t, output, &result)
+				if result.Reason != "deferred" {
+					t.Errorf("Expected the message to be deferred, got %+v", result)
+				}
+			},
+		},
+		{
+			name: "config validate",
+			run:  func() int { return runConfig([]string{"validate"}) },
+			check: func(t *testing.T, output string) {
+				var result configValidResult
+				decodeJSON(t, output, &result)
+				if !result.Valid || result.Path == "" {
+					t.Errorf("Expected a valid config, got %+v", result)
+				}
+			},
+		},
+		{
+			name: "approvals list",
+			run:  func() int { return runApprovals([]string{"list"}) },
+			check: func(t *testing.T, output string) {
+				var requests []approval.Request
+				decodeJSON(t, output, &requests)
+				if requests == nil || len(requests) != 0 {
+					t.Errorf("Expected an empty list, got %v", requests)
+				}
+			},
+		},
+		{
+			name: "audit verify",
+			run: func() int {
+				log, err := audit.Default()
+				if err != nil {
+					t.Fatalf("Default failed: %v", err)
+				}
+				log.Append(audit.Entry{Initiator: audit.InitiatorUser, Args: []string{"true"}})
+				return runAudit([]string{"verify"})
+			},
+			check: func(t *testing.T, output string) {
+				var result auditVerifyResult
+				decodeJSON(t, output, &result)
+				if !result.Intact || result.Entries != 1 || result.HeadHash == "" {
+					t.Errorf("Expected an intact log of one entry, got %+v", result)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetGlobals(t)
+			globals.JSON = true
+			writeConfig(t, tt.config)
+
+			output, code := captureStdout(t, tt.run)
+			if code != 0 {
+				t.Fatalf("Expected exit code 0, got %d with output %q", code, output)
+			}
+			tt.check(t, output)
+		})
+	}
+}
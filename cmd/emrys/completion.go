@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// runCompletion prints a completion script for bash, zsh or fish
+func runCompletion(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: emrys completion bash|zsh|fish")
+		return 2
+	}
+	switch args[0] {
+	case "bash":
+		writeBashCompletion(os.Stdout)
+	case "zsh":
+		writeZshCompletion(os.Stdout)
+	case "fish":
+		writeFishCompletion(os.Stdout)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown shell: %s\n", args[0])
+		fmt.Fprintln(os.Stderr, "Usage: emrys completion bash|zsh|fish")
+		return 2
+	}
+	return 0
+}
+
+// globalFlags are offered wherever a flag may appear
+const globalFlags = "--config --output --verbose"
+
+// commandNames returns the names of every command, including help
+func commandNames() []string {
+	names := []string{"help"}
+	for _, cmd := range commands() {
+		names = append(names, cmd.Name)
+	}
+	return names
+}
+
+// writeBashCompletion writes a bash completion script
+func writeBashCompletion(w io.Writer) {
+	fmt.Fprintln(w, "# bash completion for emrys; load with: source <(emrys completion bash)")
+	fmt.Fprintln(w, "_emrys() {")
+	fmt.Fprintln(w, `	local cur prev cmd i`)
+	fmt.Fprintln(w, `	cur="${COMP_WORDS[COMP_CWORD]}"`)
+	fmt.Fprintln(w, `	prev="${COMP_WORDS[COMP_CWORD-1]}"`)
+	fmt.Fprintln(w, `	for ((i = 1; i < COMP_CWORD; i++)); do`)
+	fmt.Fprintln(w, `		case "${COMP_WORDS[i]}" in`)
+	fmt.Fprintln(w, `		--config|--output|-o) ((i++)) ;;`)
+	fmt.Fprintln(w, `		-*) ;;`)
+	fmt.Fprintln(w, `		*) cmd="${COMP_WORDS[i]}"; break ;;`)
+	fmt.Fprintln(w, `		esac`)
+	fmt.Fprintln(w, `	done`)
+	// Global flags only come before the command
+	fmt.Fprintln(w, `	if [[ -z "$cmd" ]]; then`)
+	fmt.Fprintln(w, `		case "$prev" in`)
+	fmt.Fprintln(w, `		--config) COMPREPLY=($(compgen -f -- "$cur")); return ;;`)
+	fmt.Fprintln(w, `		--output|-o) COMPREPLY=($(compgen -W "text json" -- "$cur")); return ;;`)
+	fmt.Fprintln(w, `		esac`)
+	fmt.Fprintln(w, `		if [[ "$cur" == -* ]]; then`)
+	fmt.Fprintf(w, "\t\t\tCOMPREPLY=($(compgen -W %q -- \"$cur\"))\n", globalFlags)
+	fmt.Fprintln(w, `			return`)
+	fmt.Fprintln(w, `		fi`)
+	fmt.Fprintln(w, `	fi`)
+	fmt.Fprintln(w, `	case "$cmd" in`)
+	fmt.Fprintf(w, "\t\"\") COMPREPLY=($(compgen -W %q -- \"$cur\")) ;;\n", strings.Join(commandNames(), " "))
+	for _, cmd := range commands() {
+		if len(cmd.Subcommands) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\t%s) [[ $i -eq $((COMP_CWORD-1)) ]] && COMPREPLY=($(compgen -W %q -- \"$cur\")) ;;\n",
+			cmd.Name, strings.Join(cmd.Subcommands, " "))
+	}
+	fmt.Fprintln(w, `	esac`)
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w, "complete -F _emrys emrys")
+}
+
+// writeZshCompletion writes a zsh completion script
+func writeZshCompletion(w io.Writer) {
+	fmt.Fprintln(w, "#compdef emrys")
+	fmt.Fprintln(w, "# zsh completion for emrys; load with: source <(emrys completion zsh)")
+	fmt.Fprintln(w, "_emrys() {")
+	fmt.Fprintln(w, "\tlocal -a commands")
+	fmt.Fprintln(w, "\t_arguments -C \\")
+	fmt.Fprintln(w, "\t\t'--config[path to config.yaml]:file:_files' \\")
+	fmt.Fprintln(w, "\t\t'(-o --output)'{-o,--output}'[output format]:format:(text json)' \\")
+	fmt.Fprintln(w, "\t\t'(-v --verbose)'{-v,--verbose}'[log debug messages]' \\")
+	fmt.Fprintln(w, "\t\t'1:command:->command' \\")
+	fmt.Fprintln(w, "\t\t'*::argument:->argument'")
+	fmt.Fprintln(w, "\tcase $state in")
+	fmt.Fprintln(w, "\tcommand)")
+	fmt.Fprintln(w, "\t\tcommands=(")
+	fmt.Fprintln(w, "\t\t\t'help:Show help'")
+	for _, cmd := range commands() {
+		fmt.Fprintf(w, "\t\t\t'%s:%s'\n", cmd.Name, zshQuote(cmd.Summary))
+	}
+	fmt.Fprintln(w, "\t\t)")
+	fmt.Fprintln(w, "\t\t_describe command commands")
+	fmt.Fprintln(w, "\t\t;;")
+	fmt.Fprintln(w, "\targument)")
+	fmt.Fprintln(w, "\t\t(( CURRENT == 2 )) || return")
+	fmt.Fprintln(w, "\t\tcase $words[1] in")
+	for _, cmd := range commands() {
+		if len(cmd.Subcommands) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\t\t%s) compadd %s ;;\n", cmd.Name, strings.Join(cmd.Subcommands, " "))
+	}
+	fmt.Fprintln(w, "\t\tesac")
+	fmt.Fprintln(w, "\t\t;;")
+	fmt.Fprintln(w, "\tesac")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w, `_emrys "$@"`)
+}
+
+// zshQuote escapes a description for a single-quoted _describe entry
+func zshQuote(s string) string {
+	s = strings.ReplaceAll(s, "'", `'\''`)
+	return strings.ReplaceAll(s, ":", `\:`)
+}
+
+// writeFishCompletion writes a fish completion script
+func writeFishCompletion(w io.Writer) {
+	fmt.Fprintln(w, "# fish completion for emrys; load with: emrys completion fish | source")
+	fmt.Fprintln(w, "complete -c emrys -f")
+	fmt.Fprintln(w, "complete -c emrys -n __fish_use_subcommand -l config -r -F -d 'Path to config.yaml'")
+	fmt.Fprintln(w, "complete -c emrys -n __fish_use_subcommand -s o -l output -x -a 'text json' -d 'Output format'")
+	fmt.Fprintln(w, "complete -c emrys -n __fish_use_subcommand -s v -l verbose -d 'Log debug messages'")
+	fmt.Fprintln(w, "complete -c emrys -n __fish_use_subcommand -a help -d 'Show help'")
+	for _, cmd := range commands() {
+		fmt.Fprintf(w, "complete -c emrys -n __fish_use_subcommand -a %s -d '%s'\n", cmd.Name, strings.ReplaceAll(cmd.Summary, "'", `\'`))
+	}
+	for _, cmd := range commands() {
+		if len(cmd.Subcommands) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "complete -c emrys -n '__fish_seen_subcommand_from %s' -a '%s'\n", cmd.Name, strings.Join(cmd.Subcommands, " "))
+	}
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/anicolao/emrys/internal/config"
+)
+
+// runConfig reads and changes ~/.config/emrys/config.yaml
+func runConfig(args []string) int {
+	usage := func() {
+		fmt.Fprintln(os.Stderr, "Usage:")
+		fmt.Fprintln(os.Stderr, "  emrys config get <setting|section>")
+		fmt.Fprintln(os.Stderr, "  emrys config set <setting> <value>")
+		fmt.Fprintln(os.Stderr, "  emrys config edit")
+		fmt.Fprintln(os.Stderr, "  emrys config validate [file]")
+		fmt.Fprintln(os.Stderr, "  emrys config show [--changed]")
+	}
+	if len(args) == 0 {
+		usage()
+		return 2
+	}
+
+	path := config.DefaultPath()
+
+	switch args[0] {
+	case "get":
+		if len(args) != 2 {
+			usage()
+			return 2
+		}
+		return configGet(path, args[1])
+	case "set":
+		if len(args) != 3 {
+			usage()
+			return 2
+		}
+		return configSet(path, args[1], args[2])
+	case "edit":
+		if len(args) != 1 {
+			usage()
+			return 2
+		}
+		return configEdit(path)
+	case "validate":
+		if len(args) > 2 {
+			usage()
+			return 2
+		}
+		if len(args) == 2 {
+			path = args[1]
+		}
+		if _, err := config.Load(path); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		if globals.JSON {
+			return printJSON(configValidResult{Path: path, Valid: true})
+		}
+		fmt.Printf("✓ %s is valid\n", path)
+		return 0
+	case "show":
+		flags := flag.NewFlagSet("config show", flag.ContinueOnError)
+		changed := flags.Bool("changed", false, "only list settings that differ from the defaults")
+		if err := flags.Parse(args[1:]); err != nil || flags.NArg() != 0 {
+			usage()
+			return 2
+		}
+		return configShow(path, *changed)
+	default:
+		usage()
+		return 2
+	}
+}
+
+// configGet prints one setting, or every setting in a section
+func configGet(path, key string) int {
+	settings, err := config.Load(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	if f, err := settings.Field(key); err == nil {
+		fmt.Println(f.String())
+		return 0
+	}
+	found := false
+	for _, f := range settings.Fields() {
+		if strings.HasPrefix(f.Path, key+".") {
+			fmt.Printf("%s = %s\n", f.Path, f.String())
+			found = true
+		}
+	}
+	if !found {
+		fmt.Fprintf(os.Stderr, "Error: unknown setting %q\n", key)
+		return 1
+	}
+	return 0
+}
+
+// configSet changes one setting in config.yaml, keeping its comments
+func configSet(path, key, value string) int {
+	doc, err := config.LoadDocument(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if err := doc.Set(key, value); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if err := doc.Save(path); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	settings, _ := doc.Config()
+	f, _ := settings.Field(key)
+	fmt.Printf("✓ %s = %s\n", key, f.String())
+	if _, ok := os.LookupEnv(f.Env()); ok {
+		fmt.Printf("Note: %s is set and overrides this setting\n", f.Env())
+	}
+	return 0
+}
+
+// configEdit opens config.yaml in $EDITOR and saves it only once it is valid
+func configEdit(path string) int {
+	original, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		original, err = config.Marshal(config.Default())
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	tmp, err := os.CreateTemp("", "emrys-config-*.yaml")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(original)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	for {
+		if err := runEditor(tmp.Name()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		edited, err := os.ReadFile(tmp.Name())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		if bytes.Equal(edited, original) {
+			fmt.Println("No changes")
+			return 0
+		}
+
+		if _, err := config.Parse(edited); err != nil {
+			fmt.Fprintf(os.Stderr, "%s is not valid:\n%v\n", filepath.Base(path), err)
+			if confirm("Edit again?") {
+				continue
+			}
+			fmt.Println("Changes discarded")
+			return 1
+		}
+
+		if err := config.WriteFile(path, edited); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Printf("✓ Saved %s\n", path)
+		return 0
+	}
+}
+
+// runEditor opens file in $VISUAL or $EDITOR, which may include arguments
+// such as "code --wait"
+func runEditor(file string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	cmd := exec.Command("sh", "-c", editor+` "$1"`, "sh", file)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor failed: %w", err)
+	}
+	return nil
+}
+
+// configShow prints the effective configuration, or only the settings that
+// differ from the defaults
+func configShow(path string, changed bool) int {
+	settings, err := config.Load(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	if !changed {
+		data, err := config.Marshal(settings)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		os.Stdout.Write(data)
+		return 0
+	}
+
+	fields := config.Changed(settings)
+	if len(fields) == 0 {
+		fmt.Println("All settings have their default values")
+		return 0
+	}
+	defaults := config.Default()
+	for _, f := range fields {
+		d, _ := defaults.Field(f.Path)
+		fmt.Printf("%s = %s (default: %s)\n", f.Path, f.String(), d.String())
+	}
+	return 0
+}
+
+// configValidResult is what 'emrys config validate' prints as JSON
+type configValidResult struct {
+	Path  string `json:"path"`
+	Valid bool   `json:"valid"`
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/user"
+	"time"
+
+	"github.com/anicolao/emrys/internal/diagnose"
+	"github.com/anicolao/emrys/internal/doctor"
+)
+
+// runDiagnose writes a diagnostic bundle to attach to a bug report
+func runDiagnose(args []string) int {
+	usage := func() {
+		fmt.Fprintln(os.Stderr, "Usage: emrys diagnose --bundle [--entries n] [path]")
+	}
+	env := doctor.DefaultEnv()
+	flags := flag.NewFlagSet("diagnose", flag.ContinueOnError)
+	bundle := flags.Bool("bundle", false, "write a .tar.gz bundle")
+	entries := flags.Int("entries", env.Settings.Diagnose.Entries, "recent audit and log entries to include (0: all)")
+	if err := flags.Parse(args); err != nil || !*bundle || flags.NArg() > 1 {
+		usage()
+		return 2
+	}
+	env.Settings.Diagnose.Entries = *entries
+
+	now := time.Now()
+	path := fmt.Sprintf("emrys-diagnose-%s.tar.gz", now.Format("20060102-150405"))
+	if flags.NArg() == 1 {
+		path = flags.Arg(0)
+	}
+
+	username := ""
+	if u, err := user.Current(); err == nil {
+		username = u.Username
+	}
+	redactor, err := diagnose.NewRedactor(env.Settings.Diagnose, username, env.Home)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to create bundle: %v\n", err)
+		return 1
+	}
+	if !globals.JSON {
+		fmt.Println("Collecting diagnostics...")
+	}
+	files, err := diagnose.Write(context.Background(), f, diagnose.Files(env), redactor, now)
+	if closeErr := f.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to write bundle: %w", closeErr)
+	}
+	if err != nil {
+		os.Remove(path)
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	if globals.JSON {
+		return printJSON(map[string]any{"bundle": path, "files": files})
+	}
+	for _, file := range files {
+		if file.Error != "" {
+			fmt.Printf("  - %s: %s\n", file.Name, file.Error)
+		} else {
+			fmt.Printf("  ✓ %s\n", file.Name)
+		}
+	}
+	fmt.Printf("\n✓ Wrote %s\n", path)
+	fmt.Println("Look it over, then attach it to your bug report.")
+	return 0
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/anicolao/emrys/internal/doctor"
+)
+
+// runDoctor checks the installation, explains how to fix what is wrong and
+// exits non-zero if any check fails
+func runDoctor(args []string) int {
+	if len(args) != 0 {
+		fmt.Fprintln(os.Stderr, "Usage: emrys doctor")
+		return 2
+	}
+
+	report := doctor.Run(context.Background(), doctor.Checks(doctor.DefaultEnv()))
+	if globals.JSON {
+		printJSON(report)
+	} else {
+		report.WriteText(os.Stdout)
+	}
+	if !report.OK {
+		return 1
+	}
+	return 0
+}
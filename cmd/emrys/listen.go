@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/anicolao/emrys/internal/chat"
+	"github.com/anicolao/emrys/internal/config"
+	"github.com/anicolao/emrys/internal/voice"
+	"github.com/anicolao/emrys/internal/voice/stt"
+)
+
+// runListen holds a spoken conversation: it transcribes what it hears and
+// speaks the assistant's replies
+func runListen(args []string) int {
+	settings, err := config.Load(config.DefaultPath())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	listen := settings.Listen
+	if listen.ModelDir == "" {
+		listen.ModelDir = stt.DefaultModelDir()
+	}
+
+	flags := flag.NewFlagSet("listen", flag.ContinueOnError)
+	device := flags.String("device", listen.Device, "input device to record from (default: system default)")
+	stdin := flags.Bool("stdin", false, "read raw 16-bit mono PCM from standard input instead of a device")
+	rate := flags.Int("rate", stt.SampleRate, "sample rate of the input")
+	language := flags.String("language", listen.Language, "spoken language code, or 'auto' to detect it")
+	modelDir := flags.String("model-dir", listen.ModelDir, "directory containing whisper.cpp models")
+	model := flags.String("model", settings.Models.Chat, "chat model")
+	threshold := flags.Float64("threshold", listen.Threshold, "speech level as a fraction of full scale")
+	wake := flags.Bool("wake", listen.Wake, "only answer after a wake phrase such as \"Emrys, ...\"")
+	wakePhrases := flags.String("wake-phrases", strings.Join(listen.WakePhrases, ","), "comma-separated wake phrases")
+	wakeTimeout := flags.Duration("wake-timeout", listen.WakeTimeout, "how long to keep answering without the wake phrase")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "Usage: emrys listen [--device name | --stdin] [--rate hz] [--language code] [--wake]")
+		return 2
+	}
+
+	listen.Device = *device
+	listen.Language = *language
+	listen.ModelDir = *modelDir
+	listen.Threshold = *threshold
+	listen.Wake = *wake
+	listen.WakePhrases = strings.Split(*wakePhrases, ",")
+	listen.WakeTimeout = *wakeTimeout
+	settings.Models.Chat = *model
+
+	whisper := listen.Whisper()
+	if !whisper.Available() {
+		fmt.Fprintf(os.Stderr, "Error: whisper.cpp or a model in %s is missing\n", *modelDir)
+		return 1
+	}
+
+	speaker := voice.NewSpeaker(settings.Voice)
+	defer speaker.Close()
+	watcher, err := watchVoiceConfig(speaker)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	defer watcher.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var input io.Reader = os.Stdin
+	if !*stdin {
+		recording, err := stt.Capture(ctx, listen.Device, *rate)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		defer recording.Close()
+		input = recording
+	}
+
+	if err := converse(ctx, settings, listen, speaker, input, *rate); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// converse transcribes input, answers what it hears through speaker and
+// prints both sides until ctx is cancelled
+func converse(ctx context.Context, settings config.Config, listen config.Listen, speaker *voice.Speaker, input io.Reader, rate int) error {
+	engine := chat.NewEngine(settings.Client(), settings.ChatOptions())
+	handler := func(ctx context.Context, transcript stt.Transcript) error {
+		fmt.Printf("You: %s\n", transcript.Text())
+		reply, err := engine.SendSpoken(ctx, transcript.Text(), speaker, voice.PriorityNormal)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Emrys: %s\n", reply)
+		return nil
+	}
+
+	if listen.Wake {
+		gate := stt.NewWakeGate(handler, speaker)
+		gate.Phrases = listen.WakePhrases
+		gate.Timeout = listen.WakeTimeout
+		speaker.CachePhrases(gate.ArmMessage, gate.DisarmMessage)
+		defer gate.Disarm()
+		handler = gate.Handle
+	}
+
+	listener := &stt.Listener{
+		Transcriber: listen.Whisper(),
+		Handler:     handler,
+		SampleRate:  rate,
+		VAD:         listen.VAD(),
+		Speaker:     speaker,
+	}
+
+	if listen.Wake && len(listen.WakePhrases) > 0 {
+		fmt.Printf("Listening for %q. Press Ctrl-C to stop.\n", listen.WakePhrases[0])
+	} else {
+		fmt.Println("Listening. Press Ctrl-C to stop.")
+	}
+	if err := listener.Listen(ctx, input); err != nil && !errors.Is(err, context.Canceled) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/anicolao/emrys/internal/bootstrap"
+	"github.com/anicolao/emrys/internal/config"
+	"github.com/anicolao/emrys/internal/logging"
+	"github.com/anicolao/emrys/internal/nixdarwin"
+)
+
+// setupLogging shows log records on the terminal and writes them to the
+// rotating log file configured in config.yaml
+func setupLogging() {
+	settings, err := config.Load(config.DefaultPath())
+	if err != nil {
+		// The command itself reports the broken configuration
+		settings = config.Default()
+	}
+
+	consoleLevel := slog.LevelInfo
+	if globals.Verbose {
+		consoleLevel = slog.LevelDebug
+	}
+	// Keep standard output clean for JSON
+	var out io.Writer = os.Stdout
+	if globals.JSON {
+		out = os.Stderr
+	}
+	handlers := []slog.Handler{logging.NewConsoleHandler(out, os.Stderr, consoleLevel)}
+
+	file, err := logging.OpenFile(settings.Log.Path(), settings.Log.FileOptions())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: logging to the terminal only: %v\n", err)
+	} else {
+		handlers = append(handlers, logging.NewFileHandler(file, settings.Log.SlogLevel()))
+	}
+
+	logger := slog.New(logging.Tee(handlers...))
+	slog.SetDefault(logger)
+	bootstrap.SetLogger(logging.Component(logger, "bootstrap"))
+	nixdarwin.SetLogger(logging.Component(logger, "nixdarwin"))
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"bufio"
 	"fmt"
+	"log/slog"
 	"os"
 	"strings"
 
@@ -12,6 +13,29 @@ import (
 )
 
 func main() {
+	args, err := parseGlobals(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+	applyGlobals(args)
+
+	// Upgrade files written by older versions; "emrys migrate" reports on
+	// its own and can be asked only to check
+	if len(args) == 0 || (args[0] != "migrate" && args[0] != "completion") {
+		autoMigrate()
+	}
+
+	// Subcommands for scripting and administering Emrys, e.g. over SSH
+	if len(args) > 0 {
+		os.Exit(runCommand(args[0], args[1:]))
+	}
+	os.Exit(runGuided())
+}
+
+// runGuided walks through installing nix-darwin and the next incomplete
+// bootstrap phase, asking before each step
+func runGuided() int {
 	fmt.Println("╔════════════════════════════════════════╗")
 	fmt.Println("║           Emrys Setup                  ║")
 	fmt.Println("║  Your Personal AI Assistant on macOS  ║")
@@ -30,17 +54,17 @@ func main() {
 
 			if !confirm("Would you like to run Phase 1 bootstrap now?") {
 				fmt.Println("Bootstrap cancelled. Run this command again when ready.")
-				return
+				return 0
 			}
 
 			fmt.Println()
 			if err := bootstrap.RunPhase1(); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
+				slog.Error("{phase} failed", "phase", "Phase 1", "error", err)
+				return 1
 			}
 
 			fmt.Println()
-			return
+			return 0
 		}
 
 		fmt.Println("✓ Phase 1 bootstrap is complete!")
@@ -53,20 +77,20 @@ func main() {
 
 			if !confirm("Would you like to run Phase 2 bootstrap now?") {
 				fmt.Println("Bootstrap cancelled. Run this command again when ready.")
-				return
+				return 0
 			}
 
 			fmt.Println()
 			if err := bootstrap.RunPhase2(); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
+				slog.Error("{phase} failed", "phase", "Phase 2", "error", err)
+				return 1
 			}
 
 			fmt.Println()
 			fmt.Println("Next steps:")
 			fmt.Println("  - Run this command again to continue with Phase 3 (Voice Output)")
 			fmt.Println()
-			return
+			return 0
 		}
 
 		fmt.Println("✓ Phase 2 bootstrap is complete!")
@@ -79,13 +103,13 @@ func main() {
 
 			if !confirm("Would you like to run Phase 3 bootstrap now?") {
 				fmt.Println("Bootstrap cancelled. Run this command again when ready.")
-				return
+				return 0
 			}
 
 			fmt.Println()
 			if err := bootstrap.RunPhase3(); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
+				slog.Error("{phase} failed", "phase", "Phase 3", "error", err)
+				return 1
 			}
 
 			fmt.Println()
@@ -93,13 +117,13 @@ func main() {
 			fmt.Println("  - Phase 4 will set up the TUI application")
 			fmt.Println("  - Phase 5 will configure tmux session management")
 			fmt.Println()
-			return
+			return 0
 		}
 
 		fmt.Println("✓ Phase 3 bootstrap is complete!")
 		fmt.Println()
 		fmt.Println("Emrys is ready to use.")
-		return
+		return 0
 	}
 
 	fmt.Println("⚠ nix-darwin is not installed yet.")
@@ -114,11 +138,33 @@ func main() {
 	// Check if we should proceed
 	if !confirm("Would you like to proceed with the installation?") {
 		fmt.Println("Installation cancelled.")
-		return
+		return 0
 	}
 
 	fmt.Println()
 
+	if err := installNixDarwin(); err != nil {
+		slog.Error("nix-darwin installation failed", "error", err)
+		return 1
+	}
+
+	fmt.Println()
+	fmt.Println("════════════════════════════════════════")
+	fmt.Println("✓ Setup completed successfully!")
+	fmt.Println()
+	fmt.Println("nix-darwin has been installed and configured.")
+	fmt.Println("You may need to restart your terminal for all changes to take effect.")
+	fmt.Println()
+	fmt.Println("Next steps:")
+	fmt.Println("  - Edit ~/.nixpkgs/darwin-configuration.nix to customize your setup")
+	fmt.Println("  - Run 'darwin-rebuild switch' to apply configuration changes")
+	fmt.Println("════════════════════════════════════════")
+	return 0
+}
+
+// installNixDarwin installs Nix if needed and then nix-darwin with the
+// embedded configuration
+func installNixDarwin() error {
 	// Step 1: Check and install Nix if needed
 	if !nixdarwin.IsNixInstalled() {
 		fmt.Println("Step 1: Installing Nix...")
@@ -126,8 +172,7 @@ func main() {
 		fmt.Println()
 
 		if err := nixdarwin.InstallNix(); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
+			return err
 		}
 		fmt.Println()
 	} else {
@@ -139,22 +184,7 @@ func main() {
 	fmt.Println("Step 2: Installing nix-darwin...")
 
 	// Use the embedded configuration and flake
-	if err := nixdarwin.InstallNixDarwinWithFlake(config.DefaultNixDarwinConfig, config.DefaultFlakeConfig); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
-	}
-
-	fmt.Println()
-	fmt.Println("════════════════════════════════════════")
-	fmt.Println("✓ Setup completed successfully!")
-	fmt.Println()
-	fmt.Println("nix-darwin has been installed and configured.")
-	fmt.Println("You may need to restart your terminal for all changes to take effect.")
-	fmt.Println()
-	fmt.Println("Next steps:")
-	fmt.Println("  - Edit ~/.nixpkgs/darwin-configuration.nix to customize your setup")
-	fmt.Println("  - Run 'darwin-rebuild switch' to apply configuration changes")
-	fmt.Println("════════════════════════════════════════")
+	return nixdarwin.InstallNixDarwinWithFlake(config.DefaultNixDarwinConfig, config.DefaultFlakeConfig)
 }
 
 // confirm prompts the user for a yes/no confirmation
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/anicolao/emrys/internal/bootstrap"
+	"github.com/anicolao/emrys/internal/config"
+	"github.com/anicolao/emrys/internal/migrate"
+)
+
+// versionedFiles lists every file Emrys persists with a format version
+func versionedFiles() []migrate.File {
+	return []migrate.File{
+		{Format: config.Format, Path: config.DefaultPath()},
+		{Format: bootstrap.StateFormat, Path: bootstrap.GetStatePath()},
+	}
+}
+
+// runMigrate upgrades persisted files to the formats this version writes
+func runMigrate(args []string) int {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	check := flags.Bool("check", false, "report pending migrations without applying them; exit 1 if there are any")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "Usage: emrys migrate [--check]")
+		return 2
+	}
+
+	code := 0
+	switch {
+	case !*check:
+		if !importVoiceConf() {
+			code = 1
+		}
+	case config.VoiceConfPending(config.DefaultPath(), config.VoiceConfPath()):
+		fmt.Printf("⚠ voice.conf: needs importing into %s\n", config.DefaultPath())
+		code = 1
+	}
+	for _, f := range versionedFiles() {
+		var status migrate.Status
+		var err error
+		if *check {
+			status, err = migrate.Check(f)
+		} else {
+			status, err = migrate.Apply(f, time.Now())
+		}
+		switch {
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+			code = 1
+		case !status.Exists:
+			fmt.Printf("- %s: not created yet\n", f.Name)
+		case len(status.Pending) == 0:
+			fmt.Printf("✓ %s: version %d is current\n", f.Name, status.Version)
+		case *check:
+			fmt.Printf("⚠ %s: version %d needs migrating to %d\n", f.Name, status.Version, f.Current)
+			fmt.Println(indent(status.Describe()))
+			code = 1
+		default:
+			fmt.Printf("✓ %s: migrated from version %d to %d (backup: %s)\n", f.Name, status.Version, f.Current, status.Backup)
+			fmt.Println(indent(status.Describe()))
+		}
+	}
+	return code
+}
+
+// autoMigrate brings persisted files up to date before a command runs,
+// reporting what it changed on stderr
+func autoMigrate() {
+	importVoiceConf()
+	for _, f := range versionedFiles() {
+		status, err := migrate.Apply(f, time.Now())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			continue
+		}
+		if status.Backup != "" {
+			fmt.Fprintf(os.Stderr, "Migrated %s from version %d to %d (backup: %s)\n", f.Name, status.Version, f.Current, status.Backup)
+		}
+	}
+}
+
+// importVoiceConf creates config.yaml from the voice.conf that older
+// versions wrote, reporting on stderr, and reports whether it succeeded
+func importVoiceConf() bool {
+	imported, err := config.MigrateVoiceConf(config.DefaultPath(), config.VoiceConfPath())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		return false
+	}
+	if imported {
+		fmt.Fprintf(os.Stderr, "Imported %s into %s\n", config.VoiceConfPath(), config.DefaultPath())
+	}
+	return true
+}
+
+// indent prefixes every line of s with four spaces
+func indent(s string) string {
+	return "    " + strings.ReplaceAll(s, "\n", "\n    ")
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/anicolao/emrys/internal/bootstrap"
+	"github.com/anicolao/emrys/internal/config"
+	"github.com/anicolao/emrys/internal/llm"
+)
+
+// runModel lists, downloads and chooses Ollama models
+func runModel(args []string) int {
+	usage := func() {
+		fmt.Fprintln(os.Stderr, "Usage:")
+		fmt.Fprintln(os.Stderr, "  emrys model [list]")
+		fmt.Fprintln(os.Stderr, "  emrys model pull <name>")
+		fmt.Fprintln(os.Stderr, "  emrys model use <name>")
+	}
+
+	settings, err := config.Load(config.DefaultPath())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if len(args) == 0 || args[0] == "list" {
+		models, err := settings.Client().List(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		if globals.JSON {
+			return printJSON(models)
+		}
+		if len(models) == 0 {
+			fmt.Println("No models installed")
+			return 0
+		}
+		for _, m := range models {
+			current := " "
+			if hasModel([]llm.Model{m}, settings.Models.Chat) {
+				current = "*"
+			}
+			fmt.Printf("%s %-32s %6.1f GB  %s\n", current, m.Name, float64(m.Size)/1e9, m.ModifiedAt.Local().Format(time.DateOnly))
+		}
+		return 0
+	}
+
+	if len(args) != 2 {
+		usage()
+		return 2
+	}
+	name := args[1]
+
+	switch args[0] {
+	case "pull":
+		if err := bootstrap.DownloadModel(name); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		return 0
+
+	case "use":
+		if models, err := settings.Client().List(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not check that %s is installed: %v\n", name, err)
+		} else if !hasModel(models, name) {
+			fmt.Fprintf(os.Stderr, "Error: model %s is not installed; run 'emrys model pull %s' first\n", name, name)
+			return 1
+		}
+		return configSet(config.DefaultPath(), "models.chat", name)
+
+	default:
+		usage()
+		return 2
+	}
+}
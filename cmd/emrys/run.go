@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/anicolao/emrys/internal/bootstrap"
+	"github.com/anicolao/emrys/internal/config"
+	"github.com/anicolao/emrys/internal/logging"
+	"github.com/anicolao/emrys/internal/supervisor"
+	"github.com/anicolao/emrys/internal/voice"
+	"github.com/anicolao/emrys/internal/voice/stt"
+)
+
+// runAssistant runs Emrys in the foreground until interrupted, answering
+// when spoken to by its wake phrase
+func runAssistant(args []string) int {
+	if len(args) != 0 {
+		fmt.Fprintln(os.Stderr, "Usage: emrys run")
+		return 2
+	}
+
+	settings, err := config.Load(config.DefaultPath())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	listen := settings.Listen
+	if listen.ModelDir == "" {
+		listen.ModelDir = stt.DefaultModelDir()
+	}
+	// Always listening, so only answer when addressed
+	listen.Wake = true
+
+	speaker := voice.NewSpeaker(settings.Voice)
+	defer speaker.Close()
+	watcher, err := watchVoiceConfig(speaker)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	defer watcher.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Keep Ollama up while Emrys runs, saying when it goes down
+	if settings.Supervisor.Enabled {
+		health := supervisor.New(settings.Supervisor.Options(), speaker, logging.Component(slog.Default(), "supervisor"))
+		health.Add(supervisor.Ollama(settings.Client(), bootstrap.OllamaAgent))
+		go health.Run(ctx)
+	}
+
+	if !listen.Whisper().Available() {
+		fmt.Fprintf(os.Stderr, "Speech input is unavailable: whisper.cpp or a model in %s is missing\n", listen.ModelDir)
+		fmt.Println("Running without speech input. Press Ctrl-C to stop.")
+		<-ctx.Done()
+		return 0
+	}
+
+	recording, err := stt.Capture(ctx, listen.Device, stt.SampleRate)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	defer recording.Close()
+
+	if err := converse(ctx, settings, listen, speaker, recording, stt.SampleRate); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
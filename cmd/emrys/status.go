@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/anicolao/emrys/internal/bootstrap"
+	"github.com/anicolao/emrys/internal/config"
+	"github.com/anicolao/emrys/internal/llm"
+)
+
+// statusReport is what "emrys status" prints
+type statusReport struct {
+	Config      string        `json:"config"`
+	ConfigError string        `json:"config_error,omitempty"`
+	Phases      []phaseStatus `json:"phases"`
+	Ollama      ollamaStatus  `json:"ollama"`
+	Voice       voiceStatus   `json:"voice"`
+}
+
+// phaseStatus reports one setup phase
+type phaseStatus struct {
+	Name        string     `json:"name"`
+	Title       string     `json:"title"`
+	Complete    bool       `json:"complete"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// ollamaStatus reports the model server
+type ollamaStatus struct {
+	URL            string `json:"url"`
+	Running        bool   `json:"running"`
+	Model          string `json:"model"`
+	ModelInstalled bool   `json:"model_installed"`
+}
+
+// voiceStatus reports speech output
+type voiceStatus struct {
+	Enabled bool   `json:"enabled"`
+	Voice   string `json:"voice"`
+	Backend string `json:"backend"`
+}
+
+// gatherStatus inspects the installation
+func gatherStatus(ctx context.Context) statusReport {
+	report := statusReport{Config: config.DefaultPath()}
+	settings, err := config.Load(report.Config)
+	if err != nil {
+		report.ConfigError = err.Error()
+		settings = config.Default()
+	}
+
+	state, _ := bootstrap.LoadState()
+	for i, phase := range setupPhases() {
+		ps := phaseStatus{Name: phase.Name, Title: phase.Title, Complete: phase.Complete()}
+		if at, ok := state.Phases[i]; ok {
+			ps.CompletedAt = &at
+		}
+		report.Phases = append(report.Phases, ps)
+	}
+
+	report.Ollama = ollamaStatus{URL: settings.Ollama.URL, Model: settings.Models.Chat}
+	probe, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	if models, err := settings.Client().List(probe); err == nil {
+		report.Ollama.Running = true
+		report.Ollama.ModelInstalled = hasModel(models, settings.Models.Chat)
+	}
+
+	report.Voice = voiceStatus{
+		Enabled: settings.Voice.Enabled,
+		Voice:   settings.Voice.Voice,
+		Backend: settings.Voice.Backend,
+	}
+	return report
+}
+
+// hasModel reports whether name is among models, allowing for the implicit
+// ":latest" tag
+func hasModel(models []llm.Model, name string) bool {
+	for _, m := range models {
+		if m.Name == name || m.Name == name+":latest" {
+			return true
+		}
+	}
+	return false
+}
+
+// mark returns a check mark or a cross
+func mark(ok bool) string {
+	if ok {
+		return "✓"
+	}
+	return "✗"
+}
+
+// runStatus prints setup progress and whether services are up
+func runStatus(args []string) int {
+	if len(args) != 0 {
+		fmt.Fprintln(os.Stderr, "Usage: emrys status")
+		return 2
+	}
+
+	report := gatherStatus(context.Background())
+	if globals.JSON {
+		return printJSON(report)
+	}
+
+	fmt.Println("Setup:")
+	for _, phase := range report.Phases {
+		line := fmt.Sprintf("  %s %s", mark(phase.Complete), phase.Title)
+		if phase.CompletedAt != nil {
+			line += fmt.Sprintf(" (completed %s)", phase.CompletedAt.Local().Format(time.DateTime))
+		}
+		fmt.Println(line)
+	}
+
+	fmt.Println()
+	fmt.Println("Services:")
+	fmt.Printf("  %s Ollama at %s\n", mark(report.Ollama.Running), report.Ollama.URL)
+	fmt.Printf("  %s Model %s\n", mark(report.Ollama.ModelInstalled), report.Ollama.Model)
+	voiceLine := fmt.Sprintf("Voice %s (%s backend)", report.Voice.Voice, report.Voice.Backend)
+	if !report.Voice.Enabled {
+		voiceLine += ", disabled"
+	}
+	fmt.Printf("  %s %s\n", mark(report.Voice.Enabled), voiceLine)
+
+	fmt.Println()
+	if report.ConfigError != "" {
+		fmt.Printf("✗ %s\n", strings.ReplaceAll(report.ConfigError, "\n", "\n  "))
+	} else {
+		fmt.Printf("✓ Configuration: %s\n", report.Config)
+	}
+	return 0
+}
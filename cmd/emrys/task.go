@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	"github.com/anicolao/emrys/internal/agent"
+	"github.com/anicolao/emrys/internal/approval"
+	"github.com/anicolao/emrys/internal/config"
+	"github.com/anicolao/emrys/internal/tools"
+)
+
+// runTask hands a task to the agent, which may run shell commands to
+// complete it, and prints the agent's answer
+func runTask(args []string) int {
+	flags := flag.NewFlagSet("task", flag.ContinueOnError)
+	cwd := flags.String("cwd", "", "directory commands run in (default: current directory)")
+	if err := flags.Parse(args); err != nil || flags.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: emrys task [--cwd dir] <task>")
+		return 2
+	}
+
+	settings, err := config.Load(config.DefaultPath())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	// Every tool call goes through the approval policy; calls it asks about
+	// are put to the user, and "always" and "never" answers are remembered
+	memory, err := approval.LoadMemory(approval.DefaultMemoryPath())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	engine, err := approval.NewEngine(approval.DefaultPolicy(), approval.Options{
+		Approver: taskApprover(),
+		Memory:   memory,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	shell, err := tools.NewShellTool(tools.ShellPolicy{WorkDir: *cwd}, engine.SudoApprover())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	registry := agent.NewRegistry()
+	if err := registry.Register(shell); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	// Every step is appended to the record for later inspection
+	record, err := openRecord(settings.Agent.RecordPath())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	defer record.Close()
+	opts := settings.AgentOptions()
+	opts.Recorder = agent.NewJSONLRecorder(record)
+	opts.Authorizer = engine
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	transcript, err := agent.New(settings.Client(), registry, opts).Run(ctx, strings.Join(flags.Args(), " "))
+	if globals.JSON {
+		if code := printJSON(transcript); code != 0 {
+			return code
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if !globals.JSON {
+		fmt.Println(transcript.Answer)
+	}
+	return 0
+}
+
+// taskApprover asks on the terminal when there is one, and otherwise queues
+// requests to be answered with "emrys approvals"
+func taskApprover() approval.Approver {
+	if stdinIsTerminal() {
+		return approval.NewTerminalApprover(os.Stdin, os.Stderr)
+	}
+	return queueApprover{approval.NewQueueApprover(approval.DefaultQueueDir())}
+}
+
+// stdinIsTerminal reports whether someone can answer on standard input
+// /dev/null is a character device too, and is what launchd connects.
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	null, err := os.Stat(os.DevNull)
+	return err != nil || !os.SameFile(info, null)
+}
+
+// queueApprover tells the user how to answer each request it queues
+type queueApprover struct {
+	*approval.QueueApprover
+}
+
+// Approve queues the request and waits for an answer
+func (q queueApprover) Approve(ctx context.Context, req approval.Request) (approval.Answer, error) {
+	fmt.Fprintf(os.Stderr, "Waiting for approval to run %s (%s). Answer with:\n", req.Action.Tool, req.Rule)
+	fmt.Fprintf(os.Stderr, "  emrys approvals approve %s\n", req.ID)
+	fmt.Fprintf(os.Stderr, "  emrys approvals deny %s\n", req.ID)
+	return q.QueueApprover.Approve(ctx, req)
+}
+
+// openRecord opens the agent's step record for appending
+func openRecord(path string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open agent record: %w", err)
+	}
+	return file, nil
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/anicolao/emrys/internal/config"
+	"github.com/anicolao/emrys/internal/voice"
+)
+
+// runVoice speaks text, tests speech output and lists voices
+func runVoice(args []string) int {
+	usage := func() {
+		fmt.Fprintln(os.Stderr, "Usage:")
+		fmt.Fprintln(os.Stderr, "  emrys voice say [--priority chatter|normal|critical] <text>")
+		fmt.Fprintln(os.Stderr, "  emrys voice test")
+		fmt.Fprintln(os.Stderr, "  emrys voice voices")
+	}
+	if len(args) == 0 {
+		usage()
+		return 2
+	}
+
+	settings, err := config.Load(config.DefaultPath())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	switch args[0] {
+	case "say":
+		flags := flag.NewFlagSet("voice say", flag.ContinueOnError)
+		priorityName := flags.String("priority", "normal", "chatter, normal or critical")
+		if err := flags.Parse(args[1:]); err != nil || flags.NArg() == 0 {
+			usage()
+			return 2
+		}
+		priority, err := voice.ParsePriority(*priorityName)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		return say(settings.Voice, strings.Join(flags.Args(), " "), priority)
+
+	case "test":
+		if err := voice.Test(settings.Voice); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		if globals.JSON {
+			return printJSON(voiceTestResult{Works: true, Voice: settings.Voice.Voice})
+		}
+		fmt.Printf("✓ Voice output works with %s\n", settings.Voice.Voice)
+		return 0
+
+	case "voices":
+		voices, err := voice.ListAvailableVoices(settings.Voice)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		if globals.JSON {
+			return printJSON(voices)
+		}
+		for _, v := range voices {
+			if v == settings.Voice.Voice {
+				fmt.Printf("* %s\n", v)
+			} else {
+				fmt.Printf("  %s\n", v)
+			}
+		}
+		return 0
+
+	default:
+		usage()
+		return 2
+	}
+}
+
+// voiceTestResult is what 'emrys voice test' prints as JSON
+type voiceTestResult struct {
+	Works bool   `json:"works"`
+	Voice string `json:"voice"`
+}
+
+// sayResult is what 'emrys voice say' prints as JSON
+type sayResult struct {
+	Records []voice.Record `json:"records"`          // What happened to each sentence
+	Reason  string         `json:"reason,omitempty"` // Why nothing was spoken: "disabled" or "deferred"
+}
+
+// say speaks text through the speech queue, so that quiet hours and
+// priorities apply, and waits until it has been handled
+func say(cfg voice.Config, text string, priority voice.Priority) int {
+	if !cfg.Enabled {
+		if globals.JSON {
+			return printJSON(sayResult{Records: []voice.Record{}, Reason: "disabled"})
+		}
+		fmt.Println("Voice output is disabled; run 'emrys config set voice.enabled true' to enable it")
+		return 0
+	}
+
+	speaker := voice.NewSpeaker(cfg)
+	defer speaker.Close()
+
+	speaker.SpeakPriority(text, priority)
+	if err := waitForSpeech(speaker); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: stopped waiting for speech: %v\n", err)
+		return 1
+	}
+
+	// The speaker was made for this message, so its history is the message
+	result := sayResult{Records: speaker.History()}
+	if result.Records == nil {
+		result.Records = []voice.Record{}
+	}
+	if speaker.Pending() > 0 {
+		// Deferred for quiet hours, which this process will not outlast
+		if globals.JSON {
+			result.Reason = "deferred"
+			return printJSON(result)
+		}
+		fmt.Println("Not spoken: deferred until quiet hours end")
+		return 0
+	}
+
+	code := 0
+	for _, rec := range result.Records {
+		if rec.Status == voice.StatusFailed {
+			code = 1
+		}
+	}
+	if globals.JSON {
+		if printJSON(result) != 0 {
+			return 1
+		}
+		return code
+	}
+	for _, rec := range result.Records {
+		switch rec.Status {
+		case voice.StatusSpoken:
+		case voice.StatusFailed:
+			fmt.Fprintf(os.Stderr, "Error: %s\n", rec.Error)
+		default:
+			fmt.Printf("Not spoken: %s\n", rec.Status)
+		}
+	}
+	return code
+}
+
+// watchVoiceConfig applies changes to the voice section of config.yaml to
+// speaker until the returned watcher is closed
+func watchVoiceConfig(speaker *voice.Speaker) (*voice.ConfigWatcher, error) {
+	path := config.DefaultPath()
+	return voice.WatchConfig(path, func() (voice.Config, error) {
+		settings, err := config.Load(path)
+		return settings.Voice, err
+	}, speaker, voice.DefaultWatchInterval, nil)
+}
@@ -0,0 +1,130 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/anicolao/emrys/internal/audit"
+	"github.com/anicolao/emrys/internal/llm"
+)
+
+// DefaultMaxSteps is the default number of model round trips per task
+const DefaultMaxSteps = 10
+
+// ErrStepBudgetExhausted is returned when a task has not finished within MaxSteps
+var ErrStepBudgetExhausted = errors.New("agent step budget exhausted")
+
+// Options configures an Agent
+type Options struct {
+	Model        string     // Chat model with tool-calling support (default: llm.DefaultModel)
+	SystemPrompt string     // Optional system prompt sent before the task
+	MaxSteps     int        // Maximum model round trips per task (default: DefaultMaxSteps)
+	Recorder     Recorder   // Optional recorder that receives every step
+	Authorizer   Authorizer // Optional gate consulted before every tool call
+}
+
+// Agent runs tasks by letting the model call tools until it produces an answer
+type Agent struct {
+	client   *llm.Client
+	registry *Registry
+	executor *Executor
+	opts     Options
+}
+
+// New creates an agent that offers the tools in registry to the model
+func New(client *llm.Client, registry *Registry, opts Options) *Agent {
+	if opts.Model == "" {
+		opts.Model = llm.DefaultModel
+	}
+	if opts.MaxSteps <= 0 {
+		opts.MaxSteps = DefaultMaxSteps
+	}
+
+	return &Agent{
+		client:   client,
+		registry: registry,
+		executor: NewExecutor(registry, opts.Authorizer),
+		opts:     opts,
+	}
+}
+
+// Run executes a task. Each step sends the conversation to the model; if the
+// model requests tool calls they are executed and their results fed back,
+// otherwise the model's reply is the answer. The transcript is returned even
+// when the run fails.
+func (a *Agent) Run(ctx context.Context, task string) (*Transcript, error) {
+	transcript := &Transcript{Task: task}
+	ctx = audit.WithInitiator(ctx, auditInitiator(task))
+
+	var messages []llm.Message
+	if a.opts.SystemPrompt != "" {
+		messages = append(messages, llm.Message{Role: llm.RoleSystem, Content: a.opts.SystemPrompt})
+	}
+	messages = append(messages, llm.Message{Role: llm.RoleUser, Content: task})
+
+	for n := 1; n <= a.opts.MaxSteps; n++ {
+		step := Step{Number: n, Started: time.Now()}
+
+		resp, err := a.client.Chat(ctx, llm.ChatRequest{
+			Model:    a.opts.Model,
+			Messages: messages,
+			Tools:    a.registry.Specs(),
+		})
+		if err != nil {
+			step.Error = err.Error()
+			a.record(transcript, step)
+			return transcript, fmt.Errorf("step %d: chat request failed: %w", n, err)
+		}
+
+		reply := resp.Message
+		reply.Role = llm.RoleAssistant
+		step.Reply = reply.Content
+		messages = append(messages, reply)
+
+		if len(reply.ToolCalls) == 0 {
+			a.record(transcript, step)
+			transcript.Answer = reply.Content
+			return transcript, nil
+		}
+
+		for _, call := range reply.ToolCalls {
+			result := a.executor.Execute(ctx, call)
+			step.Tools = append(step.Tools, result)
+			messages = append(messages, llm.Message{
+				Role:     llm.RoleTool,
+				Content:  result.Content(),
+				ToolName: result.Name,
+			})
+		}
+		a.record(transcript, step)
+
+		if err := ctx.Err(); err != nil {
+			return transcript, err
+		}
+	}
+
+	return transcript, fmt.Errorf("%w after %d steps", ErrStepBudgetExhausted, a.opts.MaxSteps)
+}
+
+// auditInitiator attributes commands run for a task in the audit log
+func auditInitiator(task string) string {
+	const maxLen = 60
+	task = strings.Join(strings.Fields(task), " ")
+	if runes := []rune(task); len(runes) > maxLen {
+		task = string(runes[:maxLen]) + "…"
+	}
+	return audit.InitiatorAgent + ":" + task
+}
+
+// record finalizes a step and hands it to the transcript and recorder
+func (a *Agent) record(transcript *Transcript, step Step) {
+	step.Duration = time.Since(step.Started)
+	transcript.Steps = append(transcript.Steps, step)
+	if a.opts.Recorder != nil {
+		// Recording is best effort; a full disk must not stop the agent
+		_ = a.opts.Recorder.Record(step)
+	}
+}
@@ -0,0 +1,159 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/anicolao/emrys/internal/llm"
+)
+
+// scriptedOllama replies to /api/chat with the next scripted message
+func scriptedOllama(t *testing.T, replies []llm.Message, requests *[]llm.ChatRequest) *llm.Client {
+	n := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req llm.ChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if requests != nil {
+			*requests = append(*requests, req)
+		}
+
+		reply := replies[len(replies)-1]
+		if n < len(replies) {
+			reply = replies[n]
+		}
+		n++
+
+		json.NewEncoder(w).Encode(llm.ChatResponse{Message: reply, Done: true})
+	}))
+	t.Cleanup(server.Close)
+	return llm.NewClient(server.URL)
+}
+
+func toolCall(name, args string) llm.Message {
+	return llm.Message{
+		Role: llm.RoleAssistant,
+		ToolCalls: []llm.ToolCall{{
+			Function: llm.ToolCallFunction{Name: name, Arguments: json.RawMessage(args)},
+		}},
+	}
+}
+
+func TestRunWithToolCall(t *testing.T) {
+	var requests []llm.ChatRequest
+	client := scriptedOllama(t, []llm.Message{
+		toolCall("echo", `{"text":"pong"}`),
+		{Role: llm.RoleAssistant, Content: "The tool said pong."},
+	}, &requests)
+
+	registry := NewRegistry()
+	registry.Register(echoTool{name: "echo"})
+
+	var recorded bytes.Buffer
+	agent := New(client, registry, Options{Recorder: NewJSONLRecorder(&recorded)})
+
+	transcript, err := agent.Run(context.Background(), "ping the echo tool")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if transcript.Answer != "The tool said pong." {
+		t.Errorf("Unexpected answer: %q", transcript.Answer)
+	}
+	if len(transcript.Steps) != 2 {
+		t.Fatalf("Expected 2 steps, got %d", len(transcript.Steps))
+	}
+	if got := transcript.Steps[0].Tools[0].Output; got != "pong" {
+		t.Errorf("Expected tool output 'pong', got %q", got)
+	}
+
+	// The tool result must be fed back to the model as a tool message
+	last := requests[1].Messages[len(requests[1].Messages)-1]
+	if last.Role != llm.RoleTool || last.Content != "pong" || last.ToolName != "echo" {
+		t.Errorf("Unexpected tool message: %+v", last)
+	}
+	if len(requests[0].Tools) != 1 {
+		t.Errorf("Expected tools to be offered to the model, got %d", len(requests[0].Tools))
+	}
+
+	if lines := strings.Count(recorded.String(), "\n"); lines != 2 {
+		t.Errorf("Expected 2 recorded steps, got %d", lines)
+	}
+}
+
+func TestRunUnknownToolIsReported(t *testing.T) {
+	var requests []llm.ChatRequest
+	client := scriptedOllama(t, []llm.Message{
+		toolCall("missing", `{}`),
+		{Role: llm.RoleAssistant, Content: "done"},
+	}, &requests)
+
+	agent := New(client, NewRegistry(), Options{})
+	transcript, err := agent.Run(context.Background(), "task")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if transcript.Steps[0].Tools[0].Error == "" {
+		t.Error("Expected unknown tool error to be recorded")
+	}
+	last := requests[1].Messages[len(requests[1].Messages)-1]
+	if !strings.HasPrefix(last.Content, "error:") {
+		t.Errorf("Expected error fed back to model, got %q", last.Content)
+	}
+}
+
+func TestRunStepBudget(t *testing.T) {
+	client := scriptedOllama(t, []llm.Message{toolCall("echo", `{"text":"again"}`)}, nil)
+
+	registry := NewRegistry()
+	registry.Register(echoTool{name: "echo"})
+
+	agent := New(client, registry, Options{MaxSteps: 3})
+	transcript, err := agent.Run(context.Background(), "loop forever")
+	if !errors.Is(err, ErrStepBudgetExhausted) {
+		t.Fatalf("Expected ErrStepBudgetExhausted, got %v", err)
+	}
+	if len(transcript.Steps) != 3 {
+		t.Errorf("Expected 3 recorded steps, got %d", len(transcript.Steps))
+	}
+}
+
+// denyAuthorizer refuses every tool call
+type denyAuthorizer struct{ calls int }
+
+func (d *denyAuthorizer) Authorize(ctx context.Context, tool Tool, args json.RawMessage) (context.Context, error) {
+	d.calls++
+	return ctx, errors.New("not allowed")
+}
+
+func TestRunAuthorizerBlocksTool(t *testing.T) {
+	client := scriptedOllama(t, []llm.Message{
+		toolCall("echo", `{"text":"secret"}`),
+		{Role: llm.RoleAssistant, Content: "ok"},
+	}, nil)
+
+	registry := NewRegistry()
+	registry.Register(echoTool{name: "echo"})
+
+	authorizer := &denyAuthorizer{}
+	agent := New(client, registry, Options{Authorizer: authorizer})
+
+	transcript, err := agent.Run(context.Background(), "task")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	result := transcript.Steps[0].Tools[0]
+	if result.Output != "" || result.Error != "not allowed" {
+		t.Errorf("Expected tool to be blocked, got %+v", result)
+	}
+	if authorizer.calls != 1 {
+		t.Errorf("Expected authorizer to be consulted once, got %d", authorizer.calls)
+	}
+}
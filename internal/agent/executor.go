@@ -0,0 +1,69 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/anicolao/emrys/internal/llm"
+)
+
+// Authorizer decides whether a tool call may run
+type Authorizer interface {
+	// Authorize returns an error if the call must not run. On success it
+	// returns the context to invoke the tool with, which may carry the grant.
+	Authorize(ctx context.Context, tool Tool, args json.RawMessage) (context.Context, error)
+}
+
+// Executor runs the tool calls requested by the model
+type Executor struct {
+	registry   *Registry
+	authorizer Authorizer
+}
+
+// NewExecutor creates an executor for the tools in registry
+// If authorizer is nil every call is allowed.
+func NewExecutor(registry *Registry, authorizer Authorizer) *Executor {
+	return &Executor{registry: registry, authorizer: authorizer}
+}
+
+// Execute runs a single tool call. Failures are reported in the result
+// rather than aborting the run, so the model can see the error and recover.
+func (e *Executor) Execute(ctx context.Context, call llm.ToolCall) (result ToolResult) {
+	result = ToolResult{
+		Name:      call.Function.Name,
+		Arguments: call.Function.Arguments,
+	}
+
+	start := time.Now()
+	defer func() { result.Duration = time.Since(start) }()
+
+	tool, ok := e.registry.Get(call.Function.Name)
+	if !ok {
+		result.Error = fmt.Sprintf("unknown tool %q", call.Function.Name)
+		return result
+	}
+
+	args := call.Function.Arguments
+	if len(args) == 0 {
+		args = []byte("{}")
+	}
+
+	if e.authorizer != nil {
+		authorized, err := e.authorizer.Authorize(ctx, tool, args)
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		ctx = authorized
+	}
+
+	output, err := tool.Invoke(ctx, args)
+	result.Output = output
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	return result
+}
@@ -0,0 +1,42 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/anicolao/emrys/internal/llm"
+)
+
+// sleepTool takes a moment before answering
+type sleepTool struct{ echoTool }
+
+func (sleepTool) Invoke(context.Context, json.RawMessage) (string, error) {
+	time.Sleep(10 * time.Millisecond)
+	return "awake", nil
+}
+
+func TestExecuteRecordsDuration(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(sleepTool{echoTool{name: "sleep"}})
+
+	result := NewExecutor(registry, nil).Execute(context.Background(), llm.ToolCall{
+		Function: llm.ToolCallFunction{Name: "sleep", Arguments: json.RawMessage(`{}`)},
+	})
+	if result.Output != "awake" {
+		t.Errorf("Expected output 'awake', got %q", result.Output)
+	}
+	if result.Duration < 10*time.Millisecond {
+		t.Errorf("Expected duration of at least 10ms, got %s", result.Duration)
+	}
+}
+
+func TestExecuteRecordsDurationOnError(t *testing.T) {
+	result := NewExecutor(NewRegistry(), nil).Execute(context.Background(), llm.ToolCall{
+		Function: llm.ToolCallFunction{Name: "missing"},
+	})
+	if result.Error == "" || result.Duration <= 0 {
+		t.Errorf("Expected an error with a duration, got %+v", result)
+	}
+}
@@ -0,0 +1,81 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/anicolao/emrys/internal/llm"
+)
+
+// Registry holds the tools available to the agent
+type Registry struct {
+	mu    sync.RWMutex
+	tools map[string]Tool
+}
+
+// NewRegistry creates an empty tool registry
+func NewRegistry() *Registry {
+	return &Registry{tools: make(map[string]Tool)}
+}
+
+// Register adds a tool to the registry
+// It fails if the name is empty or taken, or if the parameter schema is not valid JSON
+func (r *Registry) Register(tool Tool) error {
+	name := tool.Name()
+	if name == "" {
+		return fmt.Errorf("tool name cannot be empty")
+	}
+	if !json.Valid(tool.Parameters()) {
+		return fmt.Errorf("tool %q has an invalid parameter schema", name)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.tools[name]; exists {
+		return fmt.Errorf("tool %q is already registered", name)
+	}
+	r.tools[name] = tool
+	return nil
+}
+
+// Get returns the tool with the given name
+func (r *Registry) Get(name string) (Tool, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tool, ok := r.tools[name]
+	return tool, ok
+}
+
+// List returns all registered tools sorted by name
+func (r *Registry) List() []Tool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tools := make([]Tool, 0, len(r.tools))
+	for _, tool := range r.tools {
+		tools = append(tools, tool)
+	}
+	sort.Slice(tools, func(i, j int) bool {
+		return tools[i].Name() < tools[j].Name()
+	})
+	return tools
+}
+
+// Specs returns the registered tools in Ollama's tools format
+func (r *Registry) Specs() []llm.ToolSpec {
+	var specs []llm.ToolSpec
+	for _, tool := range r.List() {
+		specs = append(specs, llm.ToolSpec{
+			Type: "function",
+			Function: llm.ToolFunction{
+				Name:        tool.Name(),
+				Description: tool.Description(),
+				Parameters:  tool.Parameters(),
+			},
+		})
+	}
+	return specs
+}
@@ -0,0 +1,86 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+// echoTool returns its "text" argument
+type echoTool struct {
+	name string
+}
+
+func (t echoTool) Name() string        { return t.name }
+func (t echoTool) Description() string { return "Echo the given text" }
+func (t echoTool) Parameters() json.RawMessage {
+	return json.RawMessage(`{"type":"object","properties":{"text":{"type":"string"}},"required":["text"]}`)
+}
+func (t echoTool) Invoke(_ context.Context, args json.RawMessage) (string, error) {
+	var params struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return "", err
+	}
+	return params.Text, nil
+}
+
+// badSchemaTool has a parameter schema that is not valid JSON
+type badSchemaTool struct{ echoTool }
+
+func (badSchemaTool) Parameters() json.RawMessage { return json.RawMessage(`{"type":`) }
+
+func TestRegistryRegister(t *testing.T) {
+	registry := NewRegistry()
+
+	if err := registry.Register(echoTool{name: "echo"}); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	if _, ok := registry.Get("echo"); !ok {
+		t.Error("Expected to find registered tool")
+	}
+	if _, ok := registry.Get("missing"); ok {
+		t.Error("Expected missing tool not to be found")
+	}
+}
+
+func TestRegistryRejectsInvalid(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(echoTool{name: "echo"})
+
+	tests := []struct {
+		name string
+		tool Tool
+	}{
+		{"duplicate name", echoTool{name: "echo"}},
+		{"empty name", echoTool{name: ""}},
+		{"invalid schema", badSchemaTool{echoTool{name: "bad"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := registry.Register(tt.tool); err == nil {
+				t.Error("Expected Register to fail")
+			}
+		})
+	}
+}
+
+func TestRegistrySpecs(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(echoTool{name: "zeta"})
+	registry.Register(echoTool{name: "alpha"})
+
+	specs := registry.Specs()
+	if len(specs) != 2 {
+		t.Fatalf("Expected 2 specs, got %d", len(specs))
+	}
+	if specs[0].Function.Name != "alpha" || specs[1].Function.Name != "zeta" {
+		t.Errorf("Expected specs sorted by name, got %s, %s", specs[0].Function.Name, specs[1].Function.Name)
+	}
+	if specs[0].Type != "function" {
+		t.Errorf("Expected type 'function', got '%s'", specs[0].Type)
+	}
+}
@@ -0,0 +1,68 @@
+package agent
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// ToolResult records one tool invocation
+type ToolResult struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+	Output    string          `json:"output,omitempty"`
+	Error     string          `json:"error,omitempty"`
+	Duration  time.Duration   `json:"duration"`
+}
+
+// Content returns the text fed back to the model for this result
+func (r ToolResult) Content() string {
+	if r.Error == "" {
+		return r.Output
+	}
+	if r.Output == "" {
+		return "error: " + r.Error
+	}
+	return r.Output + "\nerror: " + r.Error
+}
+
+// Step records one round trip to the model and the tool calls it requested
+type Step struct {
+	Number   int           `json:"number"`
+	Started  time.Time     `json:"started"`
+	Duration time.Duration `json:"duration"`
+	Reply    string        `json:"reply,omitempty"`
+	Tools    []ToolResult  `json:"tools,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// Transcript records a complete agent run
+type Transcript struct {
+	Task   string `json:"task"`
+	Steps  []Step `json:"steps"`
+	Answer string `json:"answer,omitempty"`
+}
+
+// Recorder receives every step as the agent completes it
+type Recorder interface {
+	Record(step Step) error
+}
+
+// JSONLRecorder writes each step as a JSON line, for later inspection
+type JSONLRecorder struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewJSONLRecorder creates a recorder that writes to w
+func NewJSONLRecorder(w io.Writer) *JSONLRecorder {
+	return &JSONLRecorder{enc: json.NewEncoder(w)}
+}
+
+// Record writes step as a single JSON line
+func (r *JSONLRecorder) Record(step Step) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.enc.Encode(step)
+}
@@ -0,0 +1,22 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Tool is a capability the agent can invoke on behalf of the model
+type Tool interface {
+	// Name is the function name the model uses to call the tool
+	Name() string
+
+	// Description tells the model what the tool does and when to use it
+	Description() string
+
+	// Parameters returns the JSON-schema describing the tool's arguments
+	Parameters() json.RawMessage
+
+	// Invoke runs the tool with the model-supplied JSON arguments and returns
+	// the result text that is fed back to the model
+	Invoke(ctx context.Context, args json.RawMessage) (string, error)
+}
@@ -0,0 +1,156 @@
+package approval
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Request is an action that needs a human's decision
+type Request struct {
+	ID      string    `json:"id"`
+	Action  Action    `json:"action"`
+	Rule    string    `json:"rule"` // Name of the rule that asked for approval
+	Created time.Time `json:"created"`
+}
+
+// Answer is a human's response to a Request
+type Answer struct {
+	Approved bool `json:"approved"`
+	Remember bool `json:"remember"` // Apply this answer to identical calls in future
+}
+
+// Approver asks a human to approve or deny an action
+// Implementations must return when ctx is done.
+type Approver interface {
+	Approve(ctx context.Context, req Request) (Answer, error)
+}
+
+// TerminalApprover prompts on a terminal
+type TerminalApprover struct {
+	In  io.Reader
+	Out io.Writer
+
+	// A single goroutine reads lines from In for the approver's lifetime, so
+	// a prompt that times out does not leave a reader behind to take the
+	// answer meant for the next one
+	start sync.Once
+	lines chan string
+	err   error // Why input ended; set before lines is closed
+}
+
+// NewTerminalApprover creates an approver that prompts on in/out
+func NewTerminalApprover(in io.Reader, out io.Writer) *TerminalApprover {
+	return &TerminalApprover{In: in, Out: out}
+}
+
+// read delivers lines from In until it fails
+func (t *TerminalApprover) read() {
+	reader := bufio.NewReader(t.In)
+	for {
+		text, err := reader.ReadString('\n')
+		if err != nil {
+			t.err = fmt.Errorf("failed to read answer: %w", err)
+			close(t.lines)
+			return
+		}
+		t.lines <- text
+	}
+}
+
+// Approve prints the request and reads y/n/a/v from the terminal
+func (t *TerminalApprover) Approve(ctx context.Context, req Request) (Answer, error) {
+	t.start.Do(func() {
+		t.lines = make(chan string)
+		go t.read()
+	})
+
+	fmt.Fprintln(t.Out)
+	fmt.Fprintf(t.Out, "⚠ Emrys wants to run %s (%s)\n", req.Action.Tool, req.Rule)
+	if req.Action.Facts.Summary != "" {
+		fmt.Fprintf(t.Out, "  %s\n", req.Action.Facts.Summary)
+	} else {
+		fmt.Fprintf(t.Out, "  %s\n", string(req.Action.Arguments))
+	}
+
+	for {
+		fmt.Fprint(t.Out, "Allow? (y)es / (n)o / (a)lways / ne(v)er: ")
+
+		var response string
+		select {
+		case text, ok := <-t.lines:
+			if !ok {
+				return Answer{}, t.err
+			}
+			response = text
+		case <-ctx.Done():
+			fmt.Fprintln(t.Out)
+			return Answer{}, ctx.Err()
+		}
+
+		switch strings.TrimSpace(strings.ToLower(response)) {
+		case "y", "yes":
+			return Answer{Approved: true}, nil
+		case "n", "no":
+			return Answer{Approved: false}, nil
+		case "a", "always":
+			return Answer{Approved: true, Remember: true}, nil
+		case "v", "never":
+			return Answer{Approved: false, Remember: true}, nil
+		}
+
+		fmt.Fprintln(t.Out, "Please answer 'y', 'n', 'a' or 'v'")
+	}
+}
+
+// Prompt is a pending request delivered by a ChannelApprover
+type Prompt struct {
+	Request Request
+	answer  chan Answer
+}
+
+// Respond answers the prompt. Only the first response counts.
+func (p *Prompt) Respond(answer Answer) {
+	select {
+	case p.answer <- answer:
+	default:
+	}
+}
+
+// ChannelApprover hands requests to another goroutine, such as a TUI
+// dialog, which answers them with Prompt.Respond
+type ChannelApprover struct {
+	prompts chan *Prompt
+}
+
+// NewChannelApprover creates an approver that delivers prompts on a channel
+func NewChannelApprover() *ChannelApprover {
+	return &ChannelApprover{prompts: make(chan *Prompt)}
+}
+
+// Prompts returns the channel on which requests arrive
+func (c *ChannelApprover) Prompts() <-chan *Prompt {
+	return c.prompts
+}
+
+// Approve delivers the request and waits for the response
+func (c *ChannelApprover) Approve(ctx context.Context, req Request) (Answer, error) {
+	prompt := &Prompt{Request: req, answer: make(chan Answer, 1)}
+
+	select {
+	case c.prompts <- prompt:
+	case <-ctx.Done():
+		return Answer{}, ctx.Err()
+	}
+
+	select {
+	case answer := <-prompt.answer:
+		return answer, nil
+	case <-ctx.Done():
+		return Answer{}, ctx.Err()
+	}
+}
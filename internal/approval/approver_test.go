@@ -0,0 +1,130 @@
+package approval
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTerminalApprover(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Answer
+	}{
+		{"y\n", Answer{Approved: true}},
+		{"no\n", Answer{Approved: false}},
+		{"maybe\na\n", Answer{Approved: true, Remember: true}},
+		{"v\n", Answer{Approved: false, Remember: true}},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		approver := NewTerminalApprover(strings.NewReader(tt.input), &out)
+
+		got, err := approver.Approve(context.Background(), Request{
+			Action: Action{Tool: "shell", Facts: Facts{Summary: "$ ls"}},
+			Rule:   "test",
+		})
+		if err != nil {
+			t.Fatalf("Approve(%q) failed: %v", tt.input, err)
+		}
+		if got != tt.want {
+			t.Errorf("Approve(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+		if !strings.Contains(out.String(), "$ ls") {
+			t.Errorf("Expected prompt to show the summary, got %q", out.String())
+		}
+	}
+}
+
+func TestTerminalApproverTimeout(t *testing.T) {
+	in, typed := io.Pipe()
+	defer typed.Close()
+	var out bytes.Buffer
+	approver := NewTerminalApprover(in, &out)
+	req := Request{Action: Action{Tool: "shell", Facts: Facts{Summary: "$ ls"}}, Rule: "test"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := approver.Approve(ctx, req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected the prompt to time out, got %v", err)
+	}
+
+	// The answer to the next prompt must reach it, not the abandoned one
+	go typed.Write([]byte("y\n"))
+	answer, err := approver.Approve(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Approve failed: %v", err)
+	}
+	if !answer.Approved {
+		t.Error("Expected approval")
+	}
+
+	typed.Close()
+	if _, err := approver.Approve(context.Background(), req); err == nil {
+		t.Error("Expected an error once input ends")
+	}
+}
+
+func TestChannelApprover(t *testing.T) {
+	approver := NewChannelApprover()
+
+	go func() {
+		prompt := <-approver.Prompts()
+		if prompt.Request.Action.Tool != "shell" {
+			t.Errorf("Unexpected request: %+v", prompt.Request)
+		}
+		prompt.Respond(Answer{Approved: true})
+	}()
+
+	answer, err := approver.Approve(context.Background(), Request{Action: Action{Tool: "shell"}})
+	if err != nil {
+		t.Fatalf("Approve failed: %v", err)
+	}
+	if !answer.Approved {
+		t.Error("Expected approval")
+	}
+}
+
+func TestQueueApprover(t *testing.T) {
+	dir := t.TempDir()
+	approver := NewQueueApprover(dir)
+
+	go func() {
+		// Wait for the request to be queued, then answer it like 'emrys approvals approve'
+		for i := 0; i < 100; i++ {
+			pending, _ := ListPending(dir)
+			if len(pending) == 1 {
+				Respond(dir, pending[0].ID, Answer{Approved: true})
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	answer, err := approver.Approve(context.Background(), Request{ID: "42", Action: Action{Tool: "shell"}, Created: time.Now()})
+	if err != nil {
+		t.Fatalf("Approve failed: %v", err)
+	}
+	if !answer.Approved {
+		t.Error("Expected approval")
+	}
+
+	pending, _ := ListPending(dir)
+	if len(pending) != 0 {
+		t.Errorf("Expected queue to be empty after answering, got %d", len(pending))
+	}
+}
+
+func TestRespondUnknownRequest(t *testing.T) {
+	if err := Respond(t.TempDir(), "missing", Answer{}); err == nil {
+		t.Error("Expected error for unknown request")
+	}
+	if err := Respond(t.TempDir(), "../escape", Answer{}); err == nil {
+		t.Error("Expected error for invalid id")
+	}
+}
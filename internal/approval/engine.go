@@ -0,0 +1,159 @@
+package approval
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/anicolao/emrys/internal/agent"
+)
+
+// DefaultTimeout is how long an approver has to answer before the action is denied
+const DefaultTimeout = 5 * time.Minute
+
+// ErrDenied is returned when an action is refused by policy or by a human
+var ErrDenied = errors.New("action denied")
+
+// Options configures an Engine
+type Options struct {
+	Approver Approver      // Asked about actions the policy marks Ask (nil: deny them)
+	Memory   *Memory       // Remembered decisions (nil: remember for this process only)
+	Timeout  time.Duration // Time allowed for an answer (default: DefaultTimeout)
+}
+
+// Engine applies a Policy to tool calls and consults a human when asked to
+type Engine struct {
+	policy   Policy
+	approver Approver
+	memory   *Memory
+	timeout  time.Duration
+	nextID   atomic.Uint64
+}
+
+// NewEngine creates an approval engine for the given policy
+func NewEngine(policy Policy, opts Options) (*Engine, error) {
+	if err := policy.compile(); err != nil {
+		return nil, fmt.Errorf("invalid approval policy: %w", err)
+	}
+	if opts.Memory == nil {
+		opts.Memory = NewMemory()
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+
+	return &Engine{
+		policy:   policy,
+		approver: opts.Approver,
+		memory:   opts.Memory,
+		timeout:  opts.Timeout,
+	}, nil
+}
+
+// Check decides an action, asking the approver if the policy requires it.
+// It returns nil if the action may proceed and an error wrapping ErrDenied
+// if it may not. Deny rules always win over remembered answers.
+func (e *Engine) Check(ctx context.Context, action Action) error {
+	decision, rule := e.policy.Classify(action)
+	ruleName := "default policy"
+	if rule != nil {
+		ruleName = rule.Name
+	}
+
+	switch decision {
+	case Allow:
+		return nil
+	case Deny:
+		return fmt.Errorf("%w: %s (%s)", ErrDenied, action.Tool, ruleName)
+	}
+
+	if remembered, ok := e.memory.Lookup(action); ok {
+		if remembered == Allow {
+			return nil
+		}
+		return fmt.Errorf("%w: %s (previously denied)", ErrDenied, action.Tool)
+	}
+
+	if e.approver == nil {
+		return fmt.Errorf("%w: %s needs approval (%s) but no approver is configured", ErrDenied, action.Tool, ruleName)
+	}
+
+	askCtx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+
+	req := Request{
+		ID:      fmt.Sprintf("%d-%d", time.Now().Unix(), e.nextID.Add(1)),
+		Action:  action,
+		Rule:    ruleName,
+		Created: time.Now(),
+	}
+	answer, err := e.approver.Approve(askCtx, req)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+			return fmt.Errorf("%w: %s was not approved within %v", ErrDenied, action.Tool, e.timeout)
+		}
+		return fmt.Errorf("%w: approval failed: %v", ErrDenied, err)
+	}
+
+	if answer.Remember {
+		decision := Deny
+		if answer.Approved {
+			decision = Allow
+		}
+		if err := e.memory.Remember(action, decision); err != nil {
+			return err
+		}
+	}
+
+	if !answer.Approved {
+		return fmt.Errorf("%w: %s was not approved", ErrDenied, action.Tool)
+	}
+	return nil
+}
+
+// Authorize implements agent.Authorizer. Tools that implement Describer
+// contribute the paths, network use, sudo and destruction facts the rules
+// match on.
+func (e *Engine) Authorize(ctx context.Context, tool agent.Tool, args json.RawMessage) (context.Context, error) {
+	action := Action{Tool: tool.Name(), Arguments: args}
+	if d, ok := tool.(Describer); ok {
+		action.Facts = d.DescribeCall(args)
+	}
+
+	if err := e.Check(ctx, action); err != nil {
+		return ctx, err
+	}
+
+	if action.Facts.Sudo {
+		ctx = context.WithValue(ctx, approvedSudoKey{}, true)
+	}
+	return ctx, nil
+}
+
+// approvedSudoKey marks a context whose tool call was approved with sudo
+type approvedSudoKey struct{}
+
+// SudoApprover returns a callback for tools that need elevated privileges,
+// such as the shell tool. Calls already approved through Authorize are
+// granted without asking twice; anything else goes through Check.
+func (e *Engine) SudoApprover() func(ctx context.Context, command string) (bool, error) {
+	return func(ctx context.Context, command string) (bool, error) {
+		if approved, _ := ctx.Value(approvedSudoKey{}).(bool); approved {
+			return true, nil
+		}
+
+		args, _ := json.Marshal(map[string]string{"command": command})
+		err := e.Check(ctx, Action{
+			Tool:      "sudo",
+			Arguments: args,
+			Facts:     Facts{Sudo: true, Summary: command},
+		})
+		if errors.Is(err, ErrDenied) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+}
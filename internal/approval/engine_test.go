@@ -0,0 +1,132 @@
+package approval
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// scriptedApprover answers every request the same way and counts requests
+type scriptedApprover struct {
+	answer Answer
+	asked  int
+}
+
+func (s *scriptedApprover) Approve(ctx context.Context, req Request) (Answer, error) {
+	s.asked++
+	return s.answer, nil
+}
+
+// silentApprover never answers
+type silentApprover struct{}
+
+func (silentApprover) Approve(ctx context.Context, req Request) (Answer, error) {
+	<-ctx.Done()
+	return Answer{}, ctx.Err()
+}
+
+var askAll = Policy{Default: Ask}
+
+func TestEngineAsksApprover(t *testing.T) {
+	approver := &scriptedApprover{answer: Answer{Approved: true}}
+	engine, err := NewEngine(askAll, Options{Approver: approver})
+	if err != nil {
+		t.Fatalf("NewEngine failed: %v", err)
+	}
+
+	if err := engine.Check(context.Background(), Action{Tool: "shell"}); err != nil {
+		t.Errorf("Expected approved action, got %v", err)
+	}
+
+	approver.answer = Answer{Approved: false}
+	if err := engine.Check(context.Background(), Action{Tool: "shell"}); !errors.Is(err, ErrDenied) {
+		t.Errorf("Expected ErrDenied, got %v", err)
+	}
+	if approver.asked != 2 {
+		t.Errorf("Expected approver to be asked twice, got %d", approver.asked)
+	}
+}
+
+func TestEngineWithoutApproverDenies(t *testing.T) {
+	engine, _ := NewEngine(askAll, Options{})
+	if err := engine.Check(context.Background(), Action{Tool: "shell"}); !errors.Is(err, ErrDenied) {
+		t.Errorf("Expected ErrDenied without approver, got %v", err)
+	}
+}
+
+func TestEngineTimeoutDenies(t *testing.T) {
+	engine, _ := NewEngine(askAll, Options{Approver: silentApprover{}, Timeout: 50 * time.Millisecond})
+
+	err := engine.Check(context.Background(), Action{Tool: "shell"})
+	if !errors.Is(err, ErrDenied) || !strings.Contains(err.Error(), "within") {
+		t.Errorf("Expected timeout denial, got %v", err)
+	}
+}
+
+func TestEngineRemembersDecisions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "decisions.json")
+	memory, err := LoadMemory(path)
+	if err != nil {
+		t.Fatalf("LoadMemory failed: %v", err)
+	}
+
+	approver := &scriptedApprover{answer: Answer{Approved: true, Remember: true}}
+	engine, _ := NewEngine(askAll, Options{Approver: approver, Memory: memory})
+
+	action := Action{Tool: "shell", Arguments: json.RawMessage(`{"command": "ls"}`)}
+	for i := 0; i < 3; i++ {
+		if err := engine.Check(context.Background(), action); err != nil {
+			t.Fatalf("Check %d failed: %v", i, err)
+		}
+	}
+	if approver.asked != 1 {
+		t.Errorf("Expected one question, got %d", approver.asked)
+	}
+
+	// Remembered decisions survive a restart and ignore whitespace differences
+	reloaded, err := LoadMemory(path)
+	if err != nil {
+		t.Fatalf("LoadMemory failed: %v", err)
+	}
+	decision, ok := reloaded.Lookup(Action{Tool: "shell", Arguments: json.RawMessage(`{"command":"ls"}`)})
+	if !ok || decision != Allow {
+		t.Errorf("Expected remembered Allow, got %s (found: %v)", decision, ok)
+	}
+}
+
+func TestEngineDenyRuleBeatsMemory(t *testing.T) {
+	memory := NewMemory()
+	action := Action{Tool: "shell", Arguments: json.RawMessage(`{"command":"rm -rf /"}`), Facts: Facts{Destructive: true}}
+	memory.Remember(action, Allow)
+
+	engine, _ := NewEngine(DefaultPolicy(), Options{Memory: memory})
+	if err := engine.Check(context.Background(), action); !errors.Is(err, ErrDenied) {
+		t.Errorf("Expected deny rule to win, got %v", err)
+	}
+}
+
+func TestSudoApproverSkipsSecondPrompt(t *testing.T) {
+	approver := &scriptedApprover{answer: Answer{Approved: true}}
+	engine, _ := NewEngine(DefaultPolicy(), Options{Approver: approver})
+	sudo := engine.SudoApprover()
+
+	granted, err := sudo(context.Background(), "sudo launchctl list")
+	if err != nil || !granted {
+		t.Fatalf("Expected sudo to be granted, got %v, %v", granted, err)
+	}
+	if approver.asked != 1 {
+		t.Errorf("Expected one question, got %d", approver.asked)
+	}
+
+	ctx := context.WithValue(context.Background(), approvedSudoKey{}, true)
+	if granted, _ := sudo(ctx, "sudo launchctl list"); !granted {
+		t.Error("Expected pre-approved context to be granted")
+	}
+	if approver.asked != 1 {
+		t.Errorf("Expected no further questions, got %d", approver.asked)
+	}
+}
@@ -0,0 +1,87 @@
+package approval
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Memory remembers decisions for identical tool calls
+type Memory struct {
+	mu        sync.Mutex
+	path      string
+	decisions map[string]Decision
+}
+
+// DefaultMemoryPath returns the file that remembered decisions are kept in
+func DefaultMemoryPath() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".config", "emrys", "decisions.json")
+}
+
+// NewMemory creates an in-memory decision store
+func NewMemory() *Memory {
+	return &Memory{decisions: make(map[string]Decision)}
+}
+
+// LoadMemory loads remembered decisions from a JSON file, creating an empty
+// store if the file does not exist. Decisions are saved back to the file.
+func LoadMemory(path string) (*Memory, error) {
+	m := &Memory{path: path, decisions: make(map[string]Decision)}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read remembered decisions: %w", err)
+	}
+	if err := json.Unmarshal(data, &m.decisions); err != nil {
+		return nil, fmt.Errorf("failed to parse remembered decisions: %w", err)
+	}
+	return m, nil
+}
+
+// Lookup returns the remembered decision for an action, if any
+func (m *Memory) Lookup(action Action) (Decision, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	decision, ok := m.decisions[fingerprint(action)]
+	return decision, ok
+}
+
+// Remember stores a decision for an action and persists it if file-backed
+func (m *Memory) Remember(action Action, decision Decision) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.decisions[fingerprint(action)] = decision
+	if m.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(m.decisions, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode remembered decisions: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(m.path), 0700); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.WriteFile(m.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to save remembered decisions: %w", err)
+	}
+	return nil
+}
+
+// fingerprint identifies an action by tool name and compacted arguments
+func fingerprint(action Action) string {
+	var args bytes.Buffer
+	if err := json.Compact(&args, action.Arguments); err != nil {
+		args.Reset()
+		args.Write(action.Arguments)
+	}
+	return action.Tool + " " + args.String()
+}
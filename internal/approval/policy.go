@@ -0,0 +1,166 @@
+package approval
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Decision is the outcome of classifying a tool call
+type Decision string
+
+// Possible decisions
+const (
+	Allow Decision = "allow" // Run without asking
+	Ask   Decision = "ask"   // Ask a human through the Approver
+	Deny  Decision = "deny"  // Refuse outright
+)
+
+// Facts describe what a tool call would do, beyond its raw arguments
+type Facts struct {
+	Paths       []string `json:"paths,omitempty"` // Files or directories the call touches
+	Network     bool     `json:"network"`         // Whether the call uses the network
+	Sudo        bool     `json:"sudo"`            // Whether the call needs elevated privileges
+	Destructive bool     `json:"destructive"`     // Whether the call would wipe a disk, the root or a home directory
+	Summary     string   `json:"summary"`         // Short human-readable description for approvers
+}
+
+// Describer is implemented by tools that can describe a call's effects
+// so that rules about paths, network use, sudo and destruction can apply
+// to them
+type Describer interface {
+	DescribeCall(args json.RawMessage) Facts
+}
+
+// Action is a tool call awaiting a decision
+type Action struct {
+	Tool      string          `json:"tool"`
+	Arguments json.RawMessage `json:"arguments"`
+	Facts     Facts           `json:"facts"`
+}
+
+// Rule classifies actions that match all of its non-empty conditions
+type Rule struct {
+	Name        string   // Shown to approvers and in errors
+	Tool        string   // Tool name glob (e.g. "shell", "*")
+	Args        string   // Regexp matched against the raw JSON arguments
+	Paths       []string // Matches if any touched path is inside one of these directories
+	Network     *bool    // Matches on network use when set
+	Sudo        *bool    // Matches on sudo when set
+	Destructive *bool    // Matches on destruction when set
+	Decision    Decision // Decision for matching actions
+
+	args *regexp.Regexp
+}
+
+// Policy is an ordered rule list; the first matching rule decides
+type Policy struct {
+	Rules   []Rule
+	Default Decision // Decision when no rule matches
+}
+
+// Bool returns a pointer to b, for Rule.Network, Rule.Sudo and
+// Rule.Destructive
+func Bool(b bool) *bool {
+	return &b
+}
+
+// DefaultPolicy lets Emrys act autonomously within boundaries: destructive
+// commands are refused, and sudo, network use and system directories need a
+// human's approval
+func DefaultPolicy() Policy {
+	return Policy{
+		Rules: []Rule{
+			{Name: "destructive command", Tool: "*", Destructive: Bool(true), Decision: Deny},
+			{Name: "elevated privileges", Tool: "*", Sudo: Bool(true), Decision: Ask},
+			{Name: "network access", Tool: "*", Network: Bool(true), Decision: Ask},
+			{Name: "system directory", Tool: "*", Paths: []string{"/System", "/Library", "/etc", "/usr", "/bin", "/sbin", "/private"}, Decision: Ask},
+		},
+		Default: Allow,
+	}
+}
+
+// compile prepares the rule patterns and validates the policy
+func (p *Policy) compile() error {
+	switch p.Default {
+	case Allow, Ask, Deny:
+	case "":
+		p.Default = Ask
+	default:
+		return fmt.Errorf("invalid default decision %q", p.Default)
+	}
+
+	for i := range p.Rules {
+		rule := &p.Rules[i]
+		switch rule.Decision {
+		case Allow, Ask, Deny:
+		default:
+			return fmt.Errorf("rule %q: invalid decision %q", rule.Name, rule.Decision)
+		}
+		if rule.Tool != "" {
+			if _, err := filepath.Match(rule.Tool, ""); err != nil {
+				return fmt.Errorf("rule %q: invalid tool pattern: %w", rule.Name, err)
+			}
+		}
+		if rule.Args != "" {
+			re, err := regexp.Compile(rule.Args)
+			if err != nil {
+				return fmt.Errorf("rule %q: invalid argument pattern: %w", rule.Name, err)
+			}
+			rule.args = re
+		}
+	}
+	return nil
+}
+
+// Classify returns the decision for an action and the rule that made it
+// The rule is nil when the default decision applies.
+func (p *Policy) Classify(action Action) (Decision, *Rule) {
+	for i := range p.Rules {
+		if p.Rules[i].matches(action) {
+			return p.Rules[i].Decision, &p.Rules[i]
+		}
+	}
+	return p.Default, nil
+}
+
+// matches reports whether every condition of the rule holds for action
+func (r *Rule) matches(action Action) bool {
+	if r.Tool != "" {
+		if ok, _ := filepath.Match(r.Tool, action.Tool); !ok {
+			return false
+		}
+	}
+	if r.args != nil && !r.args.Match(action.Arguments) {
+		return false
+	}
+	if r.Network != nil && *r.Network != action.Facts.Network {
+		return false
+	}
+	if r.Sudo != nil && *r.Sudo != action.Facts.Sudo {
+		return false
+	}
+	if r.Destructive != nil && *r.Destructive != action.Facts.Destructive {
+		return false
+	}
+	if len(r.Paths) > 0 && !touchesAny(action.Facts.Paths, r.Paths) {
+		return false
+	}
+	return true
+}
+
+// touchesAny reports whether any path is inside any of the directories
+func touchesAny(paths, dirs []string) bool {
+	for _, path := range paths {
+		path = filepath.Clean(path)
+		for _, dir := range dirs {
+			dir = filepath.Clean(dir)
+			if path == dir || strings.HasPrefix(path, dir+string(filepath.Separator)) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package approval
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDefaultPolicyClassify(t *testing.T) {
+	policy := DefaultPolicy()
+	if err := policy.compile(); err != nil {
+		t.Fatalf("Default policy is invalid: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		action Action
+		want   Decision
+	}{
+		{
+			name:   "plain command",
+			action: Action{Tool: "shell", Arguments: json.RawMessage(`{"command":"ls"}`)},
+			want:   Allow,
+		},
+		{
+			name:   "destructive command",
+			action: Action{Tool: "shell", Facts: Facts{Destructive: true, Sudo: true}},
+			want:   Deny,
+		},
+		{
+			name:   "sudo",
+			action: Action{Tool: "shell", Facts: Facts{Sudo: true}},
+			want:   Ask,
+		},
+		{
+			name:   "network",
+			action: Action{Tool: "browser", Facts: Facts{Network: true}},
+			want:   Ask,
+		},
+		{
+			name:   "system path",
+			action: Action{Tool: "shell", Facts: Facts{Paths: []string{"/etc/hosts"}}},
+			want:   Ask,
+		},
+		{
+			name:   "similar but unrelated path",
+			action: Action{Tool: "shell", Facts: Facts{Paths: []string{"/etcetera/file"}}},
+			want:   Allow,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _ := policy.Classify(tt.action); got != tt.want {
+				t.Errorf("Classify = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyFirstMatchWins(t *testing.T) {
+	policy := Policy{
+		Rules: []Rule{
+			{Name: "allow echo", Tool: "shell", Args: `"echo`, Decision: Allow},
+			{Name: "ask shell", Tool: "shell", Decision: Ask},
+		},
+		Default: Deny,
+	}
+	if err := policy.compile(); err != nil {
+		t.Fatalf("compile failed: %v", err)
+	}
+
+	decision, rule := policy.Classify(Action{Tool: "shell", Arguments: json.RawMessage(`{"command":"echo hi"}`)})
+	if decision != Allow || rule.Name != "allow echo" {
+		t.Errorf("Expected 'allow echo' to decide, got %s from %v", decision, rule)
+	}
+
+	decision, _ = policy.Classify(Action{Tool: "shell", Arguments: json.RawMessage(`{"command":"ls"}`)})
+	if decision != Ask {
+		t.Errorf("Expected Ask, got %s", decision)
+	}
+
+	decision, rule = policy.Classify(Action{Tool: "other"})
+	if decision != Deny || rule != nil {
+		t.Errorf("Expected default Deny, got %s from %v", decision, rule)
+	}
+}
+
+func TestPolicyCompileErrors(t *testing.T) {
+	bad := []Policy{
+		{Default: "maybe"},
+		{Rules: []Rule{{Name: "bad decision", Decision: "perhaps"}}},
+		{Rules: []Rule{{Name: "bad regexp", Args: "(", Decision: Deny}}},
+		{Rules: []Rule{{Name: "bad glob", Tool: "[", Decision: Deny}}},
+	}
+
+	for _, policy := range bad {
+		if err := policy.compile(); err == nil {
+			t.Errorf("Expected compile error for %+v", policy)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package approval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// queuePollInterval is how often a QueueApprover checks for an answer
+const queuePollInterval = 500 * time.Millisecond
+
+// DefaultQueueDir returns the directory used for queued approval requests
+func DefaultQueueDir() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".config", "emrys", "approvals")
+}
+
+// QueueApprover leaves requests in a directory to be answered later, for
+// example over SSH with 'emrys approvals approve <id>'
+type QueueApprover struct {
+	Dir string
+}
+
+// NewQueueApprover creates an approver backed by dir
+func NewQueueApprover(dir string) *QueueApprover {
+	return &QueueApprover{Dir: dir}
+}
+
+// Approve writes the request to the queue and waits for an answer file
+func (q *QueueApprover) Approve(ctx context.Context, req Request) (Answer, error) {
+	if err := os.MkdirAll(q.Dir, 0700); err != nil {
+		return Answer{}, fmt.Errorf("failed to create approval queue: %w", err)
+	}
+
+	data, err := json.MarshalIndent(req, "", "  ")
+	if err != nil {
+		return Answer{}, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	requestPath := requestFile(q.Dir, req.ID)
+	answerPath := answerFile(q.Dir, req.ID)
+	if err := os.WriteFile(requestPath, data, 0600); err != nil {
+		return Answer{}, fmt.Errorf("failed to queue request: %w", err)
+	}
+	defer os.Remove(requestPath)
+	defer os.Remove(answerPath)
+
+	ticker := time.NewTicker(queuePollInterval)
+	defer ticker.Stop()
+
+	for {
+		data, err := os.ReadFile(answerPath)
+		if err == nil {
+			var answer Answer
+			if err := json.Unmarshal(data, &answer); err != nil {
+				return Answer{}, fmt.Errorf("failed to parse answer: %w", err)
+			}
+			return answer, nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return Answer{}, ctx.Err()
+		}
+	}
+}
+
+// ListPending returns the queued requests in dir, oldest first
+func ListPending(dir string) ([]Request, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*.request.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var requests []Request
+	for _, path := range matches {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			// The request may have been answered and removed meanwhile
+			continue
+		}
+		var req Request
+		if err := json.Unmarshal(data, &req); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		requests = append(requests, req)
+	}
+
+	sort.Slice(requests, func(i, j int) bool {
+		return requests[i].Created.Before(requests[j].Created)
+	})
+	return requests, nil
+}
+
+// Respond answers a queued request
+func Respond(dir, id string, answer Answer) error {
+	if id == "" || strings.ContainsAny(id, `/\`) {
+		return fmt.Errorf("invalid request id %q", id)
+	}
+	if _, err := os.Stat(requestFile(dir, id)); err != nil {
+		return fmt.Errorf("no pending request with id %s", id)
+	}
+
+	data, err := json.Marshal(answer)
+	if err != nil {
+		return fmt.Errorf("failed to encode answer: %w", err)
+	}
+
+	// Write then rename so the waiting approver never reads a partial file
+	tmp := answerFile(dir, id) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write answer: %w", err)
+	}
+	return os.Rename(tmp, answerFile(dir, id))
+}
+
+func requestFile(dir, id string) string {
+	return filepath.Join(dir, id+".request.json")
+}
+
+func answerFile(dir, id string) string {
+	return filepath.Join(dir, id+".answer.json")
+}
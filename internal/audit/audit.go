@@ -0,0 +1,197 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Entry is a single record in the audit log
+type Entry struct {
+	Seq          int64     `json:"seq"`
+	Time         time.Time `json:"time"`
+	Initiator    string    `json:"initiator"` // Who caused the command (e.g. "bootstrap:phase2", "agent", "user")
+	Args         []string  `json:"args"`      // Full argument vector, program first
+	Dir          string    `json:"dir,omitempty"`
+	ExitCode     int       `json:"exit_code"`
+	Error        string    `json:"error,omitempty"`
+	DurationMS   int64     `json:"duration_ms"`
+	OutputBytes  int64     `json:"output_bytes"`
+	OutputDigest string    `json:"output_digest"` // sha256 of stdout and stderr as written
+	PrevHash     string    `json:"prev_hash"`
+	Hash         string    `json:"hash"`
+}
+
+// computeHash returns the chain hash of an entry: sha256 over the previous
+// hash and the entry's JSON encoding with the Hash field empty
+func computeHash(entry Entry) (string, error) {
+	entry.Hash = ""
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	h.Write([]byte(entry.PrevHash))
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// PathEnv names an environment variable that moves the audit log elsewhere
+const PathEnv = "EMRYS_AUDIT_LOG"
+
+// DefaultPath returns the location of the audit log
+func DefaultPath() string {
+	if path := os.Getenv(PathEnv); path != "" {
+		return path
+	}
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, "Library", "Logs", "emrys", "audit.jsonl")
+}
+
+// Log appends hash-chained entries to a JSONL file
+// Appends are serialized with an exclusive file lock, so several Emrys
+// processes can share one log without breaking the chain.
+type Log struct {
+	mu   sync.Mutex
+	path string
+}
+
+// Open returns a Log that appends to path, creating parent directories
+func Open(path string) (*Log, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	return &Log{path: path}, nil
+}
+
+// Path returns the file the log writes to
+func (l *Log) Path() string {
+	return l.path
+}
+
+// Append links entry to the end of the chain and writes it
+// Seq, PrevHash and Hash are filled in; Time is set if zero.
+func (l *Log) Append(entry Entry) (Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := os.OpenFile(l.path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return entry, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		return entry, fmt.Errorf("failed to lock audit log: %w", err)
+	}
+	defer syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+
+	last, err := lastLine(f)
+	if err != nil {
+		return entry, fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	entry.Seq = 1
+	entry.PrevHash = ""
+	if len(last) > 0 {
+		var prev Entry
+		if err := json.Unmarshal(last, &prev); err != nil {
+			return entry, fmt.Errorf("failed to parse last audit entry: %w", err)
+		}
+		entry.Seq = prev.Seq + 1
+		entry.PrevHash = prev.Hash
+	}
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	entry.Time = entry.Time.UTC()
+
+	entry.Hash, err = computeHash(entry)
+	if err != nil {
+		return entry, fmt.Errorf("failed to hash audit entry: %w", err)
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return entry, fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return entry, fmt.Errorf("failed to write audit entry: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		return entry, fmt.Errorf("failed to sync audit log: %w", err)
+	}
+
+	return entry, nil
+}
+
+// lastLine returns the last non-empty line of f
+func lastLine(f *os.File) ([]byte, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	const chunk = 4096
+	size := info.Size()
+	var tail []byte
+	for offset := size; offset > 0; {
+		n := int64(chunk)
+		if offset < n {
+			n = offset
+		}
+		offset -= n
+
+		buf := make([]byte, n)
+		if _, err := f.ReadAt(buf, offset); err != nil && err != io.EOF {
+			return nil, err
+		}
+		tail = append(buf, tail...)
+
+		trimmed := bytes.TrimRight(tail, "\n")
+		if i := bytes.LastIndexByte(trimmed, '\n'); i >= 0 {
+			return trimmed[i+1:], nil
+		}
+	}
+	return bytes.TrimRight(tail, "\n"), nil
+}
+
+// Read returns every entry in the log file at path
+func Read(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("line %d: failed to parse audit entry: %w", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	return entries, nil
+}
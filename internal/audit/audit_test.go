@@ -0,0 +1,260 @@
+package audit
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestLog(t *testing.T) *Log {
+	t.Helper()
+	log, err := Open(filepath.Join(t.TempDir(), "logs", "audit.jsonl"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	return log
+}
+
+func TestAppendChainsEntries(t *testing.T) {
+	log := newTestLog(t)
+
+	first, err := log.Append(Entry{Initiator: InitiatorUser, Args: []string{"echo", "one"}})
+	if err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	second, err := log.Append(Entry{Initiator: InitiatorUser, Args: []string{"echo", "two"}})
+	if err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	if first.Seq != 1 || second.Seq != 2 {
+		t.Errorf("Expected sequence 1, 2, got %d, %d", first.Seq, second.Seq)
+	}
+	if first.PrevHash != "" {
+		t.Errorf("Expected empty previous hash for first entry, got %q", first.PrevHash)
+	}
+	if second.PrevHash != first.Hash {
+		t.Error("Expected second entry to link to the first")
+	}
+
+	entries, err := Read(log.Path())
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(entries))
+	}
+
+	result, err := Verify(log.Path())
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if result.Entries != 2 || result.HeadHash != second.Hash {
+		t.Errorf("Unexpected verify result: %+v", result)
+	}
+}
+
+func TestAppendConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	// Separate Log values simulate separate processes sharing the file
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		log, _ := Open(path)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				if _, err := log.Append(Entry{Initiator: "test", Args: []string{"true"}}); err != nil {
+					t.Errorf("Append failed: %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	result, err := Verify(path)
+	if err != nil {
+		t.Fatalf("Verify failed after concurrent appends: %v", err)
+	}
+	if result.Entries != 40 {
+		t.Errorf("Expected 40 entries, got %d", result.Entries)
+	}
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(lines []string) []string
+	}{
+		{
+			name: "edited entry",
+			tamper: func(lines []string) []string {
+				lines[1] = strings.Replace(lines[1], `"two"`, `"TWO"`, 1)
+				return lines
+			},
+		},
+		{
+			name: "deleted entry",
+			tamper: func(lines []string) []string {
+				return append(lines[:1], lines[2:]...)
+			},
+		},
+		{
+			name: "reordered entries",
+			tamper: func(lines []string) []string {
+				lines[0], lines[1] = lines[1], lines[0]
+				return lines
+			},
+		},
+		{
+			name: "garbage line",
+			tamper: func(lines []string) []string {
+				return append([]string{lines[0], "not json"}, lines[1:]...)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := newTestLog(t)
+			for _, word := range []string{"one", "two", "three"} {
+				log.Append(Entry{Initiator: InitiatorUser, Args: []string{"echo", word}})
+			}
+
+			data, _ := os.ReadFile(log.Path())
+			lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+			lines = tt.tamper(lines)
+			os.WriteFile(log.Path(), []byte(strings.Join(lines, "\n")+"\n"), 0600)
+
+			_, err := Verify(log.Path())
+			var tamperErr *TamperError
+			if !errors.As(err, &tamperErr) {
+				t.Fatalf("Expected TamperError, got %v", err)
+			}
+		})
+	}
+}
+
+func TestRunRecordsCommand(t *testing.T) {
+	log := newTestLog(t)
+	SetDefault(log)
+	t.Cleanup(func() { SetDefault(nil) })
+
+	if _, err := Output("bootstrap:test", exec.Command("sh", "-c", "echo hello")); err != nil {
+		t.Fatalf("Output failed: %v", err)
+	}
+	err := Run(InitiatorUser, exec.Command("sh", "-c", "echo oops >&2; exit 4"))
+	if err == nil {
+		t.Fatal("Expected command failure")
+	}
+
+	entries, err := Read(log.Path())
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(entries))
+	}
+
+	if entries[0].Initiator != "bootstrap:test" || entries[0].ExitCode != 0 {
+		t.Errorf("Unexpected first entry: %+v", entries[0])
+	}
+	if entries[0].OutputBytes != 6 || !strings.HasPrefix(entries[0].OutputDigest, "sha256:") {
+		t.Errorf("Expected output digest of 6 bytes, got %d bytes %q", entries[0].OutputBytes, entries[0].OutputDigest)
+	}
+	if entries[1].ExitCode != 4 || entries[1].Error == "" {
+		t.Errorf("Expected exit code 4 with error, got %+v", entries[1])
+	}
+	if entries[1].Args[0] != "sh" {
+		t.Errorf("Expected args to be recorded, got %v", entries[1].Args)
+	}
+}
+
+func TestStartRecordsOnWait(t *testing.T) {
+	log := newTestLog(t)
+	SetDefault(log)
+	t.Cleanup(func() { SetDefault(nil) })
+
+	wait, err := Start("listen", exec.Command("sh", "-c", "echo audio"))
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if entries, _ := Read(log.Path()); len(entries) != 0 {
+		t.Fatalf("Expected no entry before Wait, got %d", len(entries))
+	}
+	if err := wait(); err != nil {
+		t.Fatalf("Wait failed: %v", err)
+	}
+
+	entries, err := Read(log.Path())
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Initiator != "listen" || entries[0].OutputBytes != 6 {
+		t.Errorf("Expected one listen entry with 6 bytes of output, got %+v", entries)
+	}
+
+	if _, err := Start("listen", exec.Command("emrys-no-such-command")); err == nil {
+		t.Fatal("Expected Start to fail")
+	}
+	if entries, _ := Read(log.Path()); len(entries) != 2 || entries[1].ExitCode != -1 {
+		t.Errorf("Expected the failed start to be recorded, got %+v", entries)
+	}
+}
+
+func TestDefaultPathEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	t.Setenv(PathEnv, path)
+	SetDefault(nil)
+	t.Cleanup(func() { SetDefault(nil) })
+
+	if got := DefaultPath(); got != path {
+		t.Errorf("Expected %s, got %s", path, got)
+	}
+	if log, err := Default(); err != nil || log.Path() != path {
+		t.Errorf("Expected the default log at %s, got %v, %v", path, log, err)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	now := time.Now()
+	entries := []Entry{
+		{Seq: 1, Time: now.Add(-2 * time.Hour), Initiator: "bootstrap:phase2", Args: []string{"launchctl", "load", "x.plist"}},
+		{Seq: 2, Time: now.Add(-time.Hour), Initiator: "agent:clean up", Args: []string{"/bin/sh", "-c", "rm a"}, ExitCode: 1},
+		{Seq: 3, Time: now, Initiator: "bootstrap:phase3", Args: []string{"osascript", "-e", "x"}},
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []int64
+	}{
+		{"all", Filter{}, []int64{1, 2, 3}},
+		{"initiator prefix", Filter{Initiator: "bootstrap"}, []int64{1, 3}},
+		{"command", Filter{Command: "launchctl"}, []int64{1}},
+		{"failed", Filter{Failed: true}, []int64{2}},
+		{"since", Filter{Since: now.Add(-90 * time.Minute)}, []int64{2, 3}},
+		{"until", Filter{Until: now.Add(-90 * time.Minute)}, []int64{1}},
+		{"limit keeps newest", Filter{Limit: 2}, []int64{2, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.filter.Apply(entries)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected %d entries, got %d", len(tt.want), len(got))
+			}
+			for i, entry := range got {
+				if entry.Seq != tt.want[i] {
+					t.Errorf("Entry %d: expected seq %d, got %d", i, tt.want[i], entry.Seq)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Initiators used across Emrys
+const (
+	InitiatorUser  = "user"
+	InitiatorAgent = "agent"
+)
+
+var (
+	defaultMu  sync.Mutex
+	defaultLog *Log
+)
+
+// SetDefault sets the log used by Run, Output and CombinedOutput
+func SetDefault(log *Log) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultLog = log
+}
+
+// Default returns the default log, opening DefaultPath on first use
+func Default() (*Log, error) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	if defaultLog == nil {
+		log, err := Open(DefaultPath())
+		if err != nil {
+			return nil, err
+		}
+		defaultLog = log
+	}
+	return defaultLog, nil
+}
+
+type initiatorKey struct{}
+
+// WithInitiator returns a context that attributes commands to initiator
+func WithInitiator(ctx context.Context, initiator string) context.Context {
+	return context.WithValue(ctx, initiatorKey{}, initiator)
+}
+
+// InitiatorFrom returns the initiator recorded in ctx, or fallback
+func InitiatorFrom(ctx context.Context, fallback string) string {
+	if initiator, ok := ctx.Value(initiatorKey{}).(string); ok && initiator != "" {
+		return initiator
+	}
+	return fallback
+}
+
+// Run runs cmd like cmd.Run and records it in the default audit log
+func Run(initiator string, cmd *exec.Cmd) error {
+	digest := newDigestWriter()
+	cmd.Stdout = teeOrDigest(cmd.Stdout, digest)
+	cmd.Stderr = teeOrDigest(cmd.Stderr, digest)
+
+	start := time.Now()
+	err := cmd.Run()
+	record(initiator, cmd, start, digest, err)
+	return err
+}
+
+// Output runs cmd like cmd.Output and records it in the default audit log
+func Output(initiator string, cmd *exec.Cmd) ([]byte, error) {
+	var stdout strings.Builder
+	digest := newDigestWriter()
+	cmd.Stdout = io.MultiWriter(&stdout, digest)
+	cmd.Stderr = teeOrDigest(cmd.Stderr, digest)
+
+	start := time.Now()
+	err := cmd.Run()
+	record(initiator, cmd, start, digest, err)
+	return []byte(stdout.String()), err
+}
+
+// CombinedOutput runs cmd like cmd.CombinedOutput and records it in the
+// default audit log
+func CombinedOutput(initiator string, cmd *exec.Cmd) ([]byte, error) {
+	var output lockedBuffer
+	digest := newDigestWriter()
+	cmd.Stdout = io.MultiWriter(&output, digest)
+	cmd.Stderr = cmd.Stdout
+
+	start := time.Now()
+	err := cmd.Run()
+	record(initiator, cmd, start, digest, err)
+	return output.Bytes(), err
+}
+
+// Start starts cmd like cmd.Start for commands that run alongside their
+// caller, such as audio capture. The returned function waits like cmd.Wait
+// and records the command in the default audit log.
+func Start(initiator string, cmd *exec.Cmd) (func() error, error) {
+	digest := newDigestWriter()
+	cmd.Stdout = teeOrDigest(cmd.Stdout, digest)
+	cmd.Stderr = teeOrDigest(cmd.Stderr, digest)
+
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		record(initiator, cmd, start, digest, err)
+		return nil, err
+	}
+	return func() error {
+		err := cmd.Wait()
+		record(initiator, cmd, start, digest, err)
+		return err
+	}, nil
+}
+
+// record appends an entry for a finished command to the default log
+// Audit failures are reported on stderr but never fail the command itself.
+func record(initiator string, cmd *exec.Cmd, start time.Time, digest *digestWriter, runErr error) {
+	entry := Entry{
+		Time:         start,
+		Initiator:    initiator,
+		Args:         cmd.Args,
+		Dir:          cmd.Dir,
+		DurationMS:   time.Since(start).Milliseconds(),
+		OutputBytes:  digest.n,
+		OutputDigest: digest.Sum(),
+	}
+
+	if runErr != nil {
+		entry.ExitCode = -1
+		var exitErr *exec.ExitError
+		if errors.As(runErr, &exitErr) {
+			entry.ExitCode = exitErr.ExitCode()
+		}
+		entry.Error = runErr.Error()
+	}
+
+	log, err := Default()
+	if err == nil {
+		_, err = log.Append(entry)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to write audit log: %v\n", err)
+	}
+}
+
+// digestWriter hashes everything written to it
+type digestWriter struct {
+	mu sync.Mutex
+	h  hash.Hash
+	n  int64
+}
+
+func newDigestWriter() *digestWriter {
+	return &digestWriter{h: sha256.New()}
+}
+
+func (d *digestWriter) Write(p []byte) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.n += int64(len(p))
+	return d.h.Write(p)
+}
+
+// Sum returns the digest in "sha256:<hex>" form
+func (d *digestWriter) Sum() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return "sha256:" + hex.EncodeToString(d.h.Sum(nil))
+}
+
+// teeOrDigest sends output to both w (if set) and the digest
+// The digest comes first so it sees output even when w stops accepting it.
+func teeOrDigest(w io.Writer, digest *digestWriter) io.Writer {
+	if w == nil {
+		return digest
+	}
+	return io.MultiWriter(digest, w)
+}
+
+// lockedBuffer is a buffer safe for concurrent stdout/stderr writes
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf = append(b.buf, p...)
+	return len(p), nil
+}
+
+func (b *lockedBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf
+}
@@ -0,0 +1,50 @@
+package audit
+
+import (
+	"strings"
+	"time"
+)
+
+// Filter selects audit entries; zero fields match everything
+type Filter struct {
+	Initiator string    // Initiator prefix (e.g. "bootstrap" matches "bootstrap:phase2")
+	Command   string    // Substring of the joined argument vector
+	Since     time.Time // Entries at or after this time
+	Until     time.Time // Entries before this time
+	Failed    bool      // Only entries with a non-zero exit code or an error
+	Limit     int       // Keep only the last Limit matches
+}
+
+// Match reports whether entry satisfies the filter (ignoring Limit)
+func (f Filter) Match(entry Entry) bool {
+	if f.Initiator != "" && !strings.HasPrefix(entry.Initiator, f.Initiator) {
+		return false
+	}
+	if f.Command != "" && !strings.Contains(strings.Join(entry.Args, " "), f.Command) {
+		return false
+	}
+	if !f.Since.IsZero() && entry.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !entry.Time.Before(f.Until) {
+		return false
+	}
+	if f.Failed && entry.ExitCode == 0 && entry.Error == "" {
+		return false
+	}
+	return true
+}
+
+// Apply returns the entries matching the filter, oldest first
+func (f Filter) Apply(entries []Entry) []Entry {
+	var matched []Entry
+	for _, entry := range entries {
+		if f.Match(entry) {
+			matched = append(matched, entry)
+		}
+	}
+	if f.Limit > 0 && len(matched) > f.Limit {
+		matched = matched[len(matched)-f.Limit:]
+	}
+	return matched
+}
@@ -0,0 +1,76 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// VerifyResult summarizes a successful verification
+type VerifyResult struct {
+	Entries  int64  // Number of entries checked
+	HeadHash string // Hash of the last entry; record it elsewhere to detect truncation
+}
+
+// TamperError describes the first place the chain is broken
+type TamperError struct {
+	Line   int
+	Reason string
+}
+
+func (e *TamperError) Error() string {
+	return fmt.Sprintf("audit log tampered at line %d: %s", e.Line, e.Reason)
+}
+
+// Verify walks the log at path and checks that every entry's hash is correct,
+// that each entry links to the previous one and that sequence numbers are
+// contiguous. Any edit, insertion, deletion or reordering before the last
+// entry is reported as a *TamperError.
+func Verify(path string) (*VerifyResult, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	result := &VerifyResult{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, &TamperError{Line: line, Reason: fmt.Sprintf("unparseable entry: %v", err)}
+		}
+
+		if entry.Seq != result.Entries+1 {
+			return nil, &TamperError{Line: line, Reason: fmt.Sprintf("expected sequence %d, found %d", result.Entries+1, entry.Seq)}
+		}
+		if entry.PrevHash != result.HeadHash {
+			return nil, &TamperError{Line: line, Reason: "previous hash does not match the preceding entry"}
+		}
+
+		hash, err := computeHash(entry)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash entry on line %d: %w", line, err)
+		}
+		if hash != entry.Hash {
+			return nil, &TamperError{Line: line, Reason: "entry contents do not match its hash"}
+		}
+
+		result.Entries++
+		result.HeadHash = entry.Hash
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	return result, nil
+}
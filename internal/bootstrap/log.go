@@ -0,0 +1,25 @@
+package bootstrap
+
+import (
+	"log/slog"
+	"sync/atomic"
+
+	"github.com/anicolao/emrys/internal/logging"
+)
+
+// progress receives bootstrap progress messages; unset means slog.Default()
+var progress atomic.Pointer[slog.Logger]
+
+// SetLogger replaces the logger that receives bootstrap progress messages
+func SetLogger(logger *slog.Logger) {
+	progress.Store(logger)
+}
+
+// logger returns the logger for progress messages, by default tagged with
+// the component
+func logger() *slog.Logger {
+	if l := progress.Load(); l != nil {
+		return l
+	}
+	return logging.Component(slog.Default(), "bootstrap")
+}
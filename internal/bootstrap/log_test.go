@@ -0,0 +1,22 @@
+package bootstrap
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSetLogger(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	var out bytes.Buffer
+	SetLogger(slog.New(slog.NewTextHandler(&out, nil)))
+	t.Cleanup(func() { SetLogger(nil) })
+
+	if err := CreateVoiceConfig(); err != nil {
+		t.Fatalf("CreateVoiceConfig failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "Saved voice settings") {
+		t.Errorf("Expected progress on the injected logger, got %q", out.String())
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/anicolao/emrys/internal/config"
+	"github.com/anicolao/emrys/internal/logging"
 	"github.com/anicolao/emrys/internal/nixdarwin"
 )
 
@@ -61,7 +62,7 @@ func UpdateNixDarwinConfiguration() error {
 	if err != nil {
 		if os.IsNotExist(err) {
 			// File doesn't exist, use the embedded template
-			fmt.Println("Configuration file not found, using embedded template...")
+			logger().Info("Configuration file not found, using embedded template...")
 
 			// Get the embedded configuration
 			configStr = config.DefaultNixDarwinConfig
@@ -150,7 +151,7 @@ func UpdateNixDarwinConfiguration() error {
 
 	// If no changes were made, we're already up to date
 	if !configChanged {
-		fmt.Println("✓ Configuration already includes Phase 1 packages")
+		logger().Info("✓ Configuration already includes Phase 1 packages")
 		return nil
 	}
 
@@ -192,23 +193,23 @@ func UpdateNixDarwinConfiguration() error {
 		return fmt.Errorf("failed to write configuration: %w", err)
 	}
 
-	fmt.Printf("✓ Updated configuration at %s\n", configPath)
+	logger().Info("✓ Updated configuration at {path}", "path", configPath)
 	return nil
 }
 
 // VerifyPackageInstallation verifies that all Phase 1 packages are installed
 func VerifyPackageInstallation() error {
-	fmt.Println("Verifying package installation...")
+	logger().Info("Verifying package installation...")
 
 	missing := GetMissingPackages()
 	if len(missing) > 0 {
 		return fmt.Errorf("some packages are still missing: %s", strings.Join(missing, ", "))
 	}
 
-	fmt.Println("✓ All Phase 1 packages verified:")
+	logger().Info("✓ All Phase 1 packages verified:")
 	for _, pkg := range Phase1Packages {
 		path, _ := exec.LookPath(pkg)
-		fmt.Printf("  - %-10s %s\n", pkg, path)
+		logger().Info("  - {package} at {path}", "package", pkg, "path", path)
 	}
 
 	return nil
@@ -216,15 +217,11 @@ func VerifyPackageInstallation() error {
 
 // RunPhase1 executes the complete Phase 1 bootstrap process
 func RunPhase1() error {
-	fmt.Println("═══════════════════════════════════════")
-	fmt.Println("  Phase 1: Package Installation")
-	fmt.Println("═══════════════════════════════════════")
-	fmt.Println()
+	logger().Info("Phase 1: Package Installation", logging.Banner)
 
 	// Check if Phase 1 is already complete
 	if IsPhase1Complete() {
-		fmt.Println("✓ Phase 1 is already complete!")
-		fmt.Println()
+		logger().Info("✓ Phase 1 is already complete!")
 		if err := VerifyPackageInstallation(); err != nil {
 			return err
 		}
@@ -234,38 +231,31 @@ func RunPhase1() error {
 	// Show what packages are missing
 	missing := GetMissingPackages()
 	if len(missing) > 0 {
-		fmt.Println("Missing packages:")
-		for _, pkg := range missing {
-			fmt.Printf("  - %s\n", pkg)
-		}
-		fmt.Println()
+		logger().Info("Missing packages: {packages}", "packages", strings.Join(missing, ", "))
 	}
 
 	// Step 1: Update the nix-darwin configuration
-	fmt.Println("Step 1: Updating nix-darwin configuration...")
+	logger().Info("Step 1: Updating nix-darwin configuration...", logging.Break)
 	if err := UpdateNixDarwinConfiguration(); err != nil {
 		return fmt.Errorf("failed to update configuration: %w", err)
 	}
-	fmt.Println()
 
 	// Step 2: Apply the configuration
-	fmt.Println("Step 2: Applying configuration...")
+	logger().Info("Step 2: Applying configuration...", logging.Break)
 	if err := nixdarwin.ApplyConfiguration(); err != nil {
 		return fmt.Errorf("failed to apply configuration: %w", err)
 	}
-	fmt.Println()
 
 	// Step 3: Verify installation
-	fmt.Println("Step 3: Verifying installation...")
+	logger().Info("Step 3: Verifying installation...", logging.Break)
 	if err := VerifyPackageInstallation(); err != nil {
 		return fmt.Errorf("verification failed: %w", err)
 	}
-	fmt.Println()
 
-	fmt.Println("═══════════════════════════════════════")
-	fmt.Println("✓ Phase 1 Bootstrap Complete!")
-	fmt.Println("═══════════════════════════════════════")
-	fmt.Println()
+	if err := RecordPhase(1); err != nil {
+		return err
+	}
 
+	logger().Info("✓ Phase 1 Bootstrap Complete!", logging.Banner)
 	return nil
 }
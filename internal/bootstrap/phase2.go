@@ -11,37 +11,54 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/anicolao/emrys/internal/audit"
+	"github.com/anicolao/emrys/internal/config"
+	"github.com/anicolao/emrys/internal/llm"
+	"github.com/anicolao/emrys/internal/logging"
 )
 
-// DefaultModel is the model to download and use for Emrys
-const DefaultModel = "llama3.2"
+// phase2Initiator attributes Phase 2 commands in the audit log
+const phase2Initiator = "bootstrap:phase2"
+
+// DefaultModel is the model to download when config.yaml does not name one
+const DefaultModel = llm.DefaultModel
+
+// OllamaAgent is the label of the launch agent that runs Ollama
+const OllamaAgent = "com.ollama.service"
 
-// OllamaAPIURL is the URL of the Ollama API
-const OllamaAPIURL = "http://localhost:11434"
+// OllamaAPIURL is the URL of the Ollama API when config.yaml does not set one
+const OllamaAPIURL = llm.DefaultURL
 
 // IsPhase2Complete checks if Phase 2 is complete
 func IsPhase2Complete() bool {
+	// RunPhase2 reports a configuration that cannot be loaded
+	settings, err := config.Load(config.DefaultPath())
+	if err != nil {
+		return false
+	}
+
 	// Check if Ollama service is running
-	if !IsOllamaRunning() {
+	if !IsOllamaRunning(settings.Ollama.URL) {
 		return false
 	}
 
-	// Check if the default model is installed
-	if !IsModelInstalled(DefaultModel) {
+	// Check if the configured model is installed
+	if !IsModelInstalled(settings.Models.Chat) {
 		return false
 	}
 
 	return true
 }
 
-// IsOllamaRunning checks if the Ollama service is running
-func IsOllamaRunning() bool {
+// IsOllamaRunning checks if the Ollama service is running at url
+func IsOllamaRunning(url string) bool {
 	// Try to ping the Ollama API
 	client := &http.Client{
 		Timeout: 2 * time.Second,
 	}
 
-	resp, err := client.Get(OllamaAPIURL)
+	resp, err := client.Get(url)
 	if err != nil {
 		return false
 	}
@@ -53,7 +70,7 @@ func IsOllamaRunning() bool {
 // IsModelInstalled checks if a specific model is installed
 func IsModelInstalled(modelName string) bool {
 	cmd := exec.Command("ollama", "list")
-	output, err := cmd.Output()
+	output, err := audit.Output(phase2Initiator, cmd)
 	if err != nil {
 		return false
 	}
@@ -65,7 +82,7 @@ func IsModelInstalled(modelName string) bool {
 // GetInstalledModels returns a list of installed Ollama models
 func GetInstalledModels() ([]string, error) {
 	cmd := exec.Command("ollama", "list")
-	output, err := cmd.Output()
+	output, err := audit.Output(phase2Initiator, cmd)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list models: %w", err)
 	}
@@ -89,11 +106,12 @@ func GetInstalledModels() ([]string, error) {
 	return models, nil
 }
 
-// StartOllamaService starts the Ollama service using launchd
-func StartOllamaService() error {
+// StartOllamaService starts the Ollama service using launchd and waits for
+// it to answer at url
+func StartOllamaService(url string) error {
 	// First check if Ollama is already running
-	if IsOllamaRunning() {
-		fmt.Println("✓ Ollama service is already running")
+	if IsOllamaRunning(url) {
+		logger().Info("✓ Ollama service is already running")
 		return nil
 	}
 
@@ -108,30 +126,27 @@ func StartOllamaService() error {
 		return fmt.Errorf("failed to get home directory: %w", err)
 	}
 
-	plistPath := filepath.Join(homeDir, "Library", "LaunchAgents", "com.ollama.service.plist")
+	plistPath := filepath.Join(homeDir, "Library", "LaunchAgents", OllamaAgent+".plist")
 
 	// Unload first in case it's already loaded but not running
-	exec.Command("launchctl", "unload", plistPath).Run()
+	audit.Run(phase2Initiator, exec.Command("launchctl", "unload", plistPath))
 
 	// Load the launch agent
 	cmd := exec.Command("launchctl", "load", plistPath)
-	if output, err := cmd.CombinedOutput(); err != nil {
+	if output, err := audit.CombinedOutput(phase2Initiator, cmd); err != nil {
 		return fmt.Errorf("failed to load launch agent: %w\nOutput: %s", err, string(output))
 	}
 
 	// Wait for the service to start
-	fmt.Print("Starting Ollama service")
+	logger().Info("Starting Ollama service...")
 	for i := 0; i < 30; i++ {
 		time.Sleep(1 * time.Second)
-		fmt.Print(".")
-		if IsOllamaRunning() {
-			fmt.Println()
-			fmt.Println("✓ Ollama service started successfully")
+		if IsOllamaRunning(url) {
+			logger().Info("✓ Ollama service started after {seconds}s", "seconds", i+1)
 			return nil
 		}
 	}
 
-	fmt.Println()
 	return fmt.Errorf("ollama service failed to start within 30 seconds")
 }
 
@@ -148,11 +163,11 @@ func CreateOllamaLaunchAgent() error {
 		return fmt.Errorf("failed to create LaunchAgents directory: %w", err)
 	}
 
-	plistPath := filepath.Join(launchAgentsDir, "com.ollama.service.plist")
+	plistPath := filepath.Join(launchAgentsDir, OllamaAgent+".plist")
 
 	// Check if plist already exists
 	if _, err := os.Stat(plistPath); err == nil {
-		fmt.Printf("✓ Launch agent already exists at %s\n", plistPath)
+		logger().Info("✓ Launch agent already exists at {path}", "path", plistPath)
 		return nil
 	}
 
@@ -196,48 +211,25 @@ func CreateOllamaLaunchAgent() error {
 		return fmt.Errorf("failed to write plist file: %w", err)
 	}
 
-	fmt.Printf("✓ Created launch agent at %s\n", plistPath)
+	logger().Info("✓ Created launch agent at {path}", "path", plistPath)
 	return nil
 }
 
 // DownloadModel downloads and installs an Ollama model with progress indication
 func DownloadModel(modelName string) error {
-	fmt.Printf("Downloading model '%s'...\n", modelName)
-	fmt.Println("Note: This may take several minutes depending on your internet connection")
-	fmt.Println()
+	logger().Info("Downloading model '{model}'...", "model", modelName)
+	logger().Info("Note: This may take several minutes depending on your internet connection")
 
-	// Start the pull command
+	// Run the pull command, displaying its progress in real-time
 	cmd := exec.Command("ollama", "pull", modelName)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
 
-	// Create pipes for stdout and stderr
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return fmt.Errorf("failed to create stdout pipe: %w", err)
-	}
-
-	stderr, err := cmd.StderrPipe()
-	if err != nil {
-		return fmt.Errorf("failed to create stderr pipe: %w", err)
-	}
-
-	// Start the command
-	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("failed to start model download: %w", err)
-	}
-
-	// Read and display output in real-time using io.Copy
-	go io.Copy(os.Stdout, stdout)
-
-	// Read and display errors in real-time using io.Copy
-	go io.Copy(os.Stderr, stderr)
-
-	// Wait for the command to complete
-	if err := cmd.Wait(); err != nil {
+	if err := audit.Run(phase2Initiator, cmd); err != nil {
 		return fmt.Errorf("model download failed: %w", err)
 	}
 
-	fmt.Println()
-	fmt.Printf("✓ Model '%s' downloaded successfully\n", modelName)
+	logger().Info("✓ Model '{model}' downloaded successfully", "model", modelName, logging.Break)
 
 	// Verify the model was installed
 	if !IsModelInstalled(modelName) {
@@ -247,9 +239,10 @@ func DownloadModel(modelName string) error {
 	return nil
 }
 
-// VerifyModelIntegrity verifies that a model can be used for inference
-func VerifyModelIntegrity(modelName string) error {
-	fmt.Printf("Verifying model '%s'...\n", modelName)
+// VerifyModelIntegrity verifies that a model can be used for inference by
+// the Ollama API at url
+func VerifyModelIntegrity(url, modelName string) error {
+	logger().Info("Verifying model '{model}'...", "model", modelName)
 
 	// Test the model with a simple query
 	requestBody := map[string]interface{}{
@@ -268,7 +261,7 @@ func VerifyModelIntegrity(modelName string) error {
 	}
 
 	resp, err := client.Post(
-		fmt.Sprintf("%s/api/generate", OllamaAPIURL),
+		fmt.Sprintf("%s/api/generate", strings.TrimSuffix(url, "/")),
 		"application/json",
 		bytes.NewBuffer(jsonData),
 	)
@@ -288,20 +281,21 @@ func VerifyModelIntegrity(modelName string) error {
 		return fmt.Errorf("failed to parse response: %w", err)
 	}
 
-	fmt.Printf("✓ Model '%s' verified successfully\n", modelName)
+	logger().Info("✓ Model '{model}' verified successfully", "model", modelName)
 	return nil
 }
 
-// TestOllamaAPI tests the Ollama API connectivity
-func TestOllamaAPI() error {
-	fmt.Println("Testing Ollama API connectivity...")
+// TestOllamaAPI tests connectivity to the Ollama API at url
+func TestOllamaAPI(url string) error {
+	logger().Info("Testing Ollama API connectivity...")
 
 	client := &http.Client{
 		Timeout: 5 * time.Second,
 	}
 
 	// Test the API root endpoint
-	resp, err := client.Get(OllamaAPIURL)
+	url = strings.TrimSuffix(url, "/")
+	resp, err := client.Get(url)
 	if err != nil {
 		return fmt.Errorf("failed to connect to Ollama API: %w", err)
 	}
@@ -312,7 +306,7 @@ func TestOllamaAPI() error {
 	}
 
 	// Test the tags endpoint to list models
-	resp, err = client.Get(fmt.Sprintf("%s/api/tags", OllamaAPIURL))
+	resp, err = client.Get(fmt.Sprintf("%s/api/tags", url))
 	if err != nil {
 		return fmt.Errorf("failed to list models via API: %w", err)
 	}
@@ -322,63 +316,59 @@ func TestOllamaAPI() error {
 		return fmt.Errorf("failed to list models, status %d", resp.StatusCode)
 	}
 
-	fmt.Println("✓ Ollama API is accessible and responding")
+	logger().Info("✓ Ollama API is accessible and responding")
 	return nil
 }
 
 // RunPhase2 executes the complete Phase 2 bootstrap process
 func RunPhase2() error {
-	fmt.Println("═══════════════════════════════════════")
-	fmt.Println("  Phase 2: Ollama Setup")
-	fmt.Println("═══════════════════════════════════════")
-	fmt.Println()
+	settings, err := config.Load(config.DefaultPath())
+	if err != nil {
+		return err
+	}
+	url, model := settings.Ollama.URL, settings.Models.Chat
+
+	logger().Info("Phase 2: Ollama Setup", logging.Banner)
 
 	// Check if Phase 2 is already complete
 	if IsPhase2Complete() {
-		fmt.Println("✓ Phase 2 is already complete!")
-		fmt.Println()
+		logger().Info("✓ Phase 2 is already complete!")
 		return nil
 	}
 
 	// Step 1: Start Ollama service
-	fmt.Println("Step 1: Starting Ollama service...")
-	if err := StartOllamaService(); err != nil {
+	logger().Info("Step 1: Starting Ollama service...", logging.Break)
+	if err := StartOllamaService(url); err != nil {
 		return fmt.Errorf("failed to start Ollama service: %w", err)
 	}
-	fmt.Println()
 
 	// Step 2: Test API connectivity
-	fmt.Println("Step 2: Testing Ollama API...")
-	if err := TestOllamaAPI(); err != nil {
+	logger().Info("Step 2: Testing Ollama API...", logging.Break)
+	if err := TestOllamaAPI(url); err != nil {
 		return fmt.Errorf("failed to test Ollama API: %w", err)
 	}
-	fmt.Println()
 
-	// Step 3: Download default model
-	fmt.Println("Step 3: Downloading default model...")
-	if !IsModelInstalled(DefaultModel) {
-		if err := DownloadModel(DefaultModel); err != nil {
+	// Step 3: Download the configured model
+	logger().Info("Step 3: Downloading default model...", logging.Break)
+	if !IsModelInstalled(model) {
+		if err := DownloadModel(model); err != nil {
 			return fmt.Errorf("failed to download model: %w", err)
 		}
 	} else {
-		fmt.Printf("✓ Model '%s' is already installed\n", DefaultModel)
+		logger().Info("✓ Model '{model}' is already installed", "model", model)
 	}
-	fmt.Println()
 
 	// Step 4: Verify model integrity
-	fmt.Println("Step 4: Verifying model...")
-	if err := VerifyModelIntegrity(DefaultModel); err != nil {
+	logger().Info("Step 4: Verifying model...", logging.Break)
+	if err := VerifyModelIntegrity(url, model); err != nil {
 		return fmt.Errorf("failed to verify model: %w", err)
 	}
-	fmt.Println()
 
-	fmt.Println("═══════════════════════════════════════")
-	fmt.Println("✓ Phase 2 Bootstrap Complete!")
-	fmt.Println("═══════════════════════════════════════")
-	fmt.Println()
-	fmt.Printf("Ollama is running at %s\n", OllamaAPIURL)
-	fmt.Printf("Default model: %s\n", DefaultModel)
-	fmt.Println()
+	if err := RecordPhase(2); err != nil {
+		return err
+	}
 
+	logger().Info("✓ Phase 2 Bootstrap Complete!", logging.Banner)
+	logger().Info("Ollama is running at {url} with the model {model}", "url", url, "model", model)
 	return nil
 }
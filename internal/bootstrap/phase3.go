@@ -8,10 +8,16 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/anicolao/emrys/internal/audit"
+	"github.com/anicolao/emrys/internal/config"
+	"github.com/anicolao/emrys/internal/logging"
 	"github.com/anicolao/emrys/internal/nixdarwin"
 	"github.com/anicolao/emrys/internal/voice"
 )
 
+// phase3Initiator attributes Phase 3 commands in the audit log
+const phase3Initiator = "bootstrap:phase3"
+
 // DefaultVoice is the default voice for Emrys
 const DefaultVoice = "Jamie"
 
@@ -22,19 +28,15 @@ func IsPhase3Complete() bool {
 		return false
 	}
 
-	// Check if voice configuration exists
-	configPath := GetVoiceConfigPath()
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		return false
-	}
-
-	return true
+	// Check if config.yaml has voice settings
+	doc, err := config.LoadDocument(GetVoiceConfigPath())
+	return err == nil && doc.Has("voice")
 }
 
-// GetVoiceConfigPath returns the path to the voice configuration file
+// GetVoiceConfigPath returns the path to the file holding the voice
+// settings, config.yaml
 func GetVoiceConfigPath() string {
-	homeDir, _ := os.UserHomeDir()
-	return filepath.Join(homeDir, ".config", "emrys", "voice.conf")
+	return config.DefaultPath()
 }
 
 // UpdateNixDarwinConfigForVoice updates the nix-darwin configuration to install Jamie voice
@@ -56,7 +58,7 @@ func UpdateNixDarwinConfigForVoice() error {
 
 	// Check if voice configuration already exists
 	if strings.Contains(configStr, "# Phase 3: Voice Output Configuration") {
-		fmt.Println("✓ Configuration already includes voice setup")
+		logger().Info("✓ Configuration already includes voice setup")
 		return nil
 	}
 
@@ -76,42 +78,36 @@ func UpdateNixDarwinConfigForVoice() error {
 		return fmt.Errorf("failed to write configuration: %w", err)
 	}
 
-	fmt.Printf("✓ Updated configuration at %s\n", configPath)
+	logger().Info("✓ Updated configuration at {path}", "path", configPath)
 	return nil
 }
 
 // InstallJamieVoice checks if Jamie voice is installed and installs it programmatically if not
 func InstallJamieVoice() error {
-	fmt.Println("Checking Jamie voice installation...")
+	logger().Info("Checking Jamie voice installation...")
 
 	// Check if Jamie voice is available
 	if voice.IsVoiceAvailable(DefaultVoice) {
-		fmt.Printf("✓ Jamie voice is already installed\n")
+		logger().Info("✓ Jamie voice is already installed")
 		return nil
 	}
 
 	// Jamie voice is not installed, install it using AppleScript
-	fmt.Println()
-	fmt.Println("⚠ Jamie voice is not installed on this system")
-	fmt.Println("Opening VoiceOver Utility to install Jamie voice...")
-	fmt.Println()
+	logger().Warn("Jamie voice is not installed on this system", logging.Break)
+	logger().Info("Opening VoiceOver Utility to install Jamie voice...")
 
 	// Try to install the voice using AppleScript
 	if err := installVoiceUsingAppleScript(); err != nil {
 		// If AppleScript fails, provide manual instructions
-		fmt.Println()
-		fmt.Printf("⚠ Could not open VoiceOver Utility automatically: %v\n", err)
-		fmt.Println()
-		fmt.Println("Please install Jamie (Premium) voice manually:")
-		fmt.Println()
-		fmt.Println("To install Jamie voice:")
-		fmt.Println("  1. Open VoiceOver Utility (in /System/Applications/Utilities/)")
-		fmt.Println("  2. Go to the 'Speech' section")
-		fmt.Println("  3. Click on the 'Voices' tab")
-		fmt.Println("  4. Find 'Jamie' in the voice list (under English (United Kingdom))")
-		fmt.Println("  5. Click the download icon (cloud with down arrow) next to Jamie")
-		fmt.Println("  6. Wait for the download to complete (may take several minutes)")
-		fmt.Println()
+		logger().Warn("Could not open VoiceOver Utility automatically", "error", err, logging.Break)
+		logger().Info("Please install Jamie (Premium) voice manually:", logging.Break)
+		logger().Info("To install Jamie voice:", logging.Break)
+		logger().Info("  1. Open VoiceOver Utility (in /System/Applications/Utilities/)")
+		logger().Info("  2. Go to the 'Speech' section")
+		logger().Info("  3. Click on the 'Voices' tab")
+		logger().Info("  4. Find 'Jamie' in the voice list (under English (United Kingdom))")
+		logger().Info("  5. Click the download icon (cloud with down arrow) next to Jamie")
+		logger().Info("  6. Wait for the download to complete (may take several minutes)")
 	}
 
 	// Ask if user has completed the installation
@@ -121,19 +117,18 @@ func InstallJamieVoice() error {
 
 	// Check again after user confirms
 	if !voice.IsVoiceAvailable(DefaultVoice) {
-		fmt.Println()
-		fmt.Println("⚠ Jamie voice is still not available")
-		fmt.Println("Please install the voice and run this command again.")
+		logger().Warn("Jamie voice is still not available", logging.Break)
+		logger().Info("Please install the voice and run this command again.")
 		return fmt.Errorf("Jamie voice not found")
 	}
 
-	fmt.Println("✓ Jamie voice is now available")
+	logger().Info("✓ Jamie voice is now available")
 	return nil
 }
 
 // installVoiceUsingAppleScript opens VoiceOver Utility to install Jamie voice using AppleScript
 func installVoiceUsingAppleScript() error {
-	fmt.Println("Opening VoiceOver Utility to download Jamie voice...")
+	logger().Info("Opening VoiceOver Utility to download Jamie voice...")
 
 	// The VoiceOver Utility is where voices are actually installed
 	appleScriptCode := `
@@ -144,22 +139,19 @@ func installVoiceUsingAppleScript() error {
 
 	// Execute the AppleScript
 	cmd := exec.Command("osascript", "-e", appleScriptCode)
-	output, err := cmd.CombinedOutput()
+	output, err := audit.CombinedOutput(phase3Initiator, cmd)
 	if err != nil {
 		return fmt.Errorf("failed to open VoiceOver Utility: %w (output: %s)", err, string(output))
 	}
 
-	fmt.Println()
-	fmt.Println("✓ VoiceOver Utility opened")
-	fmt.Println()
-	fmt.Println("To install Jamie voice:")
-	fmt.Println("  1. In the VoiceOver Utility window, go to the 'Speech' section")
-	fmt.Println("  2. Click on the 'Voices' tab")
-	fmt.Println("  3. Find 'Jamie' in the voice list (under English (United Kingdom))")
-	fmt.Println("  4. Click the download icon (cloud with down arrow) next to Jamie")
-	fmt.Println("  5. Wait for the download to complete (may take several minutes)")
-	fmt.Println("  6. Once downloaded, you can close the VoiceOver Utility")
-	fmt.Println()
+	logger().Info("✓ VoiceOver Utility opened", logging.Break)
+	logger().Info("To install Jamie voice:", logging.Break)
+	logger().Info("  1. In the VoiceOver Utility window, go to the 'Speech' section")
+	logger().Info("  2. Click on the 'Voices' tab")
+	logger().Info("  3. Find 'Jamie' in the voice list (under English (United Kingdom))")
+	logger().Info("  4. Click the download icon (cloud with down arrow) next to Jamie")
+	logger().Info("  5. Wait for the download to complete (may take several minutes)")
+	logger().Info("  6. Once downloaded, you can close the VoiceOver Utility")
 
 	return nil
 }
@@ -167,7 +159,7 @@ func installVoiceUsingAppleScript() error {
 // getMacOSVersion returns the major version number of macOS
 func getMacOSVersion() (int, error) {
 	cmd := exec.Command("sw_vers", "-productVersion")
-	output, err := cmd.Output()
+	output, err := audit.Output(phase3Initiator, cmd)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get macOS version: %w", err)
 	}
@@ -205,29 +197,25 @@ func confirmVoiceInstallation() bool {
 	}
 }
 
-// TestVoiceOutput tests the voice output with a confirmation phrase
-func TestVoiceOutput() error {
-	fmt.Println("Testing voice output...")
-	fmt.Println()
+// TestVoiceOutput tests the voice output with a confirmation phrase, using
+// the voice settings from config.yaml
+func TestVoiceOutput(settings voice.Config) error {
+	logger().Info("Testing voice output...")
 
 	// Create a test message
 	testMessage := "Hello! I am Emrys, your personal AI assistant. Voice output is working correctly."
 
 	// Test the voice
-	if err := voice.Test(DefaultVoice); err != nil {
+	if err := voice.Test(settings); err != nil {
 		return fmt.Errorf("voice test failed: %w", err)
 	}
 
-	fmt.Println("✓ Voice output test successful")
-	fmt.Println()
+	logger().Info("✓ Voice output test successful")
 
 	// Speak the test message
-	fmt.Printf("Speaking: \"%s\"\n", testMessage)
-	fmt.Println()
+	logger().Info("Speaking: \"{text}\"", "text", testMessage)
 
-	config := voice.DefaultConfig()
-	config.Voice = DefaultVoice
-	speaker := voice.NewSpeaker(config)
+	speaker := voice.NewSpeaker(settings)
 	defer speaker.Close()
 
 	if err := speaker.SpeakSync(testMessage); err != nil {
@@ -237,167 +225,154 @@ func TestVoiceOutput() error {
 	return nil
 }
 
-// CreateVoiceConfig creates the default voice configuration file
-func CreateVoiceConfig() error {
-	configPath := GetVoiceConfigPath()
+// voiceSettings are the voice settings Phase 3 writes to config.yaml so they
+// are easy to find and change; the rest keep their defaults
+var voiceSettings = []string{"enabled", "voice", "rate", "volume", "quiet_hours", "backend"}
 
-	// Create config directory if it doesn't exist
-	configDir := filepath.Dir(configPath)
-	if err := os.MkdirAll(configDir, 0755); err != nil {
-		return fmt.Errorf("failed to create config directory: %w", err)
+// CreateVoiceConfig writes the voice section of config.yaml, first importing
+// a voice.conf left by an older version
+func CreateVoiceConfig() error {
+	if err := importVoiceConfig(); err != nil {
+		return err
 	}
 
-	// Check if config already exists
-	if _, err := os.Stat(configPath); err == nil {
-		fmt.Printf("✓ Voice configuration already exists at %s\n", configPath)
+	path := GetVoiceConfigPath()
+	doc, err := config.LoadDocument(path)
+	if err != nil {
+		return err
+	}
+	if doc.Has("voice") {
+		logger().Info("✓ Voice settings already exist in {path}", "path", path)
 		return nil
 	}
 
-	// Create default configuration
-	config := voice.DefaultConfig()
-	config.Voice = DefaultVoice
-
-	// Write configuration file
-	configContent := fmt.Sprintf(`# Emrys Voice Output Configuration
-# This file contains settings for text-to-speech output
-
-# Enable or disable voice output (true/false)
-enabled = %t
-
-# Voice name (e.g., Jamie, Samantha, Alex)
-voice = %s
-
-# Speech rate in words per minute (typical range: 150-250)
-rate = %d
-
-# Volume from 0.0 to 1.0 (note: controlled via system volume)
-volume = %.1f
-
-# Enable quiet hours (true/false)
-quiet_hours = %t
-
-# Quiet hours start (24-hour format, 0-23)
-quiet_start = %d
+	defaults := config.Default()
+	defaults.Voice.Voice = DefaultVoice
+	for _, key := range voiceSettings {
+		f, err := defaults.Field("voice." + key)
+		if err != nil {
+			return err
+		}
+		if err := doc.Set(f.Path, f.String()); err != nil {
+			return err
+		}
+	}
+	if err := doc.Save(path); err != nil {
+		return err
+	}
 
-# Quiet hours end (24-hour format, 0-23)
-quiet_end = %d
-`,
-		config.Enabled,
-		config.Voice,
-		config.Rate,
-		config.Volume,
-		config.QuietHours,
-		config.QuietStart,
-		config.QuietEnd,
-	)
+	logger().Info("✓ Saved voice settings to {path}", "path", path)
+	return nil
+}
 
-	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
-		return fmt.Errorf("failed to write configuration: %w", err)
+// importVoiceConfig copies voice.conf into config.yaml if config.yaml does
+// not exist yet
+func importVoiceConfig() error {
+	migrated, err := config.MigrateVoiceConf(config.DefaultPath(), config.VoiceConfPath())
+	if err != nil {
+		return err
+	}
+	if migrated {
+		logger().Info("✓ Imported voice settings into {path}", "path", config.DefaultPath())
 	}
-
-	fmt.Printf("✓ Created voice configuration at %s\n", configPath)
 	return nil
 }
 
-// ListAvailableVoices lists all available voices on the system
-func ListAvailableVoices() error {
-	fmt.Println("Available voices on this system:")
-	fmt.Println()
+// ListAvailableVoices lists the voices of the configured backend, marking
+// the configured voice
+func ListAvailableVoices(settings voice.Config) error {
+	logger().Info("Listing the voices on this system...")
 
-	voices, err := voice.ListAvailableVoices()
+	voices, err := voice.ListAvailableVoices(settings)
 	if err != nil {
 		return fmt.Errorf("failed to list voices: %w", err)
 	}
 
 	if len(voices) == 0 {
-		fmt.Println("  No voices found")
+		logger().Info("No voices found")
 		return nil
 	}
 
-	for i, v := range voices {
-		if v == DefaultVoice {
-			fmt.Printf("  %d. %s ✓ (default)\n", i+1, v)
+	for _, v := range voices {
+		if v == settings.Voice {
+			logger().Info("  - {voice} ✓ (default)", "voice", v)
 		} else {
-			fmt.Printf("  %d. %s\n", i+1, v)
+			logger().Info("  - {voice}", "voice", v)
 		}
 	}
 
-	fmt.Println()
 	return nil
 }
 
 // RunPhase3 executes the complete Phase 3 bootstrap process
 func RunPhase3() error {
-	fmt.Println("═══════════════════════════════════════")
-	fmt.Println("  Phase 3: Voice Output Configuration")
-	fmt.Println("═══════════════════════════════════════")
-	fmt.Println()
+	settings, err := config.Load(config.DefaultPath())
+	if err != nil {
+		return err
+	}
+
+	logger().Info("Phase 3: Voice Output Configuration", logging.Banner)
 
 	// Check if Phase 3 is already complete
 	if IsPhase3Complete() {
-		fmt.Println("✓ Phase 3 is already complete!")
-		fmt.Println()
-		if err := TestVoiceOutput(); err != nil {
-			fmt.Printf("Warning: Voice test failed: %v\n", err)
+		logger().Info("✓ Phase 3 is already complete!")
+		if err := TestVoiceOutput(settings.Voice); err != nil {
+			logger().Warn("Voice test failed", "error", err)
 		}
 		return nil
 	}
 
 	// Step 1: Update nix-darwin configuration
-	fmt.Println("Step 1: Updating nix-darwin configuration...")
+	logger().Info("Step 1: Updating nix-darwin configuration...", logging.Break)
 	if err := UpdateNixDarwinConfigForVoice(); err != nil {
 		return fmt.Errorf("failed to update configuration: %w", err)
 	}
-	fmt.Println()
 
 	// Step 2: Apply the configuration
-	fmt.Println("Step 2: Applying configuration...")
+	logger().Info("Step 2: Applying configuration...", logging.Break)
 	if err := nixdarwin.ApplyConfiguration(); err != nil {
 		return fmt.Errorf("failed to apply configuration: %w", err)
 	}
-	fmt.Println()
 
 	// Step 3: Check and install Jamie voice
-	fmt.Println("Step 3: Installing Jamie voice...")
+	logger().Info("Step 3: Installing Jamie voice...", logging.Break)
 	if err := InstallJamieVoice(); err != nil {
 		return fmt.Errorf("failed to install Jamie voice: %w", err)
 	}
-	fmt.Println()
 
 	// Step 4: List available voices
-	fmt.Println("Step 4: Listing available voices...")
-	if err := ListAvailableVoices(); err != nil {
+	logger().Info("Step 4: Listing available voices...", logging.Break)
+	if err := ListAvailableVoices(settings.Voice); err != nil {
 		return fmt.Errorf("failed to list voices: %w", err)
 	}
-	fmt.Println()
 
 	// Step 5: Create voice configuration
-	fmt.Println("Step 5: Creating voice configuration...")
+	logger().Info("Step 5: Creating voice configuration...", logging.Break)
 	if err := CreateVoiceConfig(); err != nil {
 		return fmt.Errorf("failed to create voice configuration: %w", err)
 	}
-	fmt.Println()
 
 	// Step 6: Test voice output
-	fmt.Println("Step 6: Testing voice output...")
-	if err := TestVoiceOutput(); err != nil {
+	logger().Info("Step 6: Testing voice output...", logging.Break)
+	// Creating the configuration may have imported other voice settings
+	if settings, err = config.Load(config.DefaultPath()); err != nil {
+		return err
+	}
+	if err := TestVoiceOutput(settings.Voice); err != nil {
 		return fmt.Errorf("voice output test failed: %w", err)
 	}
-	fmt.Println()
-
-	fmt.Println("═══════════════════════════════════════")
-	fmt.Println("✓ Phase 3 Bootstrap Complete!")
-	fmt.Println("═══════════════════════════════════════")
-	fmt.Println()
-	fmt.Printf("Voice configuration saved to: %s\n", GetVoiceConfigPath())
-	fmt.Printf("Default voice: %s\n", DefaultVoice)
-	fmt.Println()
-	fmt.Println("Voice output features:")
-	fmt.Println("  - Message queuing to prevent overlap")
-	fmt.Println("  - Configurable speech rate and volume")
-	fmt.Println("  - Quiet hours support")
-	fmt.Println("  - Enable/disable voice output on demand")
-	fmt.Println()
 
+	if err := RecordPhase(3); err != nil {
+		return err
+	}
+
+	logger().Info("✓ Phase 3 Bootstrap Complete!", logging.Banner)
+	logger().Info("Voice configuration saved to {path}, using the voice {voice}", "path", GetVoiceConfigPath(), "voice", settings.Voice.Voice)
+	logger().Info("Change it with: emrys config set voice.<setting> <value>")
+	logger().Info("Voice output features:", logging.Break)
+	logger().Info("  - Message queuing to prevent overlap")
+	logger().Info("  - Configurable speech rate and volume")
+	logger().Info("  - Quiet hours support")
+	logger().Info("  - Enable/disable voice output on demand")
 	return nil
 }
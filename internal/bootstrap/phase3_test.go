@@ -5,6 +5,9 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/anicolao/emrys/internal/config"
+	"github.com/anicolao/emrys/internal/voice"
 )
 
 func TestGetVoiceConfigPath(t *testing.T) {
@@ -14,8 +17,8 @@ func TestGetVoiceConfigPath(t *testing.T) {
 		t.Error("Expected non-empty voice config path")
 	}
 
-	if !strings.Contains(path, ".config/emrys/voice.conf") {
-		t.Errorf("Expected path to contain '.config/emrys/voice.conf', got %s", path)
+	if !strings.Contains(path, ".config/emrys/config.yaml") {
+		t.Errorf("Expected path to contain '.config/emrys/config.yaml', got %s", path)
 	}
 }
 
@@ -32,13 +35,8 @@ func TestCreateVoiceConfig(t *testing.T) {
 		t.Fatalf("CreateVoiceConfig failed: %v", err)
 	}
 
-	// Verify the config file was created
-	configPath := GetVoiceConfigPath()
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		t.Errorf("Config file was not created at %s", configPath)
-	}
-
 	// Read the config file
+	configPath := GetVoiceConfigPath()
 	content, err := os.ReadFile(configPath)
 	if err != nil {
 		t.Fatalf("Failed to read config file: %v", err)
@@ -48,12 +46,13 @@ func TestCreateVoiceConfig(t *testing.T) {
 
 	// Verify essential configuration elements are present
 	expectedStrings := []string{
-		"# Emrys Voice Output Configuration",
-		"enabled =",
-		"voice = Jamie",
-		"rate =",
-		"volume =",
-		"quiet_hours =",
+		"# Emrys configuration",
+		"voice:",
+		"enabled: true",
+		"voice: Jamie",
+		"rate:",
+		"volume:",
+		"quiet_hours:",
 	}
 
 	for _, expected := range expectedStrings {
@@ -62,6 +61,21 @@ func TestCreateVoiceConfig(t *testing.T) {
 		}
 	}
 
+	// No voice.conf is written; config.yaml is the only place for settings
+	if _, err := os.Stat(config.VoiceConfPath()); !os.IsNotExist(err) {
+		t.Errorf("Expected no voice.conf, got %v", err)
+	}
+
+	settings, err := config.Load(configPath)
+	if err != nil {
+		t.Fatalf("Failed to load config.yaml: %v", err)
+	}
+	want := voice.DefaultConfig()
+	want.Voice = DefaultVoice
+	if settings.Voice != want {
+		t.Errorf("Expected config.yaml to hold the voice settings %+v, got %+v", want, settings.Voice)
+	}
+
 	// Test idempotency - creating again should not fail
 	err = CreateVoiceConfig()
 	if err != nil {
@@ -69,6 +83,45 @@ func TestCreateVoiceConfig(t *testing.T) {
 	}
 }
 
+func TestCreateVoiceConfigKeepsSettings(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	// Settings changed with emrys config set are kept
+	path := GetVoiceConfigPath()
+	if err := config.WriteFile(path, []byte("# Mine\nvoice:\n  voice: Samantha\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := CreateVoiceConfig(); err != nil {
+		t.Fatalf("CreateVoiceConfig failed: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "# Mine\nvoice:\n  voice: Samantha\n" {
+		t.Errorf("Expected config.yaml to be left alone, got:\n%s", data)
+	}
+}
+
+func TestCreateVoiceConfigImportsVoiceConf(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	// A voice.conf from an older version is imported rather than replaced
+	if err := os.MkdirAll(filepath.Dir(config.VoiceConfPath()), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(config.VoiceConfPath(), []byte("voice = Samantha\nrate = 175\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := CreateVoiceConfig(); err != nil {
+		t.Fatalf("CreateVoiceConfig failed: %v", err)
+	}
+
+	settings, err := config.Load(GetVoiceConfigPath())
+	if err != nil {
+		t.Fatalf("Failed to load config.yaml: %v", err)
+	}
+	if settings.Voice.Voice != "Samantha" || settings.Voice.Rate != 175 {
+		t.Errorf("Expected the voice.conf settings, got %+v", settings.Voice)
+	}
+}
+
 func TestUpdateNixDarwinConfigForVoice(t *testing.T) {
 	// Use a temporary directory for testing
 	tmpDir := t.TempDir()
@@ -153,7 +206,7 @@ func TestListAvailableVoices(t *testing.T) {
 	// This test requires macOS 'say' command
 	t.Skip("Skipping voice listing test (requires macOS)")
 
-	err := ListAvailableVoices()
+	err := ListAvailableVoices(voice.DefaultConfig())
 	if err != nil {
 		t.Errorf("ListAvailableVoices failed: %v", err)
 	}
@@ -163,7 +216,7 @@ func TestTestVoiceOutput(t *testing.T) {
 	// This test requires macOS 'say' command and Jamie voice
 	t.Skip("Skipping voice output test (requires macOS and Jamie voice)")
 
-	err := TestVoiceOutput()
+	err := TestVoiceOutput(voice.DefaultConfig())
 	if err != nil {
 		t.Errorf("TestVoiceOutput failed: %v", err)
 	}
@@ -0,0 +1,110 @@
+package bootstrap
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/anicolao/emrys/internal/migrate"
+)
+
+// StateVersion is the bootstrap state format this version of Emrys writes
+const StateVersion = 1
+
+// StateFormat describes the bootstrap state versions and how to upgrade them
+var StateFormat = migrate.Format{
+	Name:    "bootstrap state",
+	Current: StateVersion,
+	Version: stateFileVersion,
+	Migrations: []migrate.Migration{
+		{Version: 1, Description: "Record the format version", Apply: setStateVersion(1)},
+	},
+}
+
+// State records the progress of the bootstrap
+type State struct {
+	Version int               `json:"version"`
+	Phases  map[int]time.Time `json:"phases"` // When each phase last completed
+}
+
+// GetStatePath returns the path to the bootstrap state file
+func GetStatePath() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".config", "emrys", "bootstrap.json")
+}
+
+// LoadState reads the bootstrap state; a missing file yields an empty state
+func LoadState() (State, error) {
+	state := State{Version: StateVersion, Phases: make(map[int]time.Time)}
+	data, err := os.ReadFile(GetStatePath())
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return State{}, fmt.Errorf("failed to read bootstrap state: %w", err)
+	}
+
+	// Older states are upgraded in memory; RecordPhase writes them back
+	if data, _, err = StateFormat.Migrate(data); err != nil {
+		return State{}, err
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return State{}, fmt.Errorf("failed to parse bootstrap state: %w", err)
+	}
+	return state, nil
+}
+
+// RecordPhase notes that a phase has completed
+func RecordPhase(phase int) error {
+	state, err := LoadState()
+	if err != nil {
+		return err
+	}
+	if state.Phases == nil {
+		state.Phases = make(map[int]time.Time)
+	}
+	state.Phases[phase] = time.Now().UTC()
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode bootstrap state: %w", err)
+	}
+	path := GetStatePath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write bootstrap state: %w", err)
+	}
+	return nil
+}
+
+// stateFileVersion reads the version of a bootstrap state file
+func stateFileVersion(data []byte) (int, error) {
+	var file struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return 0, err
+	}
+	return file.Version, nil
+}
+
+// setStateVersion returns a migration step that records version in the
+// state file
+func setStateVersion(version int) func([]byte) ([]byte, error) {
+	return func(data []byte) ([]byte, error) {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return nil, err
+		}
+		if fields == nil {
+			fields = make(map[string]json.RawMessage)
+		}
+		fields["version"] = json.RawMessage(strconv.Itoa(version))
+		return json.MarshalIndent(fields, "", "  ")
+	}
+}
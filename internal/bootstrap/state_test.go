@@ -0,0 +1,87 @@
+package bootstrap
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRecordPhase(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	state, err := LoadState()
+	if err != nil {
+		t.Fatalf("LoadState failed: %v", err)
+	}
+	if len(state.Phases) != 0 {
+		t.Errorf("Expected no completed phases, got %v", state.Phases)
+	}
+
+	if err := RecordPhase(1); err != nil {
+		t.Fatalf("RecordPhase failed: %v", err)
+	}
+	if err := RecordPhase(2); err != nil {
+		t.Fatalf("RecordPhase failed: %v", err)
+	}
+
+	state, err = LoadState()
+	if err != nil {
+		t.Fatalf("LoadState failed: %v", err)
+	}
+	if state.Version != StateVersion {
+		t.Errorf("Expected version %d, got %d", StateVersion, state.Version)
+	}
+	if state.Phases[1].IsZero() || state.Phases[2].IsZero() || !state.Phases[3].IsZero() {
+		t.Errorf("Expected phases 1 and 2 to be recorded, got %v", state.Phases)
+	}
+
+	data, err := os.ReadFile(GetStatePath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := stateFileVersion(data); err != nil || v != StateVersion {
+		t.Errorf("Expected the file to record version %d, got %d, %v", StateVersion, v, err)
+	}
+}
+
+func TestLoadStateVersions(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	if err := RecordPhase(1); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(GetStatePath(), []byte(`{"phases": {"1": "2026-03-01T09:30:00Z"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	state, err := LoadState()
+	if err != nil {
+		t.Fatalf("Expected an older state to load, got %v", err)
+	}
+	if state.Version != StateVersion || state.Phases[1].IsZero() {
+		t.Errorf("Expected phase 1 at version %d, got %+v", StateVersion, state)
+	}
+
+	if err := os.WriteFile(GetStatePath(), []byte(`{"version": 7}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadState(); err == nil || !strings.Contains(err.Error(), "newer") {
+		t.Errorf("Expected a newer version to be refused, got %v", err)
+	}
+}
+
+func TestStateFormatMigrates(t *testing.T) {
+	old := []byte(`{"phases": {"2": "2026-03-01T09:30:00Z"}}`)
+	migrated, applied, err := StateFormat.Migrate(old)
+	if err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	if len(applied) != 1 {
+		t.Errorf("Expected 1 migration, got %d", len(applied))
+	}
+	if v, err := stateFileVersion(migrated); err != nil || v != StateVersion {
+		t.Errorf("Expected version %d, got %d, %v", StateVersion, v, err)
+	}
+	if !strings.Contains(string(migrated), "2026-03-01T09:30:00Z") {
+		t.Errorf("Expected the phases to be kept, got %s", migrated)
+	}
+}
@@ -0,0 +1,192 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/anicolao/emrys/internal/llm"
+)
+
+// DefaultKeepRecent is the number of most recent turns that are never folded
+// into the summary, so the model always sees the immediate conversation verbatim
+const DefaultKeepRecent = 4
+
+// Options configures a chat Engine
+type Options struct {
+	Roles         llm.Roles    // Models used for chatting and summarizing
+	SystemPrompt  string       // System prompt, always sent first and never folded
+	NumCtx        int          // Context window in tokens (0: ask Ollama for the chat model's num_ctx)
+	ReserveTokens int          // Tokens kept free for the reply (0: a quarter of NumCtx)
+	KeepRecent    int          // Recent unpinned messages never folded (0: DefaultKeepRecent)
+	Counter       TokenCounter // Token counter (nil: ApproxCounter)
+}
+
+// Entry is a message in the conversation history
+type Entry struct {
+	Message llm.Message
+	Pinned  bool // Pinned entries are never folded into the summary
+	tokens  int
+}
+
+// Engine holds a conversation with the chat model and keeps it inside the
+// model's context window by folding older turns into a rolling summary
+type Engine struct {
+	client  *llm.Client
+	opts    Options
+	mu      sync.Mutex
+	history []Entry
+	summary string
+	numCtx  int
+	sized   bool // numCtx was configured or reported by Ollama, not a fallback
+}
+
+// NewEngine creates a new chat engine using the given Ollama client
+func NewEngine(client *llm.Client, opts Options) *Engine {
+	defaults := llm.DefaultRoles()
+	if opts.Roles.Chat == "" {
+		opts.Roles.Chat = defaults.Chat
+	}
+	if opts.Roles.Summarize == "" {
+		opts.Roles.Summarize = opts.Roles.Chat
+	}
+	if opts.KeepRecent <= 0 {
+		opts.KeepRecent = DefaultKeepRecent
+	}
+	if opts.Counter == nil {
+		opts.Counter = ApproxCounter{}
+	}
+
+	return &Engine{
+		client: client,
+		opts:   opts,
+		numCtx: opts.NumCtx,
+		sized:  opts.NumCtx > 0,
+	}
+}
+
+// Send adds a user message to the conversation, asks the chat model for a
+// reply and records it. It returns the assistant's reply.
+func (e *Engine) Send(ctx context.Context, text string) (string, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	req, err := e.prepare(ctx, text)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := e.client.Chat(ctx, req)
+	if err != nil {
+		e.history = e.history[:len(e.history)-1]
+		return "", fmt.Errorf("chat request failed: %w", err)
+	}
+
+	e.append(ctx, llm.Message{Role: llm.RoleAssistant, Content: resp.Message.Content}, false)
+	return resp.Message.Content, nil
+}
+
+// SendStream is like Send but streams the reply, calling fn with each piece
+// of content as it arrives. If fn returns an error or ctx is cancelled, the
+// generation stops and the exchange is not recorded.
+func (e *Engine) SendStream(ctx context.Context, text string, fn func(delta string) error) (string, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	req, err := e.prepare(ctx, text)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := e.client.ChatStream(ctx, req, func(chunk llm.ChatResponse) error {
+		if chunk.Message.Content == "" {
+			return nil
+		}
+		return fn(chunk.Message.Content)
+	})
+	if err != nil {
+		e.history = e.history[:len(e.history)-1]
+		return "", fmt.Errorf("chat request failed: %w", err)
+	}
+
+	e.append(ctx, llm.Message{Role: llm.RoleAssistant, Content: resp.Message.Content}, false)
+	return resp.Message.Content, nil
+}
+
+// Pin adds a message to the history that is never folded into the summary,
+// such as a standing instruction or an important fact from the user
+func (e *Engine) Pin(msg llm.Message) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.append(context.Background(), msg, true)
+}
+
+// History returns a copy of the messages currently kept verbatim
+func (e *Engine) History() []Entry {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]Entry(nil), e.history...)
+}
+
+// Summary returns the rolling summary of folded turns, if any
+func (e *Engine) Summary() string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.summary
+}
+
+// Messages returns the messages that would be sent to the chat model now
+func (e *Engine) Messages() []llm.Message {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.messages()
+}
+
+// prepare records the user message, fits the history into the context
+// window and builds the chat request. The caller must hold e.mu.
+func (e *Engine) prepare(ctx context.Context, text string) (llm.ChatRequest, error) {
+	// Ask again next time if Ollama could not say, rather than keeping the
+	// fallback for the rest of the conversation
+	if !e.sized {
+		n, err := e.client.NumCtx(ctx, e.opts.Roles.Chat)
+		e.numCtx, e.sized = n, err == nil
+	}
+
+	e.append(ctx, llm.Message{Role: llm.RoleUser, Content: text}, false)
+
+	if err := e.fit(ctx); err != nil {
+		e.history = e.history[:len(e.history)-1]
+		return llm.ChatRequest{}, err
+	}
+
+	return llm.ChatRequest{
+		Model:    e.opts.Roles.Chat,
+		Messages: e.messages(),
+		// Tell Ollama the window we budgeted for so it never truncates silently
+		Options: map[string]interface{}{"num_ctx": e.numCtx},
+	}, nil
+}
+
+// append adds a message to the history with its token count cached
+func (e *Engine) append(ctx context.Context, msg llm.Message, pinned bool) {
+	e.history = append(e.history, Entry{
+		Message: msg,
+		Pinned:  pinned,
+		tokens:  countMessage(ctx, e.opts.Counter, msg),
+	})
+}
+
+// messages assembles the system prompt, summary and history
+func (e *Engine) messages() []llm.Message {
+	var msgs []llm.Message
+	if e.opts.SystemPrompt != "" {
+		msgs = append(msgs, llm.Message{Role: llm.RoleSystem, Content: e.opts.SystemPrompt})
+	}
+	if e.summary != "" {
+		msgs = append(msgs, summaryMessage(e.summary))
+	}
+	for _, entry := range e.history {
+		msgs = append(msgs, entry.Message)
+	}
+	return msgs
+}
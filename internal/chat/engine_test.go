@@ -0,0 +1,195 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/anicolao/emrys/internal/llm"
+)
+
+// fakeOllama serves /api/chat, answering summarization requests with a
+// short summary and everything else with a fixed reply. It serves
+// /api/show with numCtx once showFailures failures have been used up.
+type fakeOllama struct {
+	mu           sync.Mutex
+	summaries    int
+	requests     []llm.ChatRequest
+	numCtx       int
+	showFailures int
+}
+
+func (f *fakeOllama) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/api/show" {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if f.showFailures > 0 {
+			f.showFailures--
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(llm.ShowResponse{Parameters: fmt.Sprintf("num_ctx %d", f.numCtx)})
+		return
+	}
+	if r.URL.Path != "/api/chat" {
+		http.NotFound(w, r)
+		return
+	}
+
+	var req llm.ChatRequest
+	json.NewDecoder(r.Body).Decode(&req)
+
+	f.mu.Lock()
+	f.requests = append(f.requests, req)
+	content := "reply"
+	if req.Model == "summarizer" {
+		f.summaries++
+		content = "the user asked many questions"
+	}
+	f.mu.Unlock()
+
+	json.NewEncoder(w).Encode(llm.ChatResponse{
+		Message: llm.Message{Role: llm.RoleAssistant, Content: content},
+		Done:    true,
+	})
+}
+
+func newTestEngine(t *testing.T, opts Options) (*Engine, *fakeOllama) {
+	fake := &fakeOllama{}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	if opts.Counter == nil {
+		opts.Counter = ApproxCounter{}
+	}
+	return NewEngine(llm.NewClient(server.URL), opts), fake
+}
+
+func TestSendRecordsHistory(t *testing.T) {
+	engine, _ := newTestEngine(t, Options{NumCtx: 4096, SystemPrompt: "You are Emrys."})
+
+	reply, err := engine.Send(context.Background(), "hello")
+	if err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if reply != "reply" {
+		t.Errorf("Expected reply 'reply', got '%s'", reply)
+	}
+
+	history := engine.History()
+	if len(history) != 2 {
+		t.Fatalf("Expected 2 history entries, got %d", len(history))
+	}
+	if history[0].Message.Role != llm.RoleUser || history[1].Message.Role != llm.RoleAssistant {
+		t.Errorf("Unexpected roles: %s, %s", history[0].Message.Role, history[1].Message.Role)
+	}
+
+	msgs := engine.Messages()
+	if msgs[0].Role != llm.RoleSystem || msgs[0].Content != "You are Emrys." {
+		t.Errorf("Expected system prompt first, got %+v", msgs[0])
+	}
+}
+
+func TestSendSetsNumCtx(t *testing.T) {
+	engine, fake := newTestEngine(t, Options{NumCtx: 1234})
+
+	if _, err := engine.Send(context.Background(), "hello"); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	got := fake.requests[0].Options["num_ctx"]
+	if got != float64(1234) {
+		t.Errorf("Expected num_ctx 1234 in request options, got %v", got)
+	}
+}
+
+func TestSendAsksForNumCtxUntilReported(t *testing.T) {
+	engine, fake := newTestEngine(t, Options{})
+	fake.numCtx = 8192
+	fake.showFailures = 1
+
+	for i := 0; i < 3; i++ {
+		if _, err := engine.Send(context.Background(), "hello"); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+	}
+
+	want := []float64{llm.DefaultNumCtx, 8192, 8192}
+	for i, req := range fake.requests {
+		if got := req.Options["num_ctx"]; got != want[i] {
+			t.Errorf("Request %d: expected num_ctx %v, got %v", i, want[i], got)
+		}
+	}
+}
+
+func TestFoldingKeepsSystemAndPinned(t *testing.T) {
+	engine, fake := newTestEngine(t, Options{
+		Roles:        llm.Roles{Chat: "chatter", Summarize: "summarizer"},
+		SystemPrompt: "You are Emrys.",
+		NumCtx:       400,
+	})
+
+	engine.Pin(llm.Message{Role: llm.RoleUser, Content: "Remember: my name is Alex."})
+
+	long := strings.Repeat("word ", 40)
+	for i := 0; i < 10; i++ {
+		if _, err := engine.Send(context.Background(), long); err != nil {
+			t.Fatalf("Send %d failed: %v", i, err)
+		}
+	}
+
+	if fake.summaries == 0 {
+		t.Fatal("Expected older turns to be summarized")
+	}
+	if engine.Summary() == "" {
+		t.Error("Expected a rolling summary")
+	}
+
+	msgs := engine.Messages()
+	if msgs[0].Content != "You are Emrys." {
+		t.Errorf("Expected system prompt to stay first, got %q", msgs[0].Content)
+	}
+
+	foundPinned := false
+	for _, entry := range engine.History() {
+		if entry.Pinned && strings.Contains(entry.Message.Content, "Alex") {
+			foundPinned = true
+		}
+	}
+	if !foundPinned {
+		t.Error("Expected pinned message to survive folding")
+	}
+
+	if used := engine.used(context.Background()); used > engine.budget() {
+		t.Errorf("Expected prompt within budget, used %d of %d", used, engine.budget())
+	}
+
+	// The summarizer must receive the summarize-role model, never the chat model
+	for _, req := range fake.requests {
+		if strings.Contains(req.Messages[0].Content, "running summary") && req.Model != "summarizer" {
+			t.Errorf("Summary requested from model %q", req.Model)
+		}
+	}
+}
+
+func TestContextOverflow(t *testing.T) {
+	engine, _ := newTestEngine(t, Options{NumCtx: 100})
+
+	engine.Pin(llm.Message{Role: llm.RoleUser, Content: strings.Repeat("pinned ", 100)})
+
+	_, err := engine.Send(context.Background(), "hello")
+	if !errors.Is(err, ErrContextOverflow) {
+		t.Fatalf("Expected ErrContextOverflow, got %v", err)
+	}
+
+	// The rejected user message must not linger in the history
+	if n := len(engine.History()); n != 1 {
+		t.Errorf("Expected only the pinned entry in history, got %d entries", n)
+	}
+}
@@ -0,0 +1,49 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/anicolao/emrys/internal/voice"
+	"github.com/anicolao/emrys/internal/voice/stt"
+)
+
+// SendSpoken sends text like SendStream and speaks the reply as it is
+// generated, starting as soon as the first sentence is complete. Cancelling
+// ctx stops the generation and discards any speech not yet spoken, including
+// sentences still queued after the reply has finished generating.
+func (e *Engine) SendSpoken(ctx context.Context, text string, speaker *voice.Speaker, priority voice.Priority) (string, error) {
+	stream := speaker.NewStream(ctx, priority)
+
+	reply, err := e.SendStream(ctx, text, func(delta string) error {
+		stream.WriteString(delta)
+		return nil
+	})
+	if err != nil {
+		stream.Cancel()
+		return "", err
+	}
+
+	stream.Close()
+	return reply, nil
+}
+
+// SendTranscript sends what was said in a transcribed recording as a user
+// turn. It returns stt.ErrNoSpeech if the recording held no words.
+func (e *Engine) SendTranscript(ctx context.Context, transcript stt.Transcript) (string, error) {
+	text := strings.TrimSpace(transcript.Text())
+	if text == "" {
+		return "", stt.ErrNoSpeech
+	}
+	return e.Send(ctx, text)
+}
+
+// SendRecording transcribes the WAV file at path and sends it as a user turn
+func (e *Engine) SendRecording(ctx context.Context, transcriber stt.Transcriber, path string) (string, error) {
+	transcript, err := transcriber.Transcribe(ctx, path)
+	if err != nil {
+		return "", fmt.Errorf("failed to transcribe recording: %w", err)
+	}
+	return e.SendTranscript(ctx, transcript)
+}
@@ -0,0 +1,216 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/anicolao/emrys/internal/llm"
+	"github.com/anicolao/emrys/internal/voice"
+	"github.com/anicolao/emrys/internal/voice/stt"
+)
+
+// streamingOllama streams a fixed list of tokens from /api/chat. If hold is
+// set it stops after the tokens and waits for the client to go away.
+type streamingOllama struct {
+	tokens []string
+	hold   bool
+}
+
+func (s *streamingOllama) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	enc := json.NewEncoder(w)
+	for _, token := range s.tokens {
+		enc.Encode(llm.ChatResponse{Message: llm.Message{Role: llm.RoleAssistant, Content: token}})
+		w.(http.Flusher).Flush()
+	}
+	if s.hold {
+		<-r.Context().Done()
+		return
+	}
+	enc.Encode(llm.ChatResponse{Done: true})
+}
+
+func newStreamingEngine(t *testing.T, server *streamingOllama) *Engine {
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+	return NewEngine(llm.NewClient(ts.URL), Options{NumCtx: 4096, Counter: ApproxCounter{}})
+}
+
+// waitFor polls cond until it is true or the deadline passes
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for condition")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSendStream(t *testing.T) {
+	engine := newStreamingEngine(t, &streamingOllama{tokens: []string{"Hel", "lo", " there."}})
+
+	var deltas []string
+	reply, err := engine.SendStream(context.Background(), "hi", func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("SendStream failed: %v", err)
+	}
+
+	if reply != "Hello there." {
+		t.Errorf("Expected assembled reply, got %q", reply)
+	}
+	if !reflect.DeepEqual(deltas, []string{"Hel", "lo", " there."}) {
+		t.Errorf("Unexpected deltas %q", deltas)
+	}
+	if history := engine.History(); len(history) != 2 || history[1].Message.Content != "Hello there." {
+		t.Errorf("Expected the exchange to be recorded, got %+v", history)
+	}
+}
+
+func TestSendStreamCallbackError(t *testing.T) {
+	engine := newStreamingEngine(t, &streamingOllama{tokens: []string{"a", "b"}})
+	stop := errors.New("stop")
+
+	_, err := engine.SendStream(context.Background(), "hi", func(string) error { return stop })
+	if !errors.Is(err, stop) {
+		t.Errorf("Expected callback error, got %v", err)
+	}
+	if len(engine.History()) != 0 {
+		t.Error("Expected failed exchange not to be recorded")
+	}
+}
+
+func TestSendSpoken(t *testing.T) {
+	engine := newStreamingEngine(t, &streamingOllama{
+		tokens: []string{"The ", "first ", "sentence", ". ", "The **second** ", "one"},
+	})
+	backend := voice.NewFakeBackend()
+	speaker := voice.NewSpeakerWithBackend(voice.DefaultConfig(), backend)
+	defer speaker.Close()
+
+	reply, err := engine.SendSpoken(context.Background(), "talk", speaker, voice.PriorityNormal)
+	if err != nil {
+		t.Fatalf("SendSpoken failed: %v", err)
+	}
+	if reply != "The first sentence. The **second** one" {
+		t.Errorf("Unexpected reply %q", reply)
+	}
+
+	waitFor(t, func() bool { return len(backend.Texts()) == 2 })
+	want := []string{"The first sentence.", "The second one"}
+	if got := backend.Texts(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestSendSpokenStartsBeforeGenerationEnds(t *testing.T) {
+	engine := newStreamingEngine(t, &streamingOllama{
+		tokens: []string{"Ready now. ", "Still thinking"},
+		hold:   true,
+	})
+	backend := voice.NewFakeBackend()
+	speaker := voice.NewSpeakerWithBackend(voice.DefaultConfig(), backend)
+	defer speaker.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := engine.SendSpoken(ctx, "talk", speaker, voice.PriorityNormal)
+		done <- err
+	}()
+
+	// The first sentence is spoken while the model is still generating
+	waitFor(t, func() bool { return len(backend.Texts()) == 1 })
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if got := backend.Texts(); !reflect.DeepEqual(got, []string{"Ready now."}) {
+		t.Errorf("Expected the unfinished sentence to be dropped, got %v", got)
+	}
+	if len(engine.History()) != 0 {
+		t.Error("Expected interrupted exchange not to be recorded")
+	}
+}
+
+// fakeTranscriber returns a fixed transcript for every recording
+type fakeTranscriber struct {
+	transcript stt.Transcript
+	err        error
+	paths      []string
+}
+
+func (f *fakeTranscriber) Transcribe(_ context.Context, path string) (stt.Transcript, error) {
+	f.paths = append(f.paths, path)
+	return f.transcript, f.err
+}
+
+func (f *fakeTranscriber) TranscribeAudio(_ context.Context, _ stt.Audio) (stt.Transcript, error) {
+	return f.transcript, f.err
+}
+
+func TestSendRecording(t *testing.T) {
+	engine, fake := newTestEngine(t, Options{NumCtx: 4096})
+	transcriber := &fakeTranscriber{transcript: stt.Transcript{
+		Language: "en",
+		Segments: []stt.Segment{
+			{Text: "What's the weather"},
+			{Text: "[BLANK_AUDIO]"},
+			{Text: "like today?"},
+		},
+	}}
+
+	reply, err := engine.SendRecording(context.Background(), transcriber, "question.wav")
+	if err != nil {
+		t.Fatalf("SendRecording failed: %v", err)
+	}
+	if reply != "reply" {
+		t.Errorf("Expected reply 'reply', got '%s'", reply)
+	}
+	if !reflect.DeepEqual(transcriber.paths, []string{"question.wav"}) {
+		t.Errorf("Expected the recording to be transcribed, got %v", transcriber.paths)
+	}
+
+	history := engine.History()
+	if len(history) != 2 || history[0].Message.Role != llm.RoleUser {
+		t.Fatalf("Expected a user turn and a reply, got %+v", history)
+	}
+	if got := history[0].Message.Content; got != "What's the weather like today?" {
+		t.Errorf("Expected the transcript as the user turn, got %q", got)
+	}
+	if len(fake.requests) != 1 {
+		t.Errorf("Expected 1 chat request, got %d", len(fake.requests))
+	}
+}
+
+func TestSendRecordingWithoutSpeech(t *testing.T) {
+	engine, fake := newTestEngine(t, Options{NumCtx: 4096})
+	transcriber := &fakeTranscriber{transcript: stt.Transcript{
+		Segments: []stt.Segment{{Text: "[BLANK_AUDIO]"}},
+	}}
+
+	_, err := engine.SendRecording(context.Background(), transcriber, "silence.wav")
+	if !errors.Is(err, stt.ErrNoSpeech) {
+		t.Errorf("Expected ErrNoSpeech, got %v", err)
+	}
+	if len(engine.History()) != 0 || len(fake.requests) != 0 {
+		t.Error("Expected nothing to be sent for a silent recording")
+	}
+
+	transcriber.err = errors.New("whisper.cpp failed")
+	if _, err := engine.SendRecording(context.Background(), transcriber, "broken.wav"); err == nil {
+		t.Error("Expected transcription errors to be returned")
+	}
+}
@@ -0,0 +1,70 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/anicolao/emrys/internal/llm"
+)
+
+// messageOverhead approximates the template tokens a chat format adds
+// around every message (role markers, separators)
+const messageOverhead = 4
+
+// TokenCounter counts the tokens a piece of text will occupy in the context
+type TokenCounter interface {
+	Count(ctx context.Context, text string) int
+}
+
+// ApproxCounter estimates tokens at roughly four characters per token,
+// which is close enough for English text with Llama-family tokenizers
+type ApproxCounter struct{}
+
+// Count returns the approximate token count for text
+func (ApproxCounter) Count(_ context.Context, text string) int {
+	return (utf8.RuneCountInString(text) + 3) / 4
+}
+
+// OllamaCounter counts tokens with the model's own tokenizer via Ollama's
+// /api/tokenize, which stock Ollama does not serve. When the server answers
+// 404 it switches permanently to ApproxCounter; other errors fall back for
+// that count only.
+type OllamaCounter struct {
+	client      *llm.Client
+	model       string
+	mu          sync.Mutex
+	unsupported bool
+}
+
+// NewOllamaCounter creates a counter that uses the tokenizer of model
+func NewOllamaCounter(client *llm.Client, model string) *OllamaCounter {
+	return &OllamaCounter{client: client, model: model}
+}
+
+// Count returns the token count for text
+func (c *OllamaCounter) Count(ctx context.Context, text string) int {
+	c.mu.Lock()
+	unsupported := c.unsupported
+	c.mu.Unlock()
+
+	if !unsupported {
+		tokens, err := c.client.Tokenize(ctx, c.model, text)
+		if err == nil {
+			return len(tokens)
+		}
+		if errors.Is(err, llm.ErrNotFound) {
+			c.mu.Lock()
+			c.unsupported = true
+			c.mu.Unlock()
+		}
+	}
+
+	return ApproxCounter{}.Count(ctx, text)
+}
+
+// countMessage returns the tokens a message occupies including overhead
+func countMessage(ctx context.Context, counter TokenCounter, msg llm.Message) int {
+	return counter.Count(ctx, msg.Content) + messageOverhead
+}
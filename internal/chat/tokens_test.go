@@ -0,0 +1,83 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/anicolao/emrys/internal/llm"
+)
+
+func TestApproxCounter(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"abc", 1},
+		{"abcd", 1},
+		{"abcde", 2},
+		{"héllo wörld", 3},
+	}
+
+	for _, tt := range tests {
+		if got := (ApproxCounter{}).Count(context.Background(), tt.text); got != tt.want {
+			t.Errorf("Count(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestOllamaCounterUsesTokenizer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string][]int{"tokens": {1, 2, 3, 4, 5, 6, 7}})
+	}))
+	defer server.Close()
+
+	counter := NewOllamaCounter(llm.NewClient(server.URL), "test")
+	if got := counter.Count(context.Background(), "hi"); got != 7 {
+		t.Errorf("Expected 7 tokens from tokenizer, got %d", got)
+	}
+}
+
+func TestOllamaCounterFallback(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	counter := NewOllamaCounter(llm.NewClient(server.URL), "test")
+	for i := 0; i < 3; i++ {
+		if got := counter.Count(context.Background(), "abcdefgh"); got != 2 {
+			t.Errorf("Expected approximate count 2, got %d", got)
+		}
+	}
+
+	if calls != 1 {
+		t.Errorf("Expected tokenizer to be tried once, got %d calls", calls)
+	}
+}
+
+func TestOllamaCounterRetriesAfterTransientError(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string][]int{"tokens": {1, 2, 3, 4, 5, 6, 7}})
+	}))
+	defer server.Close()
+
+	counter := NewOllamaCounter(llm.NewClient(server.URL), "test")
+	if got := counter.Count(context.Background(), "abcdefgh"); got != 2 {
+		t.Errorf("Expected approximate count 2 while the server is busy, got %d", got)
+	}
+	if got := counter.Count(context.Background(), "abcdefgh"); got != 7 {
+		t.Errorf("Expected 7 tokens from the tokenizer once it answers, got %d", got)
+	}
+}
@@ -0,0 +1,148 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/anicolao/emrys/internal/llm"
+)
+
+// ErrContextOverflow is returned when the system prompt, pinned messages and
+// recent turns alone do not fit in the context window
+var ErrContextOverflow = errors.New("conversation does not fit in the model's context window")
+
+// summaryPrefix introduces the rolling summary in the messages sent to the model
+const summaryPrefix = "Summary of the earlier conversation:\n"
+
+// summarizePrompt instructs the summarize-role model
+const summarizePrompt = `You maintain a running summary of a conversation between a user and Emrys, an AI assistant.
+Merge the existing summary with the new turns into a single updated summary.
+Keep names, decisions, commitments, facts about the user and unfinished tasks.
+Drop pleasantries and repetition. Reply with the summary only.`
+
+// summaryMessage wraps the rolling summary as a system message
+func summaryMessage(summary string) llm.Message {
+	return llm.Message{Role: llm.RoleSystem, Content: summaryPrefix + summary}
+}
+
+// budget returns the number of tokens available for the prompt
+func (e *Engine) budget() int {
+	reserve := e.opts.ReserveTokens
+	if reserve <= 0 {
+		reserve = e.numCtx / 4
+	}
+	return e.numCtx - reserve
+}
+
+// used returns the number of tokens the current prompt occupies
+func (e *Engine) used(ctx context.Context) int {
+	total := 0
+	if e.opts.SystemPrompt != "" {
+		total += countMessage(ctx, e.opts.Counter, llm.Message{Content: e.opts.SystemPrompt})
+	}
+	if e.summary != "" {
+		total += countMessage(ctx, e.opts.Counter, summaryMessage(e.summary))
+	}
+	for _, entry := range e.history {
+		total += entry.tokens
+	}
+	return total
+}
+
+// foldable returns the indices of history entries that may be folded into
+// the summary: unpinned entries other than the most recent KeepRecent ones
+func (e *Engine) foldable() []int {
+	var unpinned []int
+	for i, entry := range e.history {
+		if !entry.Pinned {
+			unpinned = append(unpinned, i)
+		}
+	}
+	if len(unpinned) <= e.opts.KeepRecent {
+		return nil
+	}
+	return unpinned[:len(unpinned)-e.opts.KeepRecent]
+}
+
+// fit folds the oldest foldable turns into the summary until the prompt
+// fits the budget. It folds a quarter of the budget beyond what is strictly
+// needed so that summarization does not run on every turn.
+func (e *Engine) fit(ctx context.Context) error {
+	budget := e.budget()
+
+	for {
+		used := e.used(ctx)
+		if used <= budget {
+			return nil
+		}
+
+		candidates := e.foldable()
+		if len(candidates) == 0 {
+			return fmt.Errorf("%w: %d tokens needed, %d available", ErrContextOverflow, used, budget)
+		}
+
+		target := used - budget + budget/4
+		var fold []int
+		freed := 0
+		for _, i := range candidates {
+			fold = append(fold, i)
+			freed += e.history[i].tokens
+			if freed >= target {
+				break
+			}
+		}
+
+		if err := e.fold(ctx, fold); err != nil {
+			return err
+		}
+	}
+}
+
+// fold summarizes the given history entries into the rolling summary and
+// removes them from the history
+func (e *Engine) fold(ctx context.Context, indices []int) error {
+	var turns strings.Builder
+	for _, i := range indices {
+		msg := e.history[i].Message
+		fmt.Fprintf(&turns, "%s: %s\n", msg.Role, msg.Content)
+	}
+
+	var prompt strings.Builder
+	if e.summary != "" {
+		fmt.Fprintf(&prompt, "Existing summary:\n%s\n\n", e.summary)
+	}
+	fmt.Fprintf(&prompt, "New turns:\n%s", turns.String())
+
+	resp, err := e.client.Chat(ctx, llm.ChatRequest{
+		Model: e.opts.Roles.Summarize,
+		Messages: []llm.Message{
+			{Role: llm.RoleSystem, Content: summarizePrompt},
+			{Role: llm.RoleUser, Content: prompt.String()},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to summarize conversation: %w", err)
+	}
+
+	summary := strings.TrimSpace(resp.Message.Content)
+	if summary == "" {
+		return fmt.Errorf("failed to summarize conversation: empty summary")
+	}
+	e.summary = summary
+
+	remove := make(map[int]bool, len(indices))
+	for _, i := range indices {
+		remove[i] = true
+	}
+	kept := e.history[:0]
+	for i, entry := range e.history {
+		if !remove[i] {
+			kept = append(kept, entry)
+		}
+	}
+	e.history = kept
+
+	return nil
+}
@@ -0,0 +1,181 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Document is config.yaml as written, for changing settings without losing
+// the comments and layout of the rest of the file
+type Document struct {
+	root yaml.Node
+}
+
+// ParseDocument parses config.yaml content for editing
+func ParseDocument(data []byte) (*Document, error) {
+	d := &Document{}
+	if err := yaml.Unmarshal(data, &d.root); err != nil {
+		return nil, syntaxError(err)
+	}
+	if len(d.root.Content) == 0 {
+		d.root = yaml.Node{
+			Kind:    yaml.DocumentNode,
+			Content: []*yaml.Node{{Kind: yaml.MappingNode, HeadComment: strings.TrimSpace(header)}},
+		}
+		d.setVersion(CurrentVersion)
+	}
+	if d.root.Content[0].Kind != yaml.MappingNode {
+		return nil, ValidationError{{Line: d.root.Content[0].Line, Message: "expected a mapping of settings"}}
+	}
+	return d, nil
+}
+
+// LoadDocument reads config.yaml for editing; a missing file yields an
+// empty document
+func LoadDocument(path string) (*Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read configuration: %w", err)
+	}
+	d, err := ParseDocument(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return d, nil
+}
+
+// Bytes returns the document as YAML
+func (d *Document) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&d.root); err != nil {
+		return nil, fmt.Errorf("failed to encode configuration: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode configuration: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Config returns the validated configuration the document describes,
+// without environment overrides
+func (d *Document) Config() (Config, error) {
+	data, err := d.Bytes()
+	if err != nil {
+		return Config{}, err
+	}
+	return Parse(data)
+}
+
+// Has reports whether the document sets path, a setting or a section
+func (d *Document) Has(path string) bool {
+	node := d.root.Content[0]
+	for _, key := range strings.Split(path, ".") {
+		var next *yaml.Node
+		for i := 0; node.Kind == yaml.MappingNode && i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				next = node.Content[i+1]
+				break
+			}
+		}
+		if next == nil {
+			return false
+		}
+		node = next
+	}
+	return true
+}
+
+// Set parses value according to the type of the setting at path and stores
+// it, adding the key and its section if the file does not have them. It
+// does not check the value's range; use Config for that.
+func (d *Document) Set(path, value string) error {
+	data, err := d.Bytes()
+	if err != nil {
+		return err
+	}
+	config, _, err := decode(data)
+	if err != nil {
+		return err
+	}
+	f, err := config.Field(path)
+	if err != nil {
+		return err
+	}
+	if err := f.Set(value); err != nil {
+		return ValidationError{{Path: path, Message: err.Error()}}
+	}
+
+	var node yaml.Node
+	if err := node.Encode(f.Value()); err != nil {
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+	if node.Kind == yaml.SequenceNode {
+		node.Style = yaml.FlowStyle
+	}
+	setNode(d.root.Content[0], strings.Split(path, "."), &node)
+	return nil
+}
+
+// setNode stores value under the key path in mapping, creating mappings
+// for missing sections and keeping the comments of a replaced value
+func setNode(mapping *yaml.Node, keys []string, value *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value != keys[0] {
+			continue
+		}
+		old := mapping.Content[i+1]
+		if len(keys) > 1 {
+			if old.Kind != yaml.MappingNode {
+				*old = yaml.Node{Kind: yaml.MappingNode, HeadComment: old.HeadComment, LineComment: old.LineComment}
+			}
+			setNode(old, keys[1:], value)
+			return
+		}
+		value.HeadComment = old.HeadComment
+		value.LineComment = old.LineComment
+		value.FootComment = old.FootComment
+		*old = *value
+		return
+	}
+
+	key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: keys[0]}
+	if len(keys) == 1 {
+		mapping.Content = append(mapping.Content, key, value)
+		return
+	}
+	section := &yaml.Node{Kind: yaml.MappingNode}
+	mapping.Content = append(mapping.Content, key, section)
+	setNode(section, keys[1:], value)
+}
+
+// Save validates the document and writes it to path
+func (d *Document) Save(path string) error {
+	if _, err := d.Config(); err != nil {
+		return err
+	}
+	data, err := d.Bytes()
+	if err != nil {
+		return err
+	}
+	return WriteFile(path, data)
+}
+
+// Changed returns the settings in c that differ from the defaults
+func Changed(c Config) []Field {
+	defaults := Default()
+	var changed []Field
+	for _, f := range c.Fields() {
+		d, _ := defaults.Field(f.Path)
+		if !reflect.DeepEqual(f.Value(), d.Value()) {
+			changed = append(changed, f)
+		}
+	}
+	return changed
+}
@@ -0,0 +1,192 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const commentedConfig = `# My Mac Mini
+models:
+  chat: llama3.2 # small enough for 16GB
+voice:
+  # Jamie sounds best
+  voice: Jamie
+  rate: 200
+`
+
+func TestDocumentSetPreservesComments(t *testing.T) {
+	d, err := ParseDocument([]byte(commentedConfig))
+	if err != nil {
+		t.Fatalf("ParseDocument failed: %v", err)
+	}
+	if err := d.Set("models.chat", "qwen2.5"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := d.Set("voice.rate", "180"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	data, err := d.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(data)
+	for _, want := range []string{
+		"# My Mac Mini",
+		"chat: qwen2.5 # small enough for 16GB",
+		"# Jamie sounds best",
+		"rate: 180",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected %q in:\n%s", want, got)
+		}
+	}
+}
+
+func TestDocumentHas(t *testing.T) {
+	d, err := ParseDocument([]byte(commentedConfig))
+	if err != nil {
+		t.Fatalf("ParseDocument failed: %v", err)
+	}
+	for path, want := range map[string]bool{
+		"voice":            true,
+		"voice.rate":       true,
+		"voice.volume":     false,
+		"voice.rate.extra": false,
+		"listen":           false,
+	} {
+		if got := d.Has(path); got != want {
+			t.Errorf("Has(%q): expected %v, got %v", path, want, got)
+		}
+	}
+}
+
+func TestDocumentSetAddsSections(t *testing.T) {
+	d, err := ParseDocument([]byte(commentedConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Set("listen.wake_phrases", "computer, jarvis"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := d.Set("listen.wake_timeout", "1m"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	config, err := d.Config()
+	if err != nil {
+		t.Fatalf("Config failed: %v", err)
+	}
+	if strings.Join(config.Listen.WakePhrases, ",") != "computer,jarvis" {
+		t.Errorf("Expected two wake phrases, got %v", config.Listen.WakePhrases)
+	}
+	if config.Listen.WakeTimeout.String() != "1m0s" {
+		t.Errorf("Expected 1m, got %s", config.Listen.WakeTimeout)
+	}
+	if config.Voice.Rate != 200 {
+		t.Errorf("Expected other settings to be kept, got rate %d", config.Voice.Rate)
+	}
+}
+
+func TestDocumentSetChecksTypes(t *testing.T) {
+	d, err := ParseDocument(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Set("voice.rate", "fast"); err == nil || !strings.Contains(err.Error(), "voice.rate: expected an integer") {
+		t.Errorf("Expected a type error, got %v", err)
+	}
+	if err := d.Set("voice.pitch", "1"); err == nil {
+		t.Error("Expected an unknown setting to be rejected")
+	}
+
+	// A value of the right type can still be out of range
+	if err := d.Set("voice.rate", "900"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := d.Save(filepath.Join(t.TempDir(), "config.yaml")); err == nil {
+		t.Error("Expected Save to reject an invalid configuration")
+	}
+}
+
+func TestDocumentSaveNewFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	d, err := LoadDocument(path)
+	if err != nil {
+		t.Fatalf("LoadDocument failed: %v", err)
+	}
+	if err := d.Set("voice.enabled", "false"); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "# Emrys configuration") {
+		t.Errorf("Expected a new file to start with the header, got:\n%s", data)
+	}
+	config, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Voice.Enabled {
+		t.Error("Expected voice to be disabled")
+	}
+}
+
+func TestChanged(t *testing.T) {
+	if changed := Changed(Default()); len(changed) != 0 {
+		t.Errorf("Expected no changes in the defaults, got %v", changed)
+	}
+
+	config := Default()
+	config.Voice.Rate = 180
+	config.Listen.WakePhrases = []string{"computer"}
+	changed := Changed(config)
+
+	var paths []string
+	for _, f := range changed {
+		paths = append(paths, f.Path+"="+f.String())
+	}
+	if got := strings.Join(paths, " "); got != "voice.rate=180 listen.wake_phrases=computer" {
+		t.Errorf("Expected the two changed settings, got %s", got)
+	}
+}
+
+func TestFormatAddsVersion(t *testing.T) {
+	data, applied, err := Format.Migrate([]byte(commentedConfig))
+	if err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	if len(applied) != 1 {
+		t.Errorf("Expected one migration, got %d", len(applied))
+	}
+	if !strings.HasPrefix(string(data), "# My Mac Mini\nversion: 1\n") {
+		t.Errorf("Expected the version after the header comment, got:\n%s", data)
+	}
+	if !strings.Contains(string(data), "# Jamie sounds best") {
+		t.Errorf("Expected comments to be kept, got:\n%s", data)
+	}
+	if _, err := Parse(data); err != nil {
+		t.Errorf("Expected the migrated file to be valid, got %v", err)
+	}
+}
+
+func TestParseChecksVersion(t *testing.T) {
+	if _, err := Parse([]byte("version: 2\n")); err == nil || !strings.Contains(err.Error(), "newer") {
+		t.Errorf("Expected a newer version to be refused, got %v", err)
+	}
+	if config, err := Parse([]byte("version: 0\nmodels:\n  chat: llama3.2\n")); err != nil || config.Version != CurrentVersion || config.Models.Chat != "llama3.2" {
+		t.Errorf("Expected an old version to be upgraded when read, got %+v, %v", config, err)
+	}
+	config := Default()
+	if _, err := config.Field("version"); err == nil {
+		t.Error("Expected the version not to be a setting")
+	}
+}
@@ -0,0 +1,171 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvPrefix starts the names of environment variables that override
+// settings, as in EMRYS_VOICE_RATE for voice.rate
+const EnvPrefix = "EMRYS_"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Field is a single setting, addressed by its dotted YAML path such as
+// "voice.rate"
+type Field struct {
+	Path  string
+	value reflect.Value
+}
+
+// Fields returns every setting in c, in file order
+func (c *Config) Fields() []Field {
+	return collectFields(reflect.ValueOf(c).Elem(), "")
+}
+
+// collectFields walks the yaml-tagged fields of a struct, skipping those
+// tagged config:"-" that are not settings
+func collectFields(v reflect.Value, prefix string) []Field {
+	var fields []Field
+	for i := 0; i < v.NumField(); i++ {
+		tag := v.Type().Field(i).Tag
+		name, _, _ := strings.Cut(tag.Get("yaml"), ",")
+		if name == "" || name == "-" || tag.Get("config") == "-" {
+			continue
+		}
+		path := prefix + name
+		if fv := v.Field(i); fv.Kind() == reflect.Struct {
+			fields = append(fields, collectFields(fv, path+".")...)
+		} else {
+			fields = append(fields, Field{Path: path, value: fv})
+		}
+	}
+	return fields
+}
+
+// Field returns the setting at path
+func (c *Config) Field(path string) (Field, error) {
+	for _, f := range c.Fields() {
+		if f.Path == path {
+			return f, nil
+		}
+	}
+	return Field{}, fmt.Errorf("unknown setting %q", path)
+}
+
+// Env returns the name of the environment variable that overrides the field
+func (f Field) Env() string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(f.Path, ".", "_"))
+}
+
+// Type describes the kind of value the field holds
+func (f Field) Type() string {
+	switch {
+	case f.value.Type() == durationType:
+		return "duration"
+	case f.value.Kind() == reflect.Slice:
+		return "list"
+	case f.value.Kind() == reflect.Float64:
+		return "number"
+	default:
+		return f.value.Kind().String()
+	}
+}
+
+// Value returns the field's current value
+func (f Field) Value() any {
+	return f.value.Interface()
+}
+
+// String formats the value in the form Set accepts
+func (f Field) String() string {
+	switch v := f.value.Interface().(type) {
+	case time.Duration:
+		return v.String()
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case []string:
+		return strings.Join(v, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Set parses s according to the field's type and assigns it
+// Lists are comma-separated.
+func (f Field) Set(s string) error {
+	switch {
+	case f.value.Type() == durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("expected a duration such as 30s, got %q", s)
+		}
+		f.value.SetInt(int64(d))
+	case f.value.Kind() == reflect.String:
+		f.value.SetString(s)
+	case f.value.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("expected true or false, got %q", s)
+		}
+		f.value.SetBool(b)
+	case f.value.Kind() == reflect.Int:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("expected an integer, got %q", s)
+		}
+		f.value.SetInt(int64(n))
+	case f.value.Kind() == reflect.Float64:
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("expected a number, got %q", s)
+		}
+		f.value.SetFloat(n)
+	case f.value.Kind() == reflect.Slice && f.value.Type().Elem().Kind() == reflect.String:
+		var items []string
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		f.value.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported setting type %s", f.value.Type())
+	}
+	return nil
+}
+
+// ApplyEnv overrides settings from EMRYS_* variables in environ, which is in
+// the form os.Environ returns. It reports every variable it could not apply.
+func (c *Config) ApplyEnv(environ []string) error {
+	_, err := c.applyEnv(environ)
+	return err
+}
+
+// applyEnv applies the overrides and returns the variable used for each path
+func (c *Config) applyEnv(environ []string) (map[string]string, error) {
+	env := make(map[string]string)
+	for _, kv := range environ {
+		if key, value, ok := strings.Cut(kv, "="); ok && strings.HasPrefix(key, EnvPrefix) {
+			env[key] = value
+		}
+	}
+
+	overridden := make(map[string]string)
+	var errs ValidationError
+	for _, f := range c.Fields() {
+		value, ok := env[f.Env()]
+		if !ok {
+			continue
+		}
+		if err := f.Set(value); err != nil {
+			errs = append(errs, FieldError{Path: f.Path, Source: f.Env(), Message: err.Error()})
+			continue
+		}
+		overridden[f.Path] = f.Env()
+	}
+	return overridden, errs.orNil()
+}
@@ -0,0 +1,408 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/anicolao/emrys/internal/voice"
+	"gopkg.in/yaml.v3"
+)
+
+// header starts every config.yaml that Emrys writes
+const header = `# Emrys configuration
+# Unset values use their defaults. Any setting can be overridden with an
+# environment variable such as EMRYS_VOICE_RATE for voice.rate.
+`
+
+// FieldError is a problem with one setting
+type FieldError struct {
+	Path    string // Dotted path of the setting, such as "voice.rate"
+	Line    int    // Line in config.yaml, or 0 if unknown
+	Source  string // Environment variable the value came from, if any
+	Message string
+}
+
+// Error formats the error with its location
+func (e FieldError) Error() string {
+	var parts []string
+	if e.Source != "" {
+		parts = append(parts, e.Source)
+	} else if e.Line > 0 {
+		parts = append(parts, fmt.Sprintf("line %d", e.Line))
+	}
+	if e.Path != "" {
+		parts = append(parts, e.Path)
+	}
+	return strings.Join(append(parts, e.Message), ": ")
+}
+
+// ValidationError lists every problem found in a configuration
+type ValidationError []FieldError
+
+// Error returns one problem per line
+func (e ValidationError) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// orNil returns nil for an empty list so callers can return it as an error
+func (e ValidationError) orNil() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// languageCode matches the language codes whisper.cpp accepts
+var languageCode = regexp.MustCompile(`^[a-z]{2,3}$`)
+
+// Validate checks every setting and reports all problems as a
+// ValidationError
+func (c Config) Validate() error {
+	var errs ValidationError
+	invalid := func(path, format string, args ...any) {
+		errs = append(errs, FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if c.Version > CurrentVersion {
+		invalid("version", "%d is newer than this version of Emrys supports (%d)", c.Version, CurrentVersion)
+	}
+	if u := c.Ollama.URL; !strings.HasPrefix(u, "http://") && !strings.HasPrefix(u, "https://") ||
+		strings.TrimPrefix(strings.TrimPrefix(u, "http://"), "https://") == "" {
+		invalid("ollama.url", "must be an http:// or https:// URL, got %q", u)
+	}
+	if c.Models.Chat == "" {
+		invalid("models.chat", "must not be empty")
+	}
+	if c.Chat.NumCtx < 0 {
+		invalid("chat.num_ctx", "must not be negative, got %d", c.Chat.NumCtx)
+	}
+	if c.Chat.KeepRecent < 0 {
+		invalid("chat.keep_recent", "must not be negative, got %d", c.Chat.KeepRecent)
+	}
+	if c.Agent.MaxSteps < 0 {
+		invalid("agent.max_steps", "must not be negative, got %d", c.Agent.MaxSteps)
+	}
+
+	var voiceErrs interface{ Unwrap() []error }
+	if err := c.Voice.Validate(); errors.As(err, &voiceErrs) {
+		for _, err := range voiceErrs.Unwrap() {
+			var fe *voice.FieldError
+			if errors.As(err, &fe) {
+				invalid("voice."+fe.Key, "%s", strings.TrimPrefix(fe.Error(), fe.Key+" "))
+			} else {
+				invalid("voice", "%s", err)
+			}
+		}
+	} else if err != nil {
+		invalid("voice", "%s", err)
+	}
+
+	if c.Listen.Threshold < 0 || c.Listen.Threshold > 1 {
+		invalid("listen.threshold", "must be between 0.0 and 1.0, got %g", c.Listen.Threshold)
+	}
+	if l := c.Listen.Language; l != "auto" && !languageCode.MatchString(l) {
+		invalid("listen.language", "must be \"auto\" or a language code such as \"en\", got %q", l)
+	}
+	if c.Listen.WakeTimeout < 0 {
+		invalid("listen.wake_timeout", "must not be negative, got %s", c.Listen.WakeTimeout)
+	}
+
+	switch c.Session.Multiplexer {
+	case "tmux", "screen":
+	default:
+		invalid("session.multiplexer", "must be tmux or screen, got %q", c.Session.Multiplexer)
+	}
+	if n := c.Session.Name; n == "" || strings.ContainsAny(n, ":.") {
+		invalid("session.name", "must be non-empty without ':' or '.', got %q", n)
+	}
+
+	switch c.Browser.Headless {
+	case "auto", "true", "false":
+	default:
+		invalid("browser.headless", "must be auto, true or false, got %q", c.Browser.Headless)
+	}
+
+	switch c.Log.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		invalid("log.level", "must be debug, info, warn or error, got %q", c.Log.Level)
+	}
+	if c.Log.MaxSizeMB < 0 {
+		invalid("log.max_size_mb", "must not be negative, got %d", c.Log.MaxSizeMB)
+	}
+	if c.Log.MaxAgeDays < 0 {
+		invalid("log.max_age_days", "must not be negative, got %d", c.Log.MaxAgeDays)
+	}
+
+	if c.Diagnose.Entries < 0 {
+		invalid("diagnose.entries", "must not be negative, got %d", c.Diagnose.Entries)
+	}
+	if c.Supervisor.Interval <= 0 {
+		invalid("supervisor.interval", "must be positive, got %s", c.Supervisor.Interval)
+	}
+	if c.Supervisor.MaxBackoff < 0 {
+		invalid("supervisor.max_backoff", "must not be negative, got %s", c.Supervisor.MaxBackoff)
+	}
+	if c.Supervisor.Failures < 1 {
+		invalid("supervisor.failures", "must be at least 1, got %d", c.Supervisor.Failures)
+	}
+	if c.Supervisor.Cooldown <= 0 {
+		invalid("supervisor.cooldown", "must be positive, got %s", c.Supervisor.Cooldown)
+	}
+
+	for _, pattern := range c.Diagnose.Redact {
+		if _, err := regexp.Compile(pattern); err != nil {
+			invalid("diagnose.redact", "%q is not a regular expression: %v", pattern, err)
+		}
+	}
+
+	return errs.orNil()
+}
+
+// Parse reads config.yaml content over the defaults and validates it
+// Unknown keys are errors so that typos do not go unnoticed.
+func Parse(data []byte) (Config, error) {
+	config, lines, err := decode(data)
+	if err != nil {
+		return Config{}, err
+	}
+	if err := locate(config.Validate(), lines, nil); err != nil {
+		return Config{}, err
+	}
+	return config, nil
+}
+
+// Load reads the configuration at path, applies EMRYS_* environment
+// overrides and validates the result. A missing file yields the defaults.
+func Load(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return Config{}, fmt.Errorf("failed to read configuration: %w", err)
+	}
+
+	config, lines, err := decode(data)
+	if err != nil {
+		return Config{}, fmt.Errorf("%s: %w", path, err)
+	}
+	overridden, err := config.applyEnv(os.Environ())
+	if err != nil {
+		return Config{}, err
+	}
+	if err := locate(config.Validate(), lines, overridden); err != nil {
+		return Config{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return config, nil
+}
+
+// Save writes the configuration to path, replacing the file atomically
+func Save(path string, config Config) error {
+	data, err := Marshal(config)
+	if err != nil {
+		return err
+	}
+	return WriteFile(path, data)
+}
+
+// Marshal formats the configuration as a complete config.yaml
+func Marshal(config Config) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(header)
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(config); err != nil {
+		return nil, fmt.Errorf("failed to encode configuration: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode configuration: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteFile replaces path with data via a temporary file in the same
+// directory
+func WriteFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".config-*.yaml")
+	if err != nil {
+		return fmt.Errorf("failed to write configuration: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write configuration: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write configuration: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("failed to write configuration: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write configuration: %w", err)
+	}
+	return nil
+}
+
+// VoiceConfPending reports whether MigrateVoiceConf would import voice.conf:
+// there is a voice.conf but no config.yaml yet
+func VoiceConfPending(path, voicePath string) bool {
+	if _, err := os.Stat(path); err == nil || !os.IsNotExist(err) {
+		return false
+	}
+	_, err := os.Stat(voicePath)
+	return err == nil
+}
+
+// MigrateVoiceConf imports the settings in voice.conf into a new config.yaml
+// It does nothing if config.yaml already exists or there is no voice.conf,
+// and reports whether it wrote config.yaml. voice.conf is left in place but
+// is no longer read once config.yaml exists.
+func MigrateVoiceConf(path, voicePath string) (bool, error) {
+	if !VoiceConfPending(path, voicePath) {
+		return false, nil
+	}
+
+	voiceConfig, err := voice.LoadConfig(voicePath)
+	if err != nil {
+		return false, fmt.Errorf("failed to migrate voice configuration: %w", err)
+	}
+	config := Default()
+	config.Voice = voiceConfig
+	if err := Save(path, config); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// yamlLine matches the location prefix of yaml.v3 decoding errors
+var yamlLine = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// decode parses data over the defaults and returns the line of every key
+// Files written by older versions are upgraded in memory before decoding;
+// "emrys migrate" upgrades them on disk.
+func decode(data []byte) (Config, map[string]int, error) {
+	lines := make(map[string]int)
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return Config{}, nil, syntaxError(err)
+	}
+	if len(root.Content) == 0 {
+		return Default(), lines, nil
+	}
+	mapLines(root.Content[0], "", lines)
+
+	// A bad version is reported by the decode below
+	migrated := false
+	if version, err := fileVersion(data); err == nil && version < CurrentVersion {
+		if data, _, err = Format.Migrate(data); err != nil {
+			return Config{}, nil, err
+		}
+		migrated = true
+	}
+
+	config, err := decodeFields(data, lines, migrated)
+	if err != nil {
+		return Config{}, nil, err
+	}
+	return config, lines, nil
+}
+
+// decodeFields decodes data over the defaults, reporting keys of the wrong
+// type or unknown keys at their lines in the file as written. Once data has
+// been migrated its lines no longer match, so they are found by key.
+func decodeFields(data []byte, lines map[string]int, migrated bool) (Config, error) {
+	config := Default()
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	err := dec.Decode(&config)
+	if err == nil || err == io.EOF {
+		return config, nil
+	}
+
+	var typeErr *yaml.TypeError
+	if !errors.As(err, &typeErr) {
+		return Config{}, syntaxError(err)
+	}
+
+	var root yaml.Node
+	decoded := make(map[string]int)
+	if yaml.Unmarshal(data, &root) == nil && len(root.Content) > 0 {
+		mapLines(root.Content[0], "", decoded)
+	}
+	paths := make(map[int]string)
+	for path, line := range decoded {
+		paths[line] = path
+	}
+
+	var errs ValidationError
+	for _, msg := range typeErr.Errors {
+		fe := FieldError{Message: msg}
+		if m := yamlLine.FindStringSubmatch(msg); m != nil {
+			line, _ := strconv.Atoi(m[1])
+			fe.Path = paths[line]
+			fe.Message = m[2]
+			if fe.Line = lines[fe.Path]; fe.Line == 0 && !migrated {
+				fe.Line = line
+			}
+		}
+		errs = append(errs, fe)
+	}
+	return Config{}, errs
+}
+
+// syntaxError converts a YAML syntax error into a ValidationError with its
+// line
+func syntaxError(err error) error {
+	fe := FieldError{Message: strings.TrimPrefix(err.Error(), "yaml: ")}
+	if m := yamlLine.FindStringSubmatch(err.Error()); m != nil {
+		fe.Line, _ = strconv.Atoi(m[1])
+		fe.Message = m[2]
+	}
+	return ValidationError{fe}
+}
+
+// mapLines records the line of every mapping key below node
+func mapLines(node *yaml.Node, prefix string, lines map[string]int) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		path := prefix + key.Value
+		lines[path] = key.Line
+		mapLines(value, path+".", lines)
+	}
+}
+
+// locate fills in where each validation error's value came from
+func locate(err error, lines map[string]int, overridden map[string]string) error {
+	var errs ValidationError
+	if !errors.As(err, &errs) {
+		return err
+	}
+	for i := range errs {
+		if env, ok := overridden[errs[i].Path]; ok {
+			errs[i].Source = env
+		} else {
+			errs[i].Line = lines[errs[i].Path]
+		}
+	}
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Line < errs[j].Line })
+	return errs
+}
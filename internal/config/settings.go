@@ -0,0 +1,262 @@
+package config
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/anicolao/emrys/internal/agent"
+	"github.com/anicolao/emrys/internal/chat"
+	"github.com/anicolao/emrys/internal/llm"
+	"github.com/anicolao/emrys/internal/logging"
+	"github.com/anicolao/emrys/internal/supervisor"
+	"github.com/anicolao/emrys/internal/voice"
+	"github.com/anicolao/emrys/internal/voice/stt"
+)
+
+// Config is the Emrys configuration stored in ~/.config/emrys/config.yaml
+// Each section is the configuration of one subsystem.
+type Config struct {
+	Version    int          `yaml:"version" config:"-"` // Format version of the file
+	Ollama     Ollama       `yaml:"ollama"`
+	Models     Models       `yaml:"models"`
+	Chat       Chat         `yaml:"chat"`
+	Agent      Agent        `yaml:"agent"`
+	Voice      voice.Config `yaml:"voice"`
+	Listen     Listen       `yaml:"listen"`
+	Session    Session      `yaml:"session"`
+	Browser    Browser      `yaml:"browser"`
+	Log        Log          `yaml:"log"`
+	Diagnose   Diagnose     `yaml:"diagnose"`
+	Supervisor Supervisor   `yaml:"supervisor"`
+}
+
+// Ollama configures the connection to the local model server
+type Ollama struct {
+	URL string `yaml:"url"` // Address of the Ollama API
+}
+
+// Models names the models Emrys uses for each job
+type Models struct {
+	Chat      string `yaml:"chat"`      // Model for conversation and the default for everything else
+	Summarize string `yaml:"summarize"` // Model that folds old turns into summaries (empty: Chat)
+}
+
+// Chat configures conversations
+type Chat struct {
+	SystemPrompt string `yaml:"system_prompt"` // Sent before every conversation
+	NumCtx       int    `yaml:"num_ctx"`       // Context window in tokens (0: ask Ollama)
+	KeepRecent   int    `yaml:"keep_recent"`   // Recent messages never summarized
+}
+
+// Agent configures tool-using tasks
+type Agent struct {
+	Model        string `yaml:"model"`         // Model with tool-calling support (empty: models.chat)
+	SystemPrompt string `yaml:"system_prompt"` // Sent before every task
+	MaxSteps     int    `yaml:"max_steps"`     // Model round trips allowed per task
+	Record       string `yaml:"record"`        // File every step is appended to (empty: ~/Library/Logs/emrys/agent.jsonl)
+}
+
+// Listen configures speech input
+type Listen struct {
+	Device      string        `yaml:"device"`       // Input device (empty: system default)
+	ModelDir    string        `yaml:"model_dir"`    // Directory of whisper.cpp models (empty: ~/.config/emrys/whisper)
+	Model       string        `yaml:"model"`        // Path to a whisper.cpp model (empty: first model in model_dir)
+	Language    string        `yaml:"language"`     // Spoken language code, or "auto" to detect it
+	Threshold   float64       `yaml:"threshold"`    // Speech level as a fraction of full scale
+	Wake        bool          `yaml:"wake"`         // Only answer after a wake phrase
+	WakePhrases []string      `yaml:"wake_phrases"` // Phrases that wake Emrys
+	WakeTimeout time.Duration `yaml:"wake_timeout"` // How long to answer without the wake phrase
+}
+
+// Session configures the terminal multiplexer session Emrys runs in
+type Session struct {
+	Multiplexer string `yaml:"multiplexer"` // "tmux" or "screen"
+	Name        string `yaml:"name"`        // Session name
+	AutoCreate  bool   `yaml:"auto_create"` // Create the session if it does not exist
+}
+
+// Browser configures the browser Emrys uses for web tasks
+type Browser struct {
+	Type       string `yaml:"type"`       // Browser to drive, such as "chatgpt-atlas"
+	Headless   string `yaml:"headless"`   // "auto", "true" or "false"
+	Executable string `yaml:"executable"` // Path to the browser (empty: find it)
+}
+
+// Log configures Emrys's own log file
+type Log struct {
+	Level      string `yaml:"level"`        // "debug", "info", "warn" or "error"
+	File       string `yaml:"file"`         // Log file (empty: ~/Library/Logs/emrys/emrys.log)
+	MaxSizeMB  int    `yaml:"max_size_mb"`  // Size at which the log is rotated
+	MaxAgeDays int    `yaml:"max_age_days"` // Rotated logs older than this are removed (0: keep them)
+	Compress   bool   `yaml:"compress"`     // Gzip rotated logs
+}
+
+// Diagnose configures the bundles "emrys diagnose" writes for bug reports
+type Diagnose struct {
+	Entries         int      `yaml:"entries"`          // Recent audit and log entries to include
+	RedactSecrets   bool     `yaml:"redact_secrets"`   // Hide passwords, tokens and keys
+	RedactUsernames bool     `yaml:"redact_usernames"` // Hide the user name and home directory
+	Redact          []string `yaml:"redact"`           // Further regular expressions to hide
+}
+
+// Supervisor configures the health supervisor in "emrys run"
+type Supervisor struct {
+	Enabled    bool          `yaml:"enabled"`     // Restart services that stop responding
+	Interval   time.Duration `yaml:"interval"`    // Between probes of a healthy service
+	MaxBackoff time.Duration `yaml:"max_backoff"` // Longest wait between restarts
+	Failures   int           `yaml:"failures"`    // Failed probes in a row before restarts pause
+	Cooldown   time.Duration `yaml:"cooldown"`    // How long restarts pause
+}
+
+// Default returns the configuration used when config.yaml does not set a value
+func Default() Config {
+	return Config{
+		Version: CurrentVersion,
+		Ollama:  Ollama{URL: llm.DefaultURL},
+		Models:  Models{Chat: llm.DefaultModel},
+		Chat:    Chat{KeepRecent: chat.DefaultKeepRecent},
+		Agent:   Agent{MaxSteps: agent.DefaultMaxSteps},
+		Voice:   voice.DefaultConfig(),
+		Listen: Listen{
+			Language:    stt.LanguageAuto,
+			Threshold:   stt.DefaultVADOptions().Threshold,
+			WakePhrases: append([]string(nil), stt.DefaultWakePhrases...),
+			WakeTimeout: stt.DefaultWakeTimeout,
+		},
+		Session:  Session{Multiplexer: "tmux", Name: "emrys", AutoCreate: true},
+		Browser:  Browser{Type: "chatgpt-atlas", Headless: "auto"},
+		Log:      Log{Level: "info", MaxSizeMB: 100, MaxAgeDays: 30, Compress: true},
+		Diagnose: Diagnose{Entries: 200, RedactSecrets: true, RedactUsernames: true, Redact: []string{}},
+		Supervisor: Supervisor{
+			Enabled:    true,
+			Interval:   supervisor.DefaultOptions().Interval,
+			MaxBackoff: supervisor.DefaultOptions().MaxBackoff,
+			Failures:   supervisor.DefaultOptions().Failures,
+			Cooldown:   supervisor.DefaultOptions().Cooldown,
+		},
+	}
+}
+
+// PathEnv names an environment variable that moves config.yaml elsewhere
+const PathEnv = "EMRYS_CONFIG"
+
+// DefaultPath returns the location of config.yaml
+func DefaultPath() string {
+	if path := os.Getenv(PathEnv); path != "" {
+		return path
+	}
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".config", "emrys", "config.yaml")
+}
+
+// VoiceConfPath returns the location of the voice.conf file that predates
+// config.yaml
+func VoiceConfPath() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".config", "emrys", "voice.conf")
+}
+
+// Roles returns the models for each job
+func (m Models) Roles() llm.Roles {
+	roles := llm.Roles{Chat: m.Chat, Summarize: m.Summarize}
+	if roles.Summarize == "" {
+		roles.Summarize = roles.Chat
+	}
+	return roles
+}
+
+// Client returns an Ollama client for the configured URL
+func (c Config) Client() *llm.Client {
+	return llm.NewClient(c.Ollama.URL)
+}
+
+// ChatOptions returns the options for a chat engine
+func (c Config) ChatOptions() chat.Options {
+	return chat.Options{
+		Roles:        c.Models.Roles(),
+		SystemPrompt: c.Chat.SystemPrompt,
+		NumCtx:       c.Chat.NumCtx,
+		KeepRecent:   c.Chat.KeepRecent,
+	}
+}
+
+// AgentOptions returns the options for an agent
+func (c Config) AgentOptions() agent.Options {
+	model := c.Agent.Model
+	if model == "" {
+		model = c.Models.Chat
+	}
+	return agent.Options{
+		Model:        model,
+		SystemPrompt: c.Agent.SystemPrompt,
+		MaxSteps:     c.Agent.MaxSteps,
+	}
+}
+
+// RecordPath returns the file agent steps are recorded in, applying the
+// default location
+func (a Agent) RecordPath() string {
+	if a.Record != "" {
+		return a.Record
+	}
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, "Library", "Logs", "emrys", "agent.jsonl")
+}
+
+// Whisper returns a transcriber for the configured model
+func (l Listen) Whisper() *stt.Whisper {
+	w := stt.NewWhisper(l.ModelDir)
+	w.Model = l.Model
+	if l.Language != "" {
+		w.Language = l.Language
+	}
+	return w
+}
+
+// VAD returns the voice activity detection options
+func (l Listen) VAD() stt.VADOptions {
+	opts := stt.DefaultVADOptions()
+	if l.Threshold > 0 {
+		opts.Threshold = l.Threshold
+	}
+	return opts
+}
+
+// Path returns the log file, applying the default location
+func (l Log) Path() string {
+	if l.File != "" {
+		return l.File
+	}
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, "Library", "Logs", "emrys", "emrys.log")
+}
+
+// FileOptions returns the rotation limits for the log file
+func (l Log) FileOptions() logging.FileOptions {
+	return logging.FileOptions{
+		MaxSize:  int64(l.MaxSizeMB) << 20,
+		MaxAge:   time.Duration(l.MaxAgeDays) * 24 * time.Hour,
+		Compress: l.Compress,
+	}
+}
+
+// Options returns the supervisor's probe and restart timing
+func (s Supervisor) Options() supervisor.Options {
+	opts := supervisor.DefaultOptions()
+	opts.Interval = s.Interval
+	opts.MaxBackoff = s.MaxBackoff
+	opts.Failures = s.Failures
+	opts.Cooldown = s.Cooldown
+	return opts
+}
+
+// SlogLevel returns the level below which records are not logged
+func (l Log) SlogLevel() slog.Level {
+	level, err := logging.ParseLevel(l.Level)
+	if err != nil {
+		return slog.LevelInfo
+	}
+	return level
+}
@@ -0,0 +1,366 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anicolao/emrys/internal/llm"
+	"github.com/anicolao/emrys/internal/migrate"
+	"github.com/anicolao/emrys/internal/voice"
+)
+
+func TestDefaultIsValid(t *testing.T) {
+	if err := Default().Validate(); err != nil {
+		t.Errorf("Expected the defaults to be valid, got %v", err)
+	}
+}
+
+func TestParseEmptyUsesDefaults(t *testing.T) {
+	for _, data := range []string{"", "# nothing set\n"} {
+		config, err := Parse([]byte(data))
+		if err != nil {
+			t.Fatalf("Parse(%q) failed: %v", data, err)
+		}
+		if !reflect.DeepEqual(config, Default()) {
+			t.Errorf("Expected defaults for %q, got %+v", data, config)
+		}
+	}
+}
+
+func TestParseOverridesDefaults(t *testing.T) {
+	config, err := Parse([]byte(`
+ollama:
+  url: http://studio.local:11434
+models:
+  chat: qwen2.5
+voice:
+  rate: 180
+  quiet_windows: "22:00-07:00"
+listen:
+  wake: true
+  wake_phrases: [computer]
+  wake_timeout: 30s
+`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if config.Ollama.URL != "http://studio.local:11434" {
+		t.Errorf("Expected the configured URL, got %s", config.Ollama.URL)
+	}
+	if roles := config.Models.Roles(); roles.Chat != "qwen2.5" || roles.Summarize != "qwen2.5" {
+		t.Errorf("Expected summarize to default to the chat model, got %+v", roles)
+	}
+	if config.Voice.Rate != 180 || config.Voice.Voice != "Jamie" {
+		t.Errorf("Expected rate 180 and the default voice, got %d and %s", config.Voice.Rate, config.Voice.Voice)
+	}
+	if !config.Listen.Wake || config.Listen.WakeTimeout != 30*time.Second {
+		t.Errorf("Expected wake mode for 30s, got %v for %s", config.Listen.Wake, config.Listen.WakeTimeout)
+	}
+	if !reflect.DeepEqual(config.Listen.WakePhrases, []string{"computer"}) {
+		t.Errorf("Expected [computer], got %v", config.Listen.WakePhrases)
+	}
+	if config.AgentOptions().Model != "qwen2.5" {
+		t.Errorf("Expected the agent to use the chat model, got %s", config.AgentOptions().Model)
+	}
+}
+
+// fieldErrors returns the errors in err, failing the test if it is not a
+// ValidationError
+func fieldErrors(t *testing.T, err error) ValidationError {
+	t.Helper()
+	var errs ValidationError
+	if !errors.As(err, &errs) {
+		t.Fatalf("Expected a ValidationError, got %v", err)
+	}
+	return errs
+}
+
+func TestParseReportsFieldsAndLines(t *testing.T) {
+	_, err := Parse([]byte(`models:
+  chat: llama3.2
+voice:
+  rate: 900
+  volume: 0.5
+listen:
+  threshold: 2
+log:
+  level: loud
+`))
+	errs := fieldErrors(t, err)
+
+	want := []FieldError{
+		{Path: "voice.rate", Line: 4},
+		{Path: "listen.threshold", Line: 7},
+		{Path: "log.level", Line: 9},
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("Expected %d errors, got %v", len(want), err)
+	}
+	for i, w := range want {
+		if errs[i].Path != w.Path || errs[i].Line != w.Line {
+			t.Errorf("Expected %s on line %d, got %s on line %d", w.Path, w.Line, errs[i].Path, errs[i].Line)
+		}
+	}
+	if got := errs[0].Error(); got != "line 4: voice.rate: must be between 50 and 500, got 900" {
+		t.Errorf("Unexpected message: %s", got)
+	}
+}
+
+func TestParseRejectsUnknownAndMistypedKeys(t *testing.T) {
+	_, err := Parse([]byte(`voice:
+  rate: fast
+session:
+  nmae: work
+`))
+	errs := fieldErrors(t, err)
+	if len(errs) != 2 {
+		t.Fatalf("Expected 2 errors, got %v", err)
+	}
+	if errs[0].Path != "voice.rate" || errs[0].Line != 2 {
+		t.Errorf("Expected voice.rate on line 2, got %s on line %d", errs[0].Path, errs[0].Line)
+	}
+	if errs[1].Path != "session.nmae" || errs[1].Line != 4 || !strings.Contains(errs[1].Message, "not found") {
+		t.Errorf("Expected the unknown key on line 4, got %v", errs[1])
+	}
+}
+
+func TestParseSyntaxError(t *testing.T) {
+	_, err := Parse([]byte("voice:\n  rate: [180\n"))
+	errs := fieldErrors(t, err)
+	if errs[0].Line == 0 {
+		t.Errorf("Expected the syntax error to have a line, got %v", err)
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	config := Default()
+	err := config.ApplyEnv([]string{
+		"HOME=/Users/emrys",
+		"EMRYS_OLLAMA_URL=http://10.0.0.2:11434",
+		"EMRYS_VOICE_ENABLED=false",
+		"EMRYS_VOICE_VOLUME=0.4",
+		"EMRYS_LISTEN_WAKE_PHRASES=computer, jarvis",
+		"EMRYS_LISTEN_WAKE_TIMEOUT=1m",
+	})
+	if err != nil {
+		t.Fatalf("ApplyEnv failed: %v", err)
+	}
+
+	if config.Ollama.URL != "http://10.0.0.2:11434" {
+		t.Errorf("Expected the URL from the environment, got %s", config.Ollama.URL)
+	}
+	if config.Voice.Enabled || config.Voice.Volume != 0.4 {
+		t.Errorf("Expected voice disabled at 0.4, got %v at %g", config.Voice.Enabled, config.Voice.Volume)
+	}
+	if !reflect.DeepEqual(config.Listen.WakePhrases, []string{"computer", "jarvis"}) {
+		t.Errorf("Expected two wake phrases, got %v", config.Listen.WakePhrases)
+	}
+	if config.Listen.WakeTimeout != time.Minute {
+		t.Errorf("Expected 1m, got %s", config.Listen.WakeTimeout)
+	}
+}
+
+func TestApplyEnvRejectsBadValues(t *testing.T) {
+	config := Default()
+	errs := fieldErrors(t, config.ApplyEnv([]string{"EMRYS_VOICE_RATE=fast"}))
+	if len(errs) != 1 || errs[0].Source != "EMRYS_VOICE_RATE" || errs[0].Path != "voice.rate" {
+		t.Errorf("Expected an error naming the variable, got %v", errs)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+
+	// A missing file yields the defaults
+	config, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if config.Models.Chat != llm.DefaultModel {
+		t.Errorf("Expected the default model, got %s", config.Models.Chat)
+	}
+
+	if err := os.WriteFile(path, []byte("voice:\n  rate: 150\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("EMRYS_VOICE_RATE", "900")
+
+	// An invalid environment override is reported against the variable
+	_, err = Load(path)
+	if err == nil || !strings.Contains(err.Error(), "EMRYS_VOICE_RATE: voice.rate: must be between") {
+		t.Errorf("Expected the override to be blamed, got %v", err)
+	}
+
+	t.Setenv("EMRYS_VOICE_RATE", "220")
+	config, err = Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if config.Voice.Rate != 220 {
+		t.Errorf("Expected the environment to win, got %d", config.Voice.Rate)
+	}
+}
+
+func TestLoadUpgradesOldFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	old := "# My Mac Mini\nversion: 0\nvoice:\n  rate: 150\n"
+	if err := os.WriteFile(path, []byte(old), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := Load(path)
+	if err != nil {
+		t.Fatalf("Expected an old file to load, got %v", err)
+	}
+	if config.Version != CurrentVersion || config.Voice.Rate != 150 {
+		t.Errorf("Expected version %d with rate 150, got %d and %d", CurrentVersion, config.Version, config.Voice.Rate)
+	}
+	if data, _ := os.ReadFile(path); string(data) != old {
+		t.Errorf("Expected the file to be left for emrys migrate, got:\n%s", data)
+	}
+}
+
+func TestParseMigratesBeforeDecoding(t *testing.T) {
+	// A migration that renames voice.speed to voice.rate, as a later
+	// version might
+	format := Format
+	t.Cleanup(func() { Format = format })
+	Format.Migrations = []migrate.Migration{{Version: 1, Apply: func(data []byte) ([]byte, error) {
+		data = bytes.Replace(data, []byte("speed:"), []byte("rate:"), 1)
+		return setVersion(1)(data)
+	}}}
+
+	config, err := Parse([]byte("voice:\n  speed: 150\n"))
+	if err != nil {
+		t.Fatalf("Expected the renamed key to load, got %v", err)
+	}
+	if config.Voice.Rate != 150 {
+		t.Errorf("Expected rate 150, got %d", config.Voice.Rate)
+	}
+
+	// Migrating adds a version line, but errors keep the lines as written
+	_, err = Parse([]byte("voice:\n  speed: 150\nsession:\n  nmae: x\n"))
+	var errs ValidationError
+	if !errors.As(err, &errs) || len(errs) != 1 {
+		t.Fatalf("Expected one error, got %v", err)
+	}
+	if errs[0].Path != "session.nmae" || errs[0].Line != 4 {
+		t.Errorf("Expected the unknown key on line 4, got %s on line %d", errs[0].Path, errs[0].Line)
+	}
+}
+
+func TestSaveRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "emrys", "config.yaml")
+	config := Default()
+	config.Models.Summarize = "llama3.2:1b"
+	config.Voice.Holidays = "2025-12-25"
+	config.Listen.WakeTimeout = 45 * time.Second
+
+	if err := Save(path, config); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "# Emrys configuration") {
+		t.Error("Expected the file to start with the header comment")
+	}
+	if !strings.Contains(string(data), "wake_timeout: 45s") {
+		t.Errorf("Expected durations to be written readably, got:\n%s", data)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !reflect.DeepEqual(loaded, config) {
+		t.Errorf("Expected %+v, got %+v", config, loaded)
+	}
+}
+
+func TestMigrateVoiceConf(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	voicePath := filepath.Join(dir, "voice.conf")
+
+	// Nothing to migrate
+	if migrated, err := MigrateVoiceConf(path, voicePath); err != nil || migrated {
+		t.Fatalf("Expected no migration without voice.conf, got %v, %v", migrated, err)
+	}
+
+	if err := os.WriteFile(voicePath, []byte("voice = Samantha\nrate = 175\nquiet_hours = true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if !VoiceConfPending(path, voicePath) {
+		t.Error("Expected the import to be pending")
+	}
+	migrated, err := MigrateVoiceConf(path, voicePath)
+	if err != nil || !migrated {
+		t.Fatalf("Expected voice.conf to be migrated, got %v, %v", migrated, err)
+	}
+
+	config, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	want := voice.DefaultConfig()
+	want.Voice = "Samantha"
+	want.Rate = 175
+	want.QuietHours = true
+	if !reflect.DeepEqual(config.Voice, want) {
+		t.Errorf("Expected %+v, got %+v", want, config.Voice)
+	}
+
+	// config.yaml is authoritative once it exists
+	if err := os.WriteFile(voicePath, []byte("voice = Alex\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if VoiceConfPending(path, voicePath) {
+		t.Error("Expected no import to be pending once config.yaml exists")
+	}
+	if migrated, _ := MigrateVoiceConf(path, voicePath); migrated {
+		t.Error("Expected an existing config.yaml not to be replaced")
+	}
+}
+
+func TestFieldSetChecksTypes(t *testing.T) {
+	config := Default()
+	tests := []struct {
+		path, value string
+		ok          bool
+	}{
+		{"voice.rate", "180", true},
+		{"voice.rate", "fast", false},
+		{"voice.enabled", "maybe", false},
+		{"voice.volume", "0.25", true},
+		{"listen.wake_timeout", "15", false},
+		{"models.chat", "mistral", true},
+	}
+	for _, tt := range tests {
+		f, err := config.Field(tt.path)
+		if err != nil {
+			t.Fatalf("Field(%s) failed: %v", tt.path, err)
+		}
+		if err := f.Set(tt.value); (err == nil) != tt.ok {
+			t.Errorf("Set(%s, %q): expected ok=%v, got %v", tt.path, tt.value, tt.ok, err)
+		}
+	}
+	if config.Voice.Rate != 180 || config.Voice.Volume != 0.25 || config.Models.Chat != "mistral" {
+		t.Errorf("Expected valid values to be set, got %+v", config)
+	}
+
+	if _, err := config.Field("voice.pitch"); err == nil {
+		t.Error("Expected an unknown setting to be an error")
+	}
+	if f, _ := config.Field("voice.rate"); f.Env() != "EMRYS_VOICE_RATE" {
+		t.Errorf("Expected EMRYS_VOICE_RATE, got %s", f.Env())
+	}
+}
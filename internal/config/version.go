@@ -0,0 +1,65 @@
+package config
+
+import (
+	"strconv"
+
+	"github.com/anicolao/emrys/internal/migrate"
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentVersion is the config.yaml format this version of Emrys writes
+const CurrentVersion = 1
+
+// Format describes the config.yaml versions and how to upgrade them
+var Format = migrate.Format{
+	Name:    "config.yaml",
+	Current: CurrentVersion,
+	Version: fileVersion,
+	Migrations: []migrate.Migration{
+		{Version: 1, Description: "Record the format version", Apply: setVersion(1)},
+	},
+}
+
+// fileVersion reads the version of a config.yaml; files without one are
+// version 0
+func fileVersion(data []byte) (int, error) {
+	var file struct {
+		Version int `yaml:"version"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return 0, syntaxError(err)
+	}
+	return file.Version, nil
+}
+
+// setVersion returns a migration step that records version in the file,
+// keeping its comments
+func setVersion(version int) func([]byte) ([]byte, error) {
+	return func(data []byte) ([]byte, error) {
+		d, err := ParseDocument(data)
+		if err != nil {
+			return nil, err
+		}
+		d.setVersion(version)
+		return d.Bytes()
+	}
+}
+
+// setVersion stores the format version as the document's first key
+func (d *Document) setVersion(version int) {
+	mapping := d.root.Content[0]
+	value := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(version)}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == "version" {
+			mapping.Content[i+1] = value
+			return
+		}
+	}
+
+	// Keep a comment at the top of the file above the new key
+	key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "version"}
+	if len(mapping.Content) > 0 {
+		key.HeadComment, mapping.Content[0].HeadComment = mapping.Content[0].HeadComment, ""
+	}
+	mapping.Content = append([]*yaml.Node{key, value}, mapping.Content...)
+}
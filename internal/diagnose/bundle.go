@@ -0,0 +1,98 @@
+// Package diagnose collects logs, state and configuration into a bundle
+// that can be attached to a bug report
+package diagnose
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// File is one file in a bundle
+type File struct {
+	Name    string // Path inside the bundle
+	Collect func(ctx context.Context) ([]byte, error)
+}
+
+// Entry records what happened to one file of a bundle
+type Entry struct {
+	Name  string `json:"name"`
+	Size  int    `json:"size"`
+	Error string `json:"error,omitempty"` // Why the file is missing
+}
+
+// ManifestName is the file in every bundle that lists its contents
+const ManifestName = "MANIFEST.txt"
+
+// Write collects the files, redacts them and writes them to w as a gzipped
+// tar under a directory named after now. A file that cannot be collected is
+// left out and listed in the manifest with the reason, so one broken source
+// does not cost the rest of the bundle.
+func Write(ctx context.Context, w io.Writer, files []File, redactor *Redactor, now time.Time) ([]Entry, error) {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	dir := "emrys-diagnose-" + now.UTC().Format("20060102-150405") + "/"
+
+	var manifest bytes.Buffer
+	fmt.Fprintf(&manifest, "Emrys diagnostic bundle created %s\n\n", now.UTC().Format(time.RFC3339))
+
+	var entries []Entry
+	for _, file := range files {
+		entry := Entry{Name: file.Name}
+		data, err := file.Collect(ctx)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			entry.Error = "not present"
+		case err != nil:
+			entry.Error = err.Error()
+		}
+		if len(data) > 0 || err == nil {
+			data = redactor.Redact(data)
+			entry.Size = len(data)
+			if err := writeFile(tw, dir+file.Name, data, now); err != nil {
+				return entries, err
+			}
+		}
+		entries = append(entries, entry)
+
+		if entry.Error != "" {
+			fmt.Fprintf(&manifest, "✗ %s: %s\n", entry.Name, redactor.Redact([]byte(entry.Error)))
+		} else {
+			fmt.Fprintf(&manifest, "✓ %s (%d bytes)\n", entry.Name, entry.Size)
+		}
+	}
+
+	if err := writeFile(tw, dir+ManifestName, manifest.Bytes(), now); err != nil {
+		return entries, err
+	}
+	if err := tw.Close(); err != nil {
+		return entries, fmt.Errorf("failed to finish bundle: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return entries, fmt.Errorf("failed to compress bundle: %w", err)
+	}
+	return entries, nil
+}
+
+// writeFile adds one regular file to the archive
+func writeFile(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: modTime,
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to add %s to bundle: %w", name, err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("failed to add %s to bundle: %w", name, err)
+	}
+	return nil
+}
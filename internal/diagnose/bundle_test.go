@@ -0,0 +1,92 @@
+package diagnose
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anicolao/emrys/internal/config"
+)
+
+// readBundle returns the files in a gzipped tar by name
+func readBundle(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Bundle is not gzipped: %v", err)
+	}
+	tr := tar.NewReader(gz)
+	files := map[string]string{}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Bundle is not a tar: %v", err)
+		}
+		content, _ := io.ReadAll(tr)
+		files[header.Name] = string(content)
+	}
+	return files
+}
+
+func TestWrite(t *testing.T) {
+	constant := func(s string) func(context.Context) ([]byte, error) {
+		return func(context.Context) ([]byte, error) { return []byte(s), nil }
+	}
+	files := []File{
+		{Name: "config.yaml", Collect: constant("token: abc\n")},
+		{Name: "missing.log", Collect: func(context.Context) ([]byte, error) { return nil, os.ErrNotExist }},
+		{Name: "ollama/ps.json", Collect: func(context.Context) ([]byte, error) {
+			return []byte("oops"), errors.New("returned status 500")
+		}},
+	}
+	redactor, _ := NewRedactor(config.Diagnose{RedactSecrets: true}, "", "")
+	now := time.Date(2025, 1, 6, 12, 0, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+	entries, err := Write(context.Background(), &buf, files, redactor, now)
+	if err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if len(entries) != 3 || entries[1].Error != "not present" || entries[2].Error != "returned status 500" {
+		t.Errorf("Unexpected entries: %+v", entries)
+	}
+
+	bundle := readBundle(t, buf.Bytes())
+	dir := "emrys-diagnose-20250106-120000/"
+	if got := bundle[dir+"config.yaml"]; got != "token: [REDACTED]\n" {
+		t.Errorf("Expected a redacted config, got %q", got)
+	}
+	if _, ok := bundle[dir+"missing.log"]; ok {
+		t.Error("Expected a missing file to be left out")
+	}
+	if got := bundle[dir+"ollama/ps.json"]; got != "oops" {
+		t.Errorf("Expected partial output to be kept, got %q", got)
+	}
+	manifest := bundle[dir+ManifestName]
+	if !strings.Contains(manifest, "✓ config.yaml (18 bytes)") || !strings.Contains(manifest, "✗ missing.log: not present") {
+		t.Errorf("Unexpected manifest:\n%s", manifest)
+	}
+}
+
+func TestTail(t *testing.T) {
+	data := []byte("one\ntwo\nthree\n")
+	if got := string(tail(data, 2)); got != "two\nthree\n" {
+		t.Errorf("Expected the last two lines, got %q", got)
+	}
+	if got := string(tail(data, 10)); got != "one\ntwo\nthree\n" {
+		t.Errorf("Expected every line, got %q", got)
+	}
+	if got := string(tail(data, 0)); got != string(data) {
+		t.Errorf("Expected 0 to keep everything, got %q", got)
+	}
+}
@@ -0,0 +1,109 @@
+package diagnose
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/anicolao/emrys/internal/audit"
+	"github.com/anicolao/emrys/internal/bootstrap"
+	"github.com/anicolao/emrys/internal/doctor"
+)
+
+// nixFiles are the generated nix-darwin files in ~/.nixpkgs
+var nixFiles = []string{"flake.nix", "flake.lock", "darwin-configuration.nix"}
+
+// Files returns what a bundle collects from the machine env describes
+func Files(env doctor.Env) []File {
+	entries := env.Settings.Diagnose.Entries
+	logs := filepath.Join(env.Home, "Library", "Logs")
+
+	files := []File{
+		{Name: "doctor.txt", Collect: func(ctx context.Context) ([]byte, error) {
+			var buf bytes.Buffer
+			doctor.Run(ctx, doctor.Checks(env)).WriteText(&buf)
+			return buf.Bytes(), nil
+		}},
+		{Name: "config.yaml", Collect: readFile(env.ConfigPath)},
+		{Name: "bootstrap.json", Collect: readFile(bootstrap.GetStatePath())},
+		{Name: "audit.jsonl", Collect: tailFile(audit.DefaultPath(), entries)},
+		{Name: "emrys.log", Collect: tailFile(env.Settings.Log.Path(), entries)},
+	}
+	for _, name := range nixFiles {
+		files = append(files, File{Name: "nix/" + name, Collect: readFile(filepath.Join(env.Home, ".nixpkgs", name))})
+	}
+	files = append(files,
+		File{Name: "nix/generations.txt", Collect: func(ctx context.Context) ([]byte, error) {
+			return env.Command(ctx, "darwin-rebuild", "--list-generations")
+		}},
+		File{Name: "ollama/version.json", Collect: fetch(env.Settings.Ollama.URL + "/api/version")},
+		File{Name: "ollama/ps.json", Collect: fetch(env.Settings.Ollama.URL + "/api/ps")},
+		File{Name: "launchd/ollama-error.log", Collect: tailFile(filepath.Join(logs, "ollama-error.log"), entries)},
+	)
+
+	// Emrys's own launch agents write their standard error here
+	stderrLogs, _ := filepath.Glob(filepath.Join(logs, "emrys", "*-error.log"))
+	for _, path := range stderrLogs {
+		files = append(files, File{Name: "launchd/" + filepath.Base(path), Collect: tailFile(path, entries)})
+	}
+	return files
+}
+
+// readFile collects a whole file
+func readFile(path string) func(context.Context) ([]byte, error) {
+	return func(context.Context) ([]byte, error) {
+		return os.ReadFile(path)
+	}
+}
+
+// tailFile collects the last n lines of a file, or all of it if n is 0
+func tailFile(path string, n int) func(context.Context) ([]byte, error) {
+	return func(context.Context) ([]byte, error) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return tail(data, n), nil
+	}
+}
+
+// tail returns the last n lines of data
+func tail(data []byte, n int) []byte {
+	if n <= 0 {
+		return data
+	}
+	lines := strings.SplitAfter(strings.TrimRight(string(data), "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return []byte(strings.Join(lines, "") + "\n")
+}
+
+// fetch collects the body of an HTTP GET
+func fetch(url string) func(context.Context) ([]byte, error) {
+	return func(ctx context.Context) ([]byte, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("request to %s failed: %w", url, err)
+		}
+		defer resp.Body.Close()
+
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", url, err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return data, fmt.Errorf("%s returned status %d", url, resp.StatusCode)
+		}
+		return data, nil
+	}
+}
@@ -0,0 +1,66 @@
+package diagnose
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/anicolao/emrys/internal/config"
+)
+
+// Redacted replaces anything hidden from a bundle
+const Redacted = "[REDACTED]"
+
+// rule replaces matches of re with repl, which may refer to groups
+type rule struct {
+	re   *regexp.Regexp
+	repl string
+}
+
+// secretRules hide credentials that commonly turn up in configuration,
+// logs and command lines
+var secretRules = []rule{
+	{regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`), Redacted},
+	{regexp.MustCompile(`(?i)((?:password|passwd|secret|token|api[_-]?key|access[_-]?key|authorization)[\w-]*["']?\s*[:=]\s*["']?(?:(?:bearer|basic)\s+)?)[^\s"',\[{]+`), "${1}" + Redacted},
+	{regexp.MustCompile(`(?i)(bearer\s+)[\w.~+/=-]+`), "${1}" + Redacted},
+	{regexp.MustCompile(`((?:ssh-(?:rsa|ed25519|dss)|ecdsa-sha2-nistp\d+) )[A-Za-z0-9+/=]+`), "${1}" + Redacted},
+	{regexp.MustCompile(`(://)[^/@\s:]+:[^/@\s]+@`), "${1}" + Redacted + "@"},
+}
+
+// Redactor hides secrets and personal details in bundled files
+type Redactor struct {
+	rules []rule
+}
+
+// NewRedactor builds a redactor from the diagnose settings. When usernames
+// are redacted, home is shown as "~" and username as "<user>".
+func NewRedactor(settings config.Diagnose, username, home string) (*Redactor, error) {
+	r := &Redactor{}
+	if settings.RedactSecrets {
+		r.rules = append(r.rules, secretRules...)
+	}
+	if settings.RedactUsernames {
+		if home != "" && home != "/" {
+			r.rules = append(r.rules, rule{regexp.MustCompile(regexp.QuoteMeta(strings.TrimSuffix(home, "/")) + `\b`), "~"})
+		}
+		if username != "" {
+			r.rules = append(r.rules, rule{regexp.MustCompile(`\b` + regexp.QuoteMeta(username) + `\b`), "<user>"})
+		}
+	}
+	for _, pattern := range settings.Redact {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile redaction pattern %q: %w", pattern, err)
+		}
+		r.rules = append(r.rules, rule{re, Redacted})
+	}
+	return r, nil
+}
+
+// Redact returns data with every rule applied
+func (r *Redactor) Redact(data []byte) []byte {
+	for _, rule := range r.rules {
+		data = rule.re.ReplaceAll(data, []byte(rule.repl))
+	}
+	return data
+}
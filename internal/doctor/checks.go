@@ -0,0 +1,271 @@
+package doctor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/anicolao/emrys/internal/audit"
+	"github.com/anicolao/emrys/internal/bootstrap"
+	"github.com/anicolao/emrys/internal/config"
+	"github.com/anicolao/emrys/internal/llm"
+	"github.com/anicolao/emrys/internal/voice"
+)
+
+// initiator attributes the commands doctor runs in the audit log
+const initiator = "doctor"
+
+// Disk space below which doctor warns or fails; models take several
+// gigabytes each
+const (
+	WarnFreeBytes = 20 << 30
+	FailFreeBytes = 5 << 30
+)
+
+// OllamaAgent is the launch agent bootstrap installs to run Ollama
+const OllamaAgent = bootstrap.OllamaAgent
+
+// darwinRebuildPath is where nix-darwin installs darwin-rebuild
+const darwinRebuildPath = "/run/current-system/sw/bin/darwin-rebuild"
+
+// Env is what the standard checks inspect. Tests replace its functions to
+// check without touching the real system.
+type Env struct {
+	Home         string
+	ConfigPath   string        // Location of config.yaml
+	Settings     config.Config // Loaded configuration, or the defaults
+	LaunchAgents []string      // Labels of launch agents that must be loaded
+
+	// LookPath finds programs on the PATH
+	LookPath func(file string) (string, error)
+	// Command runs a program and returns its combined output
+	Command func(ctx context.Context, name string, args ...string) ([]byte, error)
+	// FreeSpace returns the bytes available on the volume holding path
+	FreeSpace func(path string) (uint64, error)
+	// Backend chooses the speech backend
+	Backend func(config voice.Config) voice.Backend
+}
+
+// DefaultEnv inspects this machine using the configuration at
+// config.DefaultPath; a configuration that fails to load is reported by the
+// config check and the defaults are used for the rest
+func DefaultEnv() Env {
+	home, _ := os.UserHomeDir()
+	path := config.DefaultPath()
+	settings, err := config.Load(path)
+	if err != nil {
+		settings = config.Default()
+	}
+	return Env{
+		Home:         home,
+		ConfigPath:   path,
+		Settings:     settings,
+		LaunchAgents: []string{OllamaAgent},
+		LookPath:     exec.LookPath,
+		Command:      runCommand,
+		FreeSpace:    freeSpace,
+		Backend:      voice.SelectBackend,
+	}
+}
+
+// runCommand runs a program and records it in the audit log
+func runCommand(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return audit.CombinedOutput(initiator, exec.CommandContext(ctx, name, args...))
+}
+
+// Checks returns the standard checks in the order they are reported
+func Checks(env Env) []Check {
+	return []Check{
+		{Name: "Configuration", Run: env.checkConfig},
+		{Name: "Nix daemon", Run: env.checkNixDaemon},
+		{Name: "darwin-rebuild", Run: env.checkDarwinRebuild},
+		{Name: "Ollama API", Run: env.checkOllamaAPI},
+		{Name: "Installed models", Run: env.checkModels},
+		{Name: "Chat model", Timeout: 2 * time.Minute, Run: env.checkChatModel},
+		{Name: "Voice output", Run: env.checkVoice},
+		{Name: "Launch agents", Run: env.checkLaunchAgents},
+		{Name: "SSH", Run: env.checkSSH},
+		{Name: "Disk space", Run: env.checkDiskSpace},
+	}
+}
+
+// checkConfig validates config.yaml
+func (e Env) checkConfig(ctx context.Context) Result {
+	if _, err := os.Stat(e.ConfigPath); os.IsNotExist(err) {
+		return pass("%s does not exist; using the defaults", e.ConfigPath)
+	}
+	if _, err := config.Load(e.ConfigPath); err != nil {
+		return fail("Run 'emrys config edit' to fix it, or 'emrys config validate' to see every problem", "%v", err)
+	}
+	return pass("%s is valid", e.ConfigPath)
+}
+
+// checkNixDaemon asks the Nix daemon to respond
+func (e Env) checkNixDaemon(ctx context.Context) Result {
+	if _, err := e.LookPath("nix"); err != nil {
+		return fail("Run 'emrys bootstrap nix-darwin' to install Nix", "nix is not on the PATH")
+	}
+	if output, err := e.Command(ctx, "nix", "store", "ping"); err != nil {
+		return fail("Restart it with 'sudo launchctl kickstart -k system/org.nixos.nix-daemon'",
+			"the daemon did not respond: %s", firstLine(output, err))
+	}
+	return pass("the daemon is responding")
+}
+
+// checkDarwinRebuild looks for darwin-rebuild on the PATH
+func (e Env) checkDarwinRebuild(ctx context.Context) Result {
+	if path, err := e.LookPath("darwin-rebuild"); err == nil {
+		return pass("found at %s", path)
+	}
+	if _, err := os.Stat(darwinRebuildPath); err == nil {
+		return warn("Open a new terminal so that your shell picks up the nix-darwin PATH",
+			"installed at %s but not on the PATH", darwinRebuildPath)
+	}
+	return fail("Run 'emrys bootstrap nix-darwin' to install nix-darwin", "darwin-rebuild is not installed")
+}
+
+// checkOllamaAPI asks Ollama for its version
+func (e Env) checkOllamaAPI(ctx context.Context) Result {
+	version, err := e.Settings.Client().Version(ctx)
+	if err != nil {
+		return fail(fmt.Sprintf("Start Ollama with 'launchctl kickstart -k gui/%d/%s', or run 'emrys bootstrap 2'", os.Getuid(), OllamaAgent),
+			"not reachable at %s: %v", e.Settings.Ollama.URL, err)
+	}
+	return pass("Ollama %s at %s", version, e.Settings.Ollama.URL)
+}
+
+// checkModels lists the installed models through /api/tags
+func (e Env) checkModels(ctx context.Context) Result {
+	models, err := e.Settings.Client().List(ctx)
+	if err != nil {
+		return fail("Check the Ollama API result above", "could not list models: %v", err)
+	}
+	if len(models) == 0 {
+		return warn(fmt.Sprintf("Run 'emrys model pull %s'", e.Settings.Models.Chat), "no models are installed")
+	}
+	return pass("%d installed", len(models))
+}
+
+// checkChatModel loads the configured chat model into memory
+func (e Env) checkChatModel(ctx context.Context) Result {
+	client := e.Settings.Client()
+	name := e.Settings.Models.Chat
+	models, err := client.List(ctx)
+	if err != nil {
+		return warn("Fix the Ollama API first", "%s was not checked because Ollama is not reachable", name)
+	}
+	installed := slices.ContainsFunc(models, func(m llm.Model) bool {
+		return m.Name == name || m.Name == name+":latest"
+	})
+	if !installed {
+		return fail(fmt.Sprintf("Run 'emrys model pull %s', or choose an installed model with 'emrys model use'", name),
+			"%s is not installed", name)
+	}
+	if err := client.Load(ctx, name); err != nil {
+		return fail("Check free memory, or choose a smaller model with 'emrys model use'", "%s did not load: %v", name, err)
+	}
+	return pass("%s loads", name)
+}
+
+// checkVoice looks for a working speech backend and the configured voice
+func (e Env) checkVoice(ctx context.Context) Result {
+	cfg := e.Settings.Voice
+	backend := e.Backend(cfg)
+	if !backend.Available() {
+		return fail("Run 'emrys bootstrap 3', or install espeak-ng or piper", "no text-to-speech backend is available")
+	}
+	if !cfg.Enabled {
+		return warn("Run 'emrys config set voice.enabled true' to turn it on", "voice output is disabled")
+	}
+	voices, err := backend.ListVoices(ctx)
+	if err != nil {
+		return warn("Run 'emrys voice test' to try it", "could not list %s voices: %v", backend.Name(), err)
+	}
+	if cfg.Voice != "" && !slices.Contains(voices, cfg.Voice) {
+		return warn("Run 'emrys voice voices' and choose one with 'emrys config set voice.voice <name>'",
+			"voice %s is not available from %s", cfg.Voice, backend.Name())
+	}
+	return pass("%s with the %s backend", cfg.Voice, backend.Name())
+}
+
+// checkLaunchAgents makes sure each launch agent is installed and loaded
+func (e Env) checkLaunchAgents(ctx context.Context) Result {
+	if _, err := e.LookPath("launchctl"); err != nil {
+		return warn("", "launchctl is not available; launch agents only run on macOS")
+	}
+
+	var problems, hints []string
+	for _, label := range e.LaunchAgents {
+		plist := filepath.Join(e.Home, "Library", "LaunchAgents", label+".plist")
+		if _, err := os.Stat(plist); err != nil {
+			problems = append(problems, fmt.Sprintf("%s is not installed", label))
+			hints = append(hints, "run 'emrys bootstrap'")
+			continue
+		}
+		if _, err := e.Command(ctx, "launchctl", "list", label); err != nil {
+			problems = append(problems, fmt.Sprintf("%s is not loaded", label))
+			hints = append(hints, fmt.Sprintf("run 'launchctl load -w %s'", plist))
+		}
+	}
+	if len(problems) > 0 {
+		hint := strings.Join(hints, "; ")
+		return fail(strings.ToUpper(hint[:1])+hint[1:], "%s", strings.Join(problems, "; "))
+	}
+	return pass("%s loaded", strings.Join(e.LaunchAgents, ", "))
+}
+
+// checkSSH checks the permissions sshd insists on for key-based login
+func (e Env) checkSSH(ctx context.Context) Result {
+	dir := filepath.Join(e.Home, ".ssh")
+	keys := filepath.Join(dir, "authorized_keys")
+
+	info, err := os.Stat(keys)
+	if errors.Is(err, os.ErrNotExist) {
+		return warn("Add your public key to "+keys+" to log in remotely", "no authorized keys; remote login is not set up")
+	}
+	if err != nil {
+		return fail("", "could not read %s: %v", keys, err)
+	}
+	if info.Size() == 0 {
+		return warn("Add your public key to "+keys+" to log in remotely", "%s is empty", keys)
+	}
+	if info.Mode().Perm()&0o022 != 0 {
+		return fail("Run 'chmod 600 "+keys+"'", "%s is writable by others, so sshd ignores it", keys)
+	}
+	if dirInfo, err := os.Stat(dir); err == nil && dirInfo.Mode().Perm()&0o022 != 0 {
+		return fail("Run 'chmod 700 "+dir+"'", "%s is writable by others, so sshd ignores its keys", dir)
+	}
+	return pass("%s is set up", keys)
+}
+
+// checkDiskSpace checks the free space in the home directory, where models
+// and caches live
+func (e Env) checkDiskSpace(ctx context.Context) Result {
+	free, err := e.FreeSpace(e.Home)
+	if err != nil {
+		return warn("", "could not measure free space: %v", err)
+	}
+	gb := float64(free) / (1 << 30)
+	switch {
+	case free < FailFreeBytes:
+		return fail("Free some space; Ollama models need several gigabytes each", "only %.1f GB free", gb)
+	case free < WarnFreeBytes:
+		return warn("Free some space before pulling more models", "%.1f GB free", gb)
+	}
+	return pass("%.1f GB free", gb)
+}
+
+// firstLine summarizes a failed command by the first line of its output
+func firstLine(output []byte, err error) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(string(output)), "\n")
+	if line == "" {
+		return err.Error()
+	}
+	return line
+}
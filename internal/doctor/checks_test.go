@@ -0,0 +1,194 @@
+package doctor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/anicolao/emrys/internal/config"
+	"github.com/anicolao/emrys/internal/voice"
+)
+
+// testEnv returns an Env for a healthy machine with Ollama served by handler
+func testEnv(t *testing.T, handler http.HandlerFunc) Env {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	home := t.TempDir()
+	settings := config.Default()
+	settings.Ollama.URL = server.URL
+	settings.Models.Chat = "llama3.2"
+	settings.Voice.Voice = "Jamie"
+
+	backend := voice.NewFakeBackend()
+	backend.Voices = []string{"Jamie"}
+	return Env{
+		Home:         home,
+		ConfigPath:   filepath.Join(home, "config.yaml"),
+		Settings:     settings,
+		LaunchAgents: []string{OllamaAgent},
+		LookPath:     func(file string) (string, error) { return "/usr/bin/" + file, nil },
+		Command:      func(ctx context.Context, name string, args ...string) ([]byte, error) { return nil, nil },
+		FreeSpace:    func(string) (uint64, error) { return 100 << 30, nil },
+		Backend:      func(voice.Config) voice.Backend { return backend },
+	}
+}
+
+// ollama serves the parts of the Ollama API that doctor uses
+func ollama(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/api/version":
+		fmt.Fprintln(w, `{"version":"0.5.4"}`)
+	case "/api/tags":
+		fmt.Fprintln(w, `{"models":[{"name":"llama3.2:latest"}]}`)
+	case "/api/generate":
+		fmt.Fprintln(w, `{"done":true}`)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// result runs the named standard check
+func result(t *testing.T, env Env, name string) Result {
+	t.Helper()
+	for _, check := range Checks(env) {
+		if check.Name == name {
+			return runCheck(context.Background(), check)
+		}
+	}
+	t.Fatalf("No check named %s", name)
+	return Result{}
+}
+
+func TestChecksHealthy(t *testing.T) {
+	env := testEnv(t, ollama)
+	keys := filepath.Join(env.Home, ".ssh", "authorized_keys")
+	os.MkdirAll(filepath.Dir(keys), 0700)
+	os.WriteFile(keys, []byte("ssh-ed25519 AAAA user@host\n"), 0600)
+	plist := filepath.Join(env.Home, "Library", "LaunchAgents", OllamaAgent+".plist")
+	os.MkdirAll(filepath.Dir(plist), 0755)
+	os.WriteFile(plist, []byte("<plist/>"), 0644)
+
+	report := Run(context.Background(), Checks(env))
+	for _, r := range report.Results {
+		if r.Status != Pass {
+			t.Errorf("Expected %s to pass, got %s: %s", r.Name, r.Status, r.Message)
+		}
+	}
+	if !report.OK {
+		t.Error("Expected the report to be OK")
+	}
+}
+
+func TestOllamaDown(t *testing.T) {
+	env := testEnv(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	})
+
+	if r := result(t, env, "Ollama API"); r.Status != Fail || r.Hint == "" {
+		t.Errorf("Expected Ollama API to fail with a hint, got %+v", r)
+	}
+	if r := result(t, env, "Chat model"); r.Status != Warn {
+		t.Errorf("Expected the chat model to be skipped with a warning, got %+v", r)
+	}
+}
+
+func TestChatModelMissing(t *testing.T) {
+	env := testEnv(t, ollama)
+	env.Settings.Models.Chat = "mistral"
+
+	r := result(t, env, "Chat model")
+	if r.Status != Fail || r.Hint != "Run 'emrys model pull mistral', or choose an installed model with 'emrys model use'" {
+		t.Errorf("Expected a failure suggesting a pull, got %+v", r)
+	}
+}
+
+func TestConfigInvalid(t *testing.T) {
+	env := testEnv(t, ollama)
+	os.WriteFile(env.ConfigPath, []byte("voice:\n  rate: fast\n"), 0644)
+
+	if r := result(t, env, "Configuration"); r.Status != Fail {
+		t.Errorf("Expected an invalid config to fail, got %+v", r)
+	}
+}
+
+func TestNixDaemonNotResponding(t *testing.T) {
+	env := testEnv(t, ollama)
+	env.Command = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		return []byte("error: cannot connect to socket\nmore detail\n"), errors.New("exit status 1")
+	}
+
+	r := result(t, env, "Nix daemon")
+	if r.Status != Fail || r.Message != "the daemon did not respond: error: cannot connect to socket" {
+		t.Errorf("Unexpected result: %+v", r)
+	}
+}
+
+func TestLaunchAgentNotLoaded(t *testing.T) {
+	env := testEnv(t, ollama)
+	plist := filepath.Join(env.Home, "Library", "LaunchAgents", OllamaAgent+".plist")
+	os.MkdirAll(filepath.Dir(plist), 0755)
+	os.WriteFile(plist, []byte("<plist/>"), 0644)
+	env.Command = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		return nil, errors.New("exit status 113")
+	}
+
+	r := result(t, env, "Launch agents")
+	if r.Status != Fail || r.Hint != "Run 'launchctl load -w "+plist+"'" {
+		t.Errorf("Unexpected result: %+v", r)
+	}
+}
+
+func TestSSHPermissions(t *testing.T) {
+	env := testEnv(t, ollama)
+	if r := result(t, env, "SSH"); r.Status != Warn {
+		t.Errorf("Expected a warning without authorized keys, got %+v", r)
+	}
+
+	keys := filepath.Join(env.Home, ".ssh", "authorized_keys")
+	os.MkdirAll(filepath.Dir(keys), 0700)
+	os.WriteFile(keys, []byte("ssh-ed25519 AAAA user@host\n"), 0600)
+	os.Chmod(keys, 0666)
+	if r := result(t, env, "SSH"); r.Status != Fail || r.Hint != "Run 'chmod 600 "+keys+"'" {
+		t.Errorf("Expected a permissions failure, got %+v", r)
+	}
+}
+
+func TestDiskSpace(t *testing.T) {
+	env := testEnv(t, ollama)
+	tests := []struct {
+		free uint64
+		want Status
+	}{
+		{100 << 30, Pass},
+		{10 << 30, Warn},
+		{1 << 30, Fail},
+	}
+	for _, tt := range tests {
+		env.FreeSpace = func(string) (uint64, error) { return tt.free, nil }
+		if r := result(t, env, "Disk space"); r.Status != tt.want {
+			t.Errorf("Expected %s for %d bytes, got %+v", tt.want, tt.free, r)
+		}
+	}
+}
+
+func TestVoice(t *testing.T) {
+	env := testEnv(t, ollama)
+	backend := voice.NewFakeBackend()
+	env.Backend = func(voice.Config) voice.Backend { return backend }
+
+	if r := result(t, env, "Voice output"); r.Status != Warn {
+		t.Errorf("Expected a warning for a missing voice, got %+v", r)
+	}
+
+	backend.Disabled = true
+	if r := result(t, env, "Voice output"); r.Status != Fail {
+		t.Errorf("Expected a failure without a backend, got %+v", r)
+	}
+}
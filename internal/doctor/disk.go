@@ -0,0 +1,15 @@
+package doctor
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// freeSpace returns the bytes available to the user on the volume holding path
+func freeSpace(path string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, fmt.Errorf("failed to stat filesystem: %w", err)
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
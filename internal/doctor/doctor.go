@@ -0,0 +1,145 @@
+// Package doctor checks that an Emrys installation is healthy and explains
+// how to fix what is not
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout bounds a check that does not set its own timeout
+const DefaultTimeout = 10 * time.Second
+
+// Status is the outcome of a check
+type Status string
+
+// Check outcomes, from best to worst
+const (
+	Pass Status = "pass"
+	Warn Status = "warn"
+	Fail Status = "fail"
+)
+
+// Check is one health check
+type Check struct {
+	Name    string
+	Timeout time.Duration // Defaults to DefaultTimeout
+	Run     func(ctx context.Context) Result
+}
+
+// Result is what a check found
+type Result struct {
+	Name    string `json:"name"`
+	Status  Status `json:"status"`
+	Message string `json:"message"`
+	Hint    string `json:"hint,omitempty"` // How to fix a warning or failure
+}
+
+// Report holds the results of every check, in the order the checks were given
+type Report struct {
+	OK      bool     `json:"ok"` // No check failed
+	Results []Result `json:"results"`
+}
+
+// pass reports a healthy check
+func pass(format string, args ...any) Result {
+	return Result{Status: Pass, Message: fmt.Sprintf(format, args...)}
+}
+
+// warn reports something that works but may cause trouble
+func warn(hint, format string, args ...any) Result {
+	return Result{Status: Warn, Message: fmt.Sprintf(format, args...), Hint: hint}
+}
+
+// fail reports something that is broken
+func fail(hint, format string, args ...any) Result {
+	return Result{Status: Fail, Message: fmt.Sprintf(format, args...), Hint: hint}
+}
+
+// Run runs the checks concurrently and collects their results
+func Run(ctx context.Context, checks []Check) Report {
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = runCheck(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{OK: true, Results: results}
+	for _, r := range results {
+		if r.Status == Fail {
+			report.OK = false
+		}
+	}
+	return report
+}
+
+// runCheck runs one check within its timeout, turning a panic into a failure
+func runCheck(ctx context.Context, check Check) Result {
+	timeout := check.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var result Result
+	done := make(chan Result, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fail("", "check crashed: %v", r)
+			}
+		}()
+		done <- check.Run(ctx)
+	}()
+	select {
+	case result = <-done:
+	case <-ctx.Done():
+		result = fail("", "timed out after %s", timeout)
+	}
+	result.Name = check.Name
+	return result
+}
+
+// Count returns how many results have the given status
+func (r Report) Count(status Status) int {
+	n := 0
+	for _, result := range r.Results {
+		if result.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// WriteText prints the report for a person to read
+func (r Report) WriteText(w io.Writer) {
+	for _, result := range r.Results {
+		fmt.Fprintf(w, "%s %s: %s\n", symbol(result.Status), result.Name, strings.ReplaceAll(result.Message, "\n", "\n  "))
+		if result.Hint != "" && result.Status != Pass {
+			fmt.Fprintf(w, "  → %s\n", result.Hint)
+		}
+	}
+	fmt.Fprintf(w, "\n%d passed, %d warnings, %d failed\n", r.Count(Pass), r.Count(Warn), r.Count(Fail))
+}
+
+// symbol marks a status in text output
+func symbol(status Status) string {
+	switch status {
+	case Pass:
+		return "✓"
+	case Warn:
+		return "!"
+	default:
+		return "✗"
+	}
+}
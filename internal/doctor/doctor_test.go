@@ -0,0 +1,70 @@
+package doctor
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunKeepsOrder(t *testing.T) {
+	checks := []Check{
+		{Name: "slow", Run: func(ctx context.Context) Result {
+			time.Sleep(20 * time.Millisecond)
+			return pass("done")
+		}},
+		{Name: "warning", Run: func(ctx context.Context) Result { return warn("fix it", "careful") }},
+	}
+
+	report := Run(context.Background(), checks)
+	if !report.OK {
+		t.Error("Expected warnings not to fail the report")
+	}
+	if len(report.Results) != 2 || report.Results[0].Name != "slow" || report.Results[1].Name != "warning" {
+		t.Fatalf("Expected results in check order, got %+v", report.Results)
+	}
+	if report.Results[1].Status != Warn || report.Results[1].Hint != "fix it" {
+		t.Errorf("Expected a warning with a hint, got %+v", report.Results[1])
+	}
+}
+
+func TestRunFailures(t *testing.T) {
+	checks := []Check{
+		{Name: "broken", Run: func(ctx context.Context) Result { return fail("repair it", "broken") }},
+		{Name: "crash", Run: func(ctx context.Context) Result { panic("boom") }},
+		{Name: "hang", Timeout: 10 * time.Millisecond, Run: func(ctx context.Context) Result {
+			<-ctx.Done()
+			time.Sleep(50 * time.Millisecond)
+			return pass("too late")
+		}},
+	}
+
+	report := Run(context.Background(), checks)
+	if report.OK {
+		t.Error("Expected the report to fail")
+	}
+	if report.Count(Fail) != 3 {
+		t.Errorf("Expected 3 failures, got %+v", report.Results)
+	}
+	if !strings.Contains(report.Results[1].Message, "boom") {
+		t.Errorf("Expected the panic in the message, got %q", report.Results[1].Message)
+	}
+	if !strings.Contains(report.Results[2].Message, "timed out") {
+		t.Errorf("Expected a timeout, got %q", report.Results[2].Message)
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	report := Report{Results: []Result{
+		{Name: "Disk space", Status: Pass, Message: "100.0 GB free"},
+		{Name: "Ollama API", Status: Fail, Message: "not reachable", Hint: "Start Ollama"},
+	}}
+
+	var buf bytes.Buffer
+	report.WriteText(&buf)
+	want := "✓ Disk space: 100.0 GB free\n✗ Ollama API: not reachable\n  → Start Ollama\n\n1 passed, 0 warnings, 1 failed\n"
+	if buf.String() != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, buf.String())
+	}
+}
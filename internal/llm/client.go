@@ -0,0 +1,308 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultURL is the default address of the local Ollama API
+const DefaultURL = "http://localhost:11434"
+
+// ErrNotFound is returned when Ollama answers 404, because the server
+// does not serve the endpoint or does not have the model
+var ErrNotFound = errors.New("not found")
+
+// Message roles understood by /api/chat
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+	RoleTool      = "tool"
+)
+
+// Message is a single chat message in the Ollama /api/chat format
+type Message struct {
+	Role      string     `json:"role"`
+	Content   string     `json:"content"`
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"` // Tool calls requested by the assistant
+	ToolName  string     `json:"tool_name,omitempty"`  // Tool that produced a RoleTool message
+}
+
+// ToolCall is a function call requested by the model
+type ToolCall struct {
+	Function ToolCallFunction `json:"function"`
+}
+
+// ToolCallFunction names the function to call and carries its JSON arguments
+type ToolCallFunction struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+// ToolSpec describes a tool the model may call, in Ollama's tools format
+type ToolSpec struct {
+	Type     string       `json:"type"`
+	Function ToolFunction `json:"function"`
+}
+
+// ToolFunction describes a callable function with a JSON-schema for its parameters
+type ToolFunction struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Parameters  json.RawMessage `json:"parameters"`
+}
+
+// ChatRequest is the body of an /api/chat request
+type ChatRequest struct {
+	Model    string                 `json:"model"`
+	Messages []Message              `json:"messages"`
+	Tools    []ToolSpec             `json:"tools,omitempty"`
+	Stream   bool                   `json:"stream"`
+	Options  map[string]interface{} `json:"options,omitempty"`
+}
+
+// ChatResponse is a (possibly partial) /api/chat response
+type ChatResponse struct {
+	Model           string  `json:"model"`
+	Message         Message `json:"message"`
+	Done            bool    `json:"done"`
+	DoneReason      string  `json:"done_reason,omitempty"`
+	PromptEvalCount int     `json:"prompt_eval_count,omitempty"`
+	EvalCount       int     `json:"eval_count,omitempty"`
+}
+
+// ShowResponse holds the parts of /api/show that Emrys uses
+type ShowResponse struct {
+	Parameters string                 `json:"parameters"`
+	ModelInfo  map[string]interface{} `json:"model_info"`
+}
+
+// Model describes an installed model, as listed by /api/tags
+type Model struct {
+	Name       string    `json:"name"`
+	Size       int64     `json:"size"`
+	ModifiedAt time.Time `json:"modified_at"`
+}
+
+// Client talks to an Ollama server over HTTP
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// NewClient creates a new Ollama client for the given base URL
+func NewClient(baseURL string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultURL
+	}
+	return &Client{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		// Generation can take minutes on slower hardware; callers bound
+		// individual requests with their context instead
+		HTTPClient: &http.Client{Timeout: 10 * time.Minute},
+	}
+}
+
+// Chat sends a non-streaming chat request and returns the complete response
+func (c *Client) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	req.Stream = false
+
+	resp, err := c.post(ctx, "/api/chat", req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result ChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to parse chat response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// ChatStream sends a streaming chat request, calling fn for every chunk as it
+// arrives. It returns the final chunk with the full message content assembled.
+func (c *Client) ChatStream(ctx context.Context, req ChatRequest, fn func(ChatResponse) error) (*ChatResponse, error) {
+	req.Stream = true
+
+	resp, err := c.post(ctx, "/api/chat", req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var content strings.Builder
+	var toolCalls []ToolCall
+	var last ChatResponse
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var chunk ChatResponse
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return nil, fmt.Errorf("failed to parse chat stream: %w", err)
+		}
+
+		content.WriteString(chunk.Message.Content)
+		toolCalls = append(toolCalls, chunk.Message.ToolCalls...)
+		if fn != nil {
+			if err := fn(chunk); err != nil {
+				return nil, err
+			}
+		}
+
+		last = chunk
+		if chunk.Done {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read chat stream: %w", err)
+	}
+
+	last.Message.Role = RoleAssistant
+	last.Message.Content = content.String()
+	last.Message.ToolCalls = toolCalls
+	return &last, nil
+}
+
+// Show returns model metadata from /api/show
+func (c *Client) Show(ctx context.Context, model string) (*ShowResponse, error) {
+	resp, err := c.post(ctx, "/api/show", map[string]string{"model": model})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result ShowResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to parse show response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// List returns the installed models from /api/tags
+func (c *Client) List(ctx context.Context) ([]Model, error) {
+	resp, err := c.get(ctx, "/api/tags")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Models []Model `json:"models"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to parse tags response: %w", err)
+	}
+
+	return result.Models, nil
+}
+
+// Version returns the server version from /api/version
+func (c *Client) Version(ctx context.Context) (string, error) {
+	resp, err := c.get(ctx, "/api/version")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Version string `json:"version"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to parse version response: %w", err)
+	}
+
+	return result.Version, nil
+}
+
+// Load loads a model into memory without generating anything, which shows
+// that the model is installed and fits
+func (c *Client) Load(ctx context.Context, model string) error {
+	resp, err := c.post(ctx, "/api/generate", map[string]interface{}{"model": model, "stream": false})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Tokenize returns the tokens for text using the model's tokenizer.
+// Not every Ollama build exposes /api/tokenize, so callers should be
+// prepared to fall back to an approximation when this fails.
+func (c *Client) Tokenize(ctx context.Context, model, text string) ([]int, error) {
+	resp, err := c.post(ctx, "/api/tokenize", map[string]string{"model": model, "content": text})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Tokens []int `json:"tokens"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to parse tokenize response: %w", err)
+	}
+
+	return result.Tokens, nil
+}
+
+// get fetches the given API path and checks the status code
+func (c *Client) get(ctx context.Context, path string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	return c.do(req, path)
+}
+
+// post sends a JSON body to the given API path and checks the status code
+func (c *Client) post(ctx context.Context, path string, body interface{}) (*http.Response, error) {
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+path, bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return c.do(req, path)
+}
+
+// do sends a request and checks the status code
+func (c *Client) do(req *http.Request, path string) (*http.Response, error) {
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request to %s failed: %w", path, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		err := fmt.Errorf("%s returned status %d: %s", path, resp.StatusCode, strings.TrimSpace(string(data)))
+		if resp.StatusCode == http.StatusNotFound {
+			err = fmt.Errorf("%w: %w", ErrNotFound, err)
+		}
+		return nil, err
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,185 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestChat(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			http.NotFound(w, r)
+			return
+		}
+
+		var req ChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		if req.Stream {
+			t.Error("Expected non-streaming request")
+		}
+
+		json.NewEncoder(w).Encode(ChatResponse{
+			Model:   req.Model,
+			Message: Message{Role: RoleAssistant, Content: "hello"},
+			Done:    true,
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	resp, err := client.Chat(context.Background(), ChatRequest{
+		Model:    "test",
+		Messages: []Message{{Role: RoleUser, Content: "hi"}},
+	})
+	if err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+
+	if resp.Message.Content != "hello" {
+		t.Errorf("Expected content 'hello', got '%s'", resp.Message.Content)
+	}
+}
+
+func TestChatStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, word := range []string{"Hello", " there", "!"} {
+			fmt.Fprintf(w, `{"message":{"role":"assistant","content":%q},"done":false}`+"\n", word)
+		}
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":""},"done":true,"eval_count":3}`)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+
+	var chunks []string
+	resp, err := client.ChatStream(context.Background(), ChatRequest{Model: "test"}, func(chunk ChatResponse) error {
+		chunks = append(chunks, chunk.Message.Content)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ChatStream failed: %v", err)
+	}
+
+	if len(chunks) != 4 {
+		t.Errorf("Expected 4 chunks, got %d", len(chunks))
+	}
+	if resp.Message.Content != "Hello there!" {
+		t.Errorf("Expected assembled content 'Hello there!', got '%s'", resp.Message.Content)
+	}
+	if resp.EvalCount != 3 {
+		t.Errorf("Expected eval count 3, got %d", resp.EvalCount)
+	}
+}
+
+func TestChatErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "model not found", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	_, err := client.Chat(context.Background(), ChatRequest{Model: "missing"})
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a 404, got %v", err)
+	}
+}
+
+func TestList(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/api/tags" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintln(w, `{"models":[{"name":"llama3.2:latest","size":2019393189,"modified_at":"2025-01-06T12:00:00Z"}]}`)
+	}))
+	defer server.Close()
+
+	models, err := NewClient(server.URL).List(context.Background())
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(models) != 1 || models[0].Name != "llama3.2:latest" || models[0].Size != 2019393189 {
+		t.Errorf("Unexpected models: %+v", models)
+	}
+}
+
+func TestVersion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/version" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintln(w, `{"version":"0.5.4"}`)
+	}))
+	defer server.Close()
+
+	version, err := NewClient(server.URL).Version(context.Background())
+	if err != nil {
+		t.Fatalf("Version failed: %v", err)
+	}
+	if version != "0.5.4" {
+		t.Errorf("Expected 0.5.4, got %q", version)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&req)
+		if r.URL.Path != "/api/generate" || req["prompt"] != nil {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		if req["model"] != "llama3.2" {
+			http.Error(w, `{"error":"model 'missing' not found"}`, http.StatusNotFound)
+			return
+		}
+		fmt.Fprintln(w, `{"model":"llama3.2","response":"","done":true}`)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	if err := client.Load(context.Background(), "llama3.2"); err != nil {
+		t.Errorf("Load failed: %v", err)
+	}
+	if err := client.Load(context.Background(), "missing"); err == nil {
+		t.Error("Expected error for a missing model")
+	}
+}
+
+func TestNumCtx(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(ShowResponse{
+			Parameters: "stop                           \"<|eot_id|>\"\nnum_ctx                        8192",
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	if n, err := client.NumCtx(context.Background(), "test"); err != nil || n != 8192 {
+		t.Errorf("Expected num_ctx 8192, got %d (%v)", n, err)
+	}
+}
+
+func TestNumCtxDefault(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	n, err := client.NumCtx(context.Background(), "test")
+	if n != DefaultNumCtx {
+		t.Errorf("Expected default num_ctx %d, got %d", DefaultNumCtx, n)
+	}
+	if err == nil {
+		t.Error("Expected the failure to be reported with the fallback")
+	}
+}
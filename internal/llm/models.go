@@ -0,0 +1,61 @@
+package llm
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+// DefaultModel is the model used for every role unless configured otherwise
+const DefaultModel = "llama3.2"
+
+// DefaultNumCtx is the context window Ollama uses when a model does not set num_ctx
+const DefaultNumCtx = 2048
+
+// Roles maps the jobs Emrys gives to language models onto model names
+type Roles struct {
+	Chat      string // Model used for conversation with the user
+	Summarize string // Model used to fold old turns into summaries
+}
+
+// DefaultRoles returns roles that use DefaultModel for everything
+func DefaultRoles() Roles {
+	return Roles{
+		Chat:      DefaultModel,
+		Summarize: DefaultModel,
+	}
+}
+
+// NumCtx returns the context window size Ollama will use for a model.
+// It reads num_ctx from the model's Modelfile parameters; a model without
+// one gets DefaultNumCtx, since Ollama does not use the model's full
+// trained context unless num_ctx is raised explicitly. If the model cannot
+// be shown it returns DefaultNumCtx along with the error, so callers can
+// carry on with the fallback without taking it for the model's own size.
+func (c *Client) NumCtx(ctx context.Context, model string) (int, error) {
+	info, err := c.Show(ctx, model)
+	if err != nil {
+		return DefaultNumCtx, err
+	}
+
+	if n := parseNumCtx(info.Parameters); n > 0 {
+		return n, nil
+	}
+
+	return DefaultNumCtx, nil
+}
+
+// parseNumCtx extracts num_ctx from the parameters block of /api/show
+// Parameters are formatted one per line as "name    value"
+func parseNumCtx(parameters string) int {
+	for _, line := range strings.Split(parameters, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "num_ctx" {
+			n, err := strconv.Atoi(fields[1])
+			if err == nil {
+				return n
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,274 @@
+// Package logging sets up Emrys's logs: leveled, component-tagged slog
+// records written as JSON to a rotating file, and the same messages shown
+// plainly on the terminal
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// ComponentKey is the attribute naming the subsystem that logged a record
+const ComponentKey = "component"
+
+// Component returns a logger whose records name the given subsystem
+func Component(logger *slog.Logger, name string) *slog.Logger {
+	return logger.With(ComponentKey, name)
+}
+
+// ParseLevel converts "debug", "info", "warn" or "error" to a level
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return 0, fmt.Errorf("unknown log level %q", name)
+	}
+	return level, nil
+}
+
+// LayoutKey is the attribute that asks the console to set a record apart
+// from the lines around it; the log file leaves it out
+const LayoutKey = "layout"
+
+// Layouts for progress output, passed as an attribute such as
+// logger.Info("Phase 2: Ollama Setup", logging.Banner)
+var (
+	Banner = slog.String(LayoutKey, "banner") // Title drawn between rules
+	Break  = slog.String(LayoutKey, "break")  // Starts a paragraph after a blank line
+)
+
+// rule is drawn above and below a banner
+const rule = "═══════════════════════════════════════"
+
+// ConsoleHandler shows records the way Emrys has always printed progress:
+// the message followed by its attributes, warnings marked with ⚠ and
+// errors prefixed and sent to a separate writer. A message can name
+// attributes in braces, as in "✓ Model {model} downloaded", to show them as
+// part of the sentence; the log file keeps the message as written. The
+// component is for the log file and only shown in debug records. Blank
+// lines come from layouts rather than records of their own.
+type ConsoleHandler struct {
+	out, errOut io.Writer
+	level       slog.Leveler
+	attrs       []slog.Attr
+	mu          *sync.Mutex
+	blank       *bool // out ends in a blank line, or nothing was written yet
+}
+
+// NewConsoleHandler returns a handler that writes info, debug and warning
+// messages to out and errors to errOut
+func NewConsoleHandler(out, errOut io.Writer, level slog.Leveler) *ConsoleHandler {
+	blank := true
+	return &ConsoleHandler{out: out, errOut: errOut, level: level, mu: &sync.Mutex{}, blank: &blank}
+}
+
+// Enabled reports whether level is at or above the handler's level
+func (h *ConsoleHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+// Handle writes one record
+func (h *ConsoleHandler) Handle(_ context.Context, r slog.Record) error {
+	var line strings.Builder
+	w := h.out
+	switch {
+	case r.Level >= slog.LevelError:
+		w = h.errOut
+		line.WriteString("Error: ")
+	case r.Level >= slog.LevelWarn:
+		line.WriteString("⚠ ")
+	}
+
+	attrs := append([]slog.Attr(nil), h.attrs...)
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	message, used := fill(r.Message, attrs)
+	line.WriteString(message)
+
+	var layout string
+	var shown []slog.Attr
+	for _, a := range attrs {
+		switch {
+		case used[a.Key]:
+		case a.Key == LayoutKey:
+			layout = a.Value.String()
+		case r.Level >= slog.LevelWarn && a.Key == "error":
+			fmt.Fprintf(&line, ": %v", a.Value)
+		case a.Key == ComponentKey && r.Level >= slog.LevelInfo:
+		default:
+			shown = append(shown, a)
+		}
+	}
+	for _, a := range shown {
+		fmt.Fprintf(&line, " %s=%s", a.Key, quote(a.Value.String()))
+	}
+	line.WriteString("\n")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if w != h.out {
+		_, err := io.WriteString(w, line.String())
+		return err
+	}
+
+	text := line.String()
+	if layout == "banner" {
+		text = rule + "\n" + text + rule + "\n\n"
+	}
+	if layout != "" && !*h.blank {
+		text = "\n" + text
+	}
+	*h.blank = strings.HasSuffix(text, "\n\n")
+	_, err := io.WriteString(w, text)
+	return err
+}
+
+// fill replaces each {key} in message with the value of the attribute
+// named key and reports the keys it used. Braces naming no attribute are
+// left alone.
+func fill(message string, attrs []slog.Attr) (string, map[string]bool) {
+	if !strings.Contains(message, "{") {
+		return message, nil
+	}
+	values := make(map[string]string)
+	for _, a := range attrs {
+		values[a.Key] = a.Value.String()
+	}
+
+	var out strings.Builder
+	used := make(map[string]bool)
+	for {
+		start := strings.IndexByte(message, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(message[start:], '}')
+		if end < 0 {
+			break
+		}
+		end += start
+		key := message[start+1 : end]
+		value, ok := values[key]
+		if !ok {
+			out.WriteString(message[:end+1])
+			message = message[end+1:]
+			continue
+		}
+		out.WriteString(message[:start])
+		out.WriteString(value)
+		used[key] = true
+		message = message[end+1:]
+	}
+	out.WriteString(message)
+	return out.String(), used
+}
+
+// quote quotes s if it would not read as one value
+func quote(s string) string {
+	if s == "" || strings.ContainsFunc(s, unicode.IsSpace) || strings.ContainsRune(s, '"') {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// WithAttrs returns a handler that includes attrs in every record
+func (h *ConsoleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.attrs = append(append([]slog.Attr(nil), h.attrs...), attrs...)
+	return &h2
+}
+
+// WithGroup returns the handler unchanged; the console does not show groups
+func (h *ConsoleHandler) WithGroup(string) slog.Handler {
+	return h
+}
+
+// NewFileHandler returns a handler that writes JSON records to w, leaving
+// out console layouts
+func NewFileHandler(w io.Writer, level slog.Leveler) slog.Handler {
+	return fileHandler{slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})}
+}
+
+// fileHandler drops layout attributes before writing JSON
+type fileHandler struct {
+	slog.Handler
+}
+
+// Handle writes the record without its layout
+func (h fileHandler) Handle(ctx context.Context, r slog.Record) error {
+	plain := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key != LayoutKey {
+			plain.AddAttrs(a)
+		}
+		return true
+	})
+	return h.Handler.Handle(ctx, plain)
+}
+
+// WithAttrs keeps the filter on the derived handler
+func (h fileHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return fileHandler{h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup keeps the filter on the derived handler
+func (h fileHandler) WithGroup(name string) slog.Handler {
+	return fileHandler{h.Handler.WithGroup(name)}
+}
+
+// Tee returns a handler that passes every record to each of handlers
+func Tee(handlers ...slog.Handler) slog.Handler {
+	return tee(handlers)
+}
+
+// tee fans records out to several handlers
+type tee []slog.Handler
+
+// Enabled reports whether any handler wants records at level
+func (t tee) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range t {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+// Handle passes r to each handler that wants it
+func (t tee) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, h := range t {
+		if h.Enabled(ctx, r.Level) {
+			if err := h.Handle(ctx, r.Clone()); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// WithAttrs adds attrs to every handler
+func (t tee) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(tee, len(t))
+	for i, h := range t {
+		handlers[i] = h.WithAttrs(attrs)
+	}
+	return handlers
+}
+
+// WithGroup opens a group in every handler
+func (t tee) WithGroup(name string) slog.Handler {
+	handlers := make(tee, len(t))
+	for i, h := range t {
+		handlers[i] = h.WithGroup(name)
+	}
+	return handlers
+}
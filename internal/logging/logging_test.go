@@ -0,0 +1,133 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestConsoleHandler(t *testing.T) {
+	var out, errOut bytes.Buffer
+	logger := Component(slog.New(NewConsoleHandler(&out, &errOut, slog.LevelInfo)), "bootstrap")
+
+	logger.Info("✓ Created launch agent", "path", "/tmp/x.plist")
+	logger.Warn("Voice test failed", "error", errors.New("no backend"), "voice", "Jamie Premium")
+	logger.Error("Phase 2 failed", "error", errors.New("no Ollama"))
+	logger.Debug("hidden")
+
+	want := "✓ Created launch agent path=/tmp/x.plist\n⚠ Voice test failed: no backend voice=\"Jamie Premium\"\n"
+	if out.String() != want {
+		t.Errorf("Expected %q, got %q", want, out.String())
+	}
+	if errOut.String() != "Error: Phase 2 failed: no Ollama\n" {
+		t.Errorf("Expected the error with its cause, got %q", errOut.String())
+	}
+}
+
+func TestConsoleHandlerTemplate(t *testing.T) {
+	var console, file bytes.Buffer
+	logger := slog.New(Tee(
+		NewConsoleHandler(&console, &console, slog.LevelInfo),
+		NewFileHandler(&file, slog.LevelInfo),
+	))
+
+	logger.Info("✓ Model {model} downloaded successfully", "model", "llama3.2", "seconds", 12)
+	logger.Info("Ollama is running at {url}", "url", "http://localhost:11434", "model", "llama3.2")
+	logger.Info("Unknown {name} and {unclosed", "model", "x")
+	logger.Warn("Could not open {app}", "app", "VoiceOver Utility", "error", errors.New("denied"))
+
+	want := "✓ Model llama3.2 downloaded successfully seconds=12\n" +
+		"Ollama is running at http://localhost:11434 model=llama3.2\n" +
+		"Unknown {name} and {unclosed model=x\n" +
+		"⚠ Could not open VoiceOver Utility: denied\n"
+	if console.String() != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, console.String())
+	}
+
+	// The file keeps the constant message with its attributes
+	var record map[string]any
+	if err := json.Unmarshal([]byte(strings.SplitN(file.String(), "\n", 2)[0]), &record); err != nil {
+		t.Fatalf("Expected JSON, got %q", file.String())
+	}
+	if record["msg"] != "✓ Model {model} downloaded successfully" || record["model"] != "llama3.2" {
+		t.Errorf("Unexpected record %v", record)
+	}
+}
+
+func TestConsoleHandlerDebug(t *testing.T) {
+	var out bytes.Buffer
+	logger := Component(slog.New(NewConsoleHandler(&out, &out, slog.LevelDebug)), "voice")
+
+	logger.Debug("cache hit", "text", "hello")
+	if out.String() != "cache hit component=voice text=hello\n" {
+		t.Errorf("Expected debug attributes, got %q", out.String())
+	}
+}
+
+func TestConsoleHandlerLayout(t *testing.T) {
+	var out bytes.Buffer
+	logger := slog.New(NewConsoleHandler(&out, &out, slog.LevelInfo))
+
+	logger.Info("Phase 2: Ollama Setup", Banner)
+	logger.Info("Step 1: Starting Ollama service...", Break)
+	logger.Info("✓ Ollama service is already running")
+	logger.Info("Step 2: Testing Ollama API...", Break)
+	logger.Info("✓ Phase 2 Bootstrap Complete!", Banner)
+	logger.Info("Ollama is running", "url", "http://localhost:11434")
+
+	want := rule + "\nPhase 2: Ollama Setup\n" + rule + "\n\n" +
+		"Step 1: Starting Ollama service...\n✓ Ollama service is already running\n\n" +
+		"Step 2: Testing Ollama API...\n\n" +
+		rule + "\n✓ Phase 2 Bootstrap Complete!\n" + rule + "\n\n" +
+		"Ollama is running url=http://localhost:11434\n"
+	if out.String() != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, out.String())
+	}
+}
+
+func TestFileHandlerAndTee(t *testing.T) {
+	var console, file bytes.Buffer
+	logger := slog.New(Tee(
+		NewConsoleHandler(&console, &console, slog.LevelInfo),
+		NewFileHandler(&file, slog.LevelDebug),
+	))
+	logger = Component(logger, "nixdarwin")
+
+	logger.Info("Applying nix-darwin configuration", Break)
+	logger.Debug("running", "args", "darwin-rebuild switch")
+	logger.Info("✓ Configuration applied successfully")
+
+	if console.String() != "Applying nix-darwin configuration\n✓ Configuration applied successfully\n" {
+		t.Errorf("Unexpected console output %q", console.String())
+	}
+
+	lines := strings.Split(strings.TrimSpace(file.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected every record in the file, got %q", file.String())
+	}
+	var record map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatalf("Expected JSON, got %q", lines[0])
+	}
+	if _, ok := record[LayoutKey]; ok || record["component"] != "nixdarwin" {
+		t.Errorf("Expected the layout to be left out, got %v", record)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &record); err != nil {
+		t.Fatalf("Expected JSON, got %q", lines[1])
+	}
+	if record["component"] != "nixdarwin" || record["level"] != "DEBUG" || record["args"] != "darwin-rebuild switch" {
+		t.Errorf("Unexpected record %v", record)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	if level, err := ParseLevel("warn"); err != nil || level != slog.LevelWarn {
+		t.Errorf("Expected warn, got %v, %v", level, err)
+	}
+	if _, err := ParseLevel("loud"); err == nil {
+		t.Error("Expected an error for an unknown level")
+	}
+}
@@ -0,0 +1,169 @@
+package logging
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileOptions limits how much a RotatingFile keeps
+type FileOptions struct {
+	MaxSize  int64         // Bytes at which the file is rotated (0: never)
+	MaxAge   time.Duration // Rotated files older than this are removed (0: keep them)
+	Compress bool          // Gzip rotated files
+}
+
+// rotatedTime names a rotated file; it sorts in time order
+const rotatedTime = "20060102-150405.000"
+
+// RotatingFile is an append-only log file that moves itself aside when it
+// grows past MaxSize. Rotated files sit next to it as name-<time>.log, or
+// name-<time>.log.gz when compressed.
+type RotatingFile struct {
+	path string
+	opts FileOptions
+	now  func() time.Time
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// OpenFile opens path for appending, creating parent directories, and
+// removes rotated files older than opts.MaxAge
+func OpenFile(path string, opts FileOptions) (*RotatingFile, error) {
+	r := &RotatingFile{path: path, opts: opts, now: time.Now}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	r.prune()
+	return r, nil
+}
+
+// open opens the current file and records its size
+func (r *RotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+	r.file = f
+	r.size = info.Size()
+	return nil
+}
+
+// Write appends p, rotating first if p would take the file past MaxSize
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return 0, os.ErrClosed
+	}
+	if r.opts.MaxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.opts.MaxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// rotate moves the current file aside and starts a new one
+func (r *RotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+	r.file = nil
+
+	ext := filepath.Ext(r.path)
+	rotated := strings.TrimSuffix(r.path, ext) + "-" + r.now().UTC().Format(rotatedTime) + ext
+	if err := os.Rename(r.path, rotated); err != nil {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+	if err := r.open(); err != nil {
+		return err
+	}
+
+	if r.opts.Compress {
+		// A failed compression leaves the rotated file as it is
+		compress(rotated)
+	}
+	r.prune()
+	return nil
+}
+
+// compress gzips path and removes the original
+func compress(path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(path+".gz", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(out)
+	if _, err := io.Copy(gz, in); err != nil {
+		out.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		out.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(path + ".gz")
+		return err
+	}
+	return os.Remove(path)
+}
+
+// Rotated returns the rotated files of the log, oldest first
+func (r *RotatingFile) Rotated() []string {
+	ext := filepath.Ext(r.path)
+	matches, _ := filepath.Glob(strings.TrimSuffix(r.path, ext) + "-*" + ext + "*")
+	return matches
+}
+
+// prune removes rotated files older than MaxAge
+func (r *RotatingFile) prune() {
+	if r.opts.MaxAge <= 0 {
+		return
+	}
+	cutoff := r.now().Add(-r.opts.MaxAge)
+	for _, path := range r.Rotated() {
+		if info, err := os.Stat(path); err == nil && info.ModTime().Before(cutoff) {
+			os.Remove(path)
+		}
+	}
+}
+
+// Close closes the current file
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
@@ -0,0 +1,85 @@
+package logging
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotatingFileRotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "emrys.log")
+	f, err := OpenFile(path, FileOptions{MaxSize: 10})
+	if err != nil {
+		t.Fatalf("OpenFile failed: %v", err)
+	}
+	defer f.Close()
+	now := time.Date(2025, 1, 6, 12, 0, 0, 0, time.UTC)
+	f.now = func() time.Time { return now }
+
+	f.Write([]byte("12345678\n"))
+	f.Write([]byte("abc\n"))
+
+	rotated := f.Rotated()
+	if len(rotated) != 1 || filepath.Base(rotated[0]) != "emrys-20250106-120000.000.log" {
+		t.Fatalf("Expected one rotated file, got %v", rotated)
+	}
+	if data, _ := os.ReadFile(rotated[0]); string(data) != "12345678\n" {
+		t.Errorf("Expected the old content in the rotated file, got %q", data)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "abc\n" {
+		t.Errorf("Expected the new content in the log, got %q", data)
+	}
+}
+
+func TestRotatingFileCompresses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "emrys.log")
+	f, err := OpenFile(path, FileOptions{MaxSize: 10, Compress: true})
+	if err != nil {
+		t.Fatalf("OpenFile failed: %v", err)
+	}
+	defer f.Close()
+
+	f.Write([]byte("12345678\n"))
+	f.Write([]byte("abc\n"))
+
+	rotated := f.Rotated()
+	if len(rotated) != 1 || !strings.HasSuffix(rotated[0], ".log.gz") {
+		t.Fatalf("Expected one compressed file, got %v", rotated)
+	}
+	in, _ := os.Open(rotated[0])
+	defer in.Close()
+	gz, err := gzip.NewReader(in)
+	if err != nil {
+		t.Fatalf("Rotated file is not gzipped: %v", err)
+	}
+	if data, _ := io.ReadAll(gz); string(data) != "12345678\n" {
+		t.Errorf("Expected the old content, got %q", data)
+	}
+}
+
+func TestRotatingFilePrunes(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "emrys.log")
+	old := filepath.Join(dir, "emrys-20240101-000000.000.log.gz")
+	recent := filepath.Join(dir, "emrys-20250105-000000.000.log.gz")
+	os.WriteFile(old, nil, 0600)
+	os.WriteFile(recent, nil, 0600)
+	os.Chtimes(old, time.Now().Add(-60*24*time.Hour), time.Now().Add(-60*24*time.Hour))
+
+	f, err := OpenFile(path, FileOptions{MaxAge: 30 * 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("OpenFile failed: %v", err)
+	}
+	defer f.Close()
+
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Error("Expected the old log to be removed")
+	}
+	if _, err := os.Stat(recent); err != nil {
+		t.Error("Expected the recent log to be kept")
+	}
+}
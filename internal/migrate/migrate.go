@@ -0,0 +1,169 @@
+// Package migrate upgrades Emrys's persisted files from the formats written
+// by older versions
+package migrate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Migration upgrades a file from Version-1 to Version
+type Migration struct {
+	Version     int
+	Description string
+	Apply       func(data []byte) ([]byte, error)
+}
+
+// Format describes a versioned file format and the migrations that bring
+// older files up to date. Files without a version field are version 0.
+type Format struct {
+	Name       string
+	Current    int                            // Version this build of Emrys writes
+	Version    func(data []byte) (int, error) // Reads the version of a file
+	Migrations []Migration                    // In order of Version
+}
+
+// Pending returns the migrations that upgrade a file at version to Current
+func (f Format) Pending(version int) ([]Migration, error) {
+	if version > f.Current {
+		return nil, fmt.Errorf("%s version %d is newer than this version of Emrys supports (%d)", f.Name, version, f.Current)
+	}
+
+	var pending []Migration
+	next := version + 1
+	for _, m := range f.Migrations {
+		if m.Version <= version {
+			continue
+		}
+		if m.Version != next || m.Version > f.Current {
+			break
+		}
+		pending = append(pending, m)
+		next++
+	}
+	if next != f.Current+1 {
+		return nil, fmt.Errorf("%s has no migration from version %d to %d", f.Name, next-1, next)
+	}
+	return pending, nil
+}
+
+// Migrate applies the pending migrations to data and returns the result
+// along with the migrations applied
+func (f Format) Migrate(data []byte) ([]byte, []Migration, error) {
+	version, err := f.Version(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s version: %w", f.Name, err)
+	}
+	pending, err := f.Pending(version)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, m := range pending {
+		if data, err = m.Apply(data); err != nil {
+			return nil, nil, fmt.Errorf("failed to migrate %s to version %d: %w", f.Name, m.Version, err)
+		}
+	}
+	return data, pending, nil
+}
+
+// File is a versioned file on disk
+type File struct {
+	Format
+	Path string
+}
+
+// Status describes whether a file needs migrating
+type Status struct {
+	File    File
+	Exists  bool
+	Version int
+	Pending []Migration
+	Backup  string // Copy of the file before migrating, once migrated
+}
+
+// Check reports the migrations a file needs without changing it
+func Check(f File) (Status, error) {
+	status := Status{File: f}
+	data, err := os.ReadFile(f.Path)
+	if os.IsNotExist(err) {
+		return status, nil
+	}
+	if err != nil {
+		return status, fmt.Errorf("failed to read %s: %w", f.Name, err)
+	}
+	status.Exists = true
+
+	if status.Version, err = f.Version(data); err != nil {
+		return status, fmt.Errorf("failed to read %s version: %w", f.Name, err)
+	}
+	if status.Pending, err = f.Pending(status.Version); err != nil {
+		return status, err
+	}
+	return status, nil
+}
+
+// Apply migrates a file in place, first copying it to a backup named after
+// its old version and the time
+func Apply(f File, now time.Time) (Status, error) {
+	status, err := Check(f)
+	if err != nil || len(status.Pending) == 0 {
+		return status, err
+	}
+
+	data, err := os.ReadFile(f.Path)
+	if err != nil {
+		return status, fmt.Errorf("failed to read %s: %w", f.Name, err)
+	}
+	migrated, _, err := f.Migrate(data)
+	if err != nil {
+		return status, err
+	}
+
+	info, err := os.Stat(f.Path)
+	if err != nil {
+		return status, fmt.Errorf("failed to read %s: %w", f.Name, err)
+	}
+	backup := fmt.Sprintf("%s.v%d-%s.bak", f.Path, status.Version, now.Format("20060102-150405"))
+	if err := os.WriteFile(backup, data, info.Mode().Perm()); err != nil {
+		return status, fmt.Errorf("failed to back up %s: %w", f.Name, err)
+	}
+	status.Backup = backup
+
+	if err := replace(f.Path, migrated, info.Mode().Perm()); err != nil {
+		return status, fmt.Errorf("failed to write %s: %w", f.Name, err)
+	}
+	return status, nil
+}
+
+// replace writes data to path via a temporary file in the same directory
+func replace(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Describe summarizes the pending migrations, one per line
+func (s Status) Describe() string {
+	var lines []string
+	for _, m := range s.Pending {
+		lines = append(lines, fmt.Sprintf("v%d: %s", m.Version, m.Description))
+	}
+	return strings.Join(lines, "\n")
+}
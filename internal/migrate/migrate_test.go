@@ -0,0 +1,158 @@
+package migrate
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testFormat stores its version on the first line as "vN" and appends the
+// name of each migration applied
+var testFormat = Format{
+	Name:    "test file",
+	Current: 3,
+	Version: func(data []byte) (int, error) {
+		first, _, _ := strings.Cut(string(data), "\n")
+		if !strings.HasPrefix(first, "v") {
+			return 0, nil
+		}
+		return strconv.Atoi(first[1:])
+	},
+	Migrations: []Migration{
+		{Version: 1, Description: "one", Apply: step(1)},
+		{Version: 2, Description: "two", Apply: step(2)},
+		{Version: 3, Description: "three", Apply: step(3)},
+	},
+}
+
+// step returns a migration that sets the version line and records itself
+func step(version int) func([]byte) ([]byte, error) {
+	return func(data []byte) ([]byte, error) {
+		lines := strings.Split(string(data), "\n")
+		if strings.HasPrefix(lines[0], "v") {
+			lines = lines[1:]
+		}
+		lines = append([]string{"v" + strconv.Itoa(version)}, lines...)
+		return []byte(strings.Join(lines, "\n") + "step" + strconv.Itoa(version) + "\n"), nil
+	}
+}
+
+func TestPending(t *testing.T) {
+	tests := []struct {
+		version int
+		want    string
+	}{
+		{0, "one two three"},
+		{1, "two three"},
+		{3, ""},
+	}
+	for _, tt := range tests {
+		pending, err := testFormat.Pending(tt.version)
+		if err != nil {
+			t.Fatalf("Pending(%d) failed: %v", tt.version, err)
+		}
+		var names []string
+		for _, m := range pending {
+			names = append(names, m.Description)
+		}
+		if got := strings.Join(names, " "); got != tt.want {
+			t.Errorf("Pending(%d): expected %q, got %q", tt.version, tt.want, got)
+		}
+	}
+}
+
+func TestPendingErrors(t *testing.T) {
+	if _, err := testFormat.Pending(4); err == nil || !strings.Contains(err.Error(), "newer") {
+		t.Errorf("Expected a newer version to be refused, got %v", err)
+	}
+
+	gap := testFormat
+	gap.Migrations = []Migration{testFormat.Migrations[0], testFormat.Migrations[2]}
+	if _, err := gap.Pending(0); err == nil || !strings.Contains(err.Error(), "no migration from version 1 to 2") {
+		t.Errorf("Expected the missing migration to be reported, got %v", err)
+	}
+}
+
+func TestMigrateAppliesInOrder(t *testing.T) {
+	data, applied, err := testFormat.Migrate([]byte("v1\n"))
+	if err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	if len(applied) != 2 {
+		t.Errorf("Expected 2 migrations, got %d", len(applied))
+	}
+	if got := string(data); got != "v3\nstep2\nstep3\n" {
+		t.Errorf("Unexpected result: %q", got)
+	}
+}
+
+func TestCheckDoesNotModify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.txt")
+	if err := os.WriteFile(path, []byte("legacy\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := Check(File{Format: testFormat, Path: path})
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if !status.Exists || status.Version != 0 || len(status.Pending) != 3 {
+		t.Errorf("Expected three pending migrations from version 0, got %+v", status)
+	}
+	if status.Describe() != "v1: one\nv2: two\nv3: three" {
+		t.Errorf("Unexpected description: %q", status.Describe())
+	}
+
+	data, _ := os.ReadFile(path)
+	if string(data) != "legacy\n" {
+		t.Errorf("Expected Check to leave the file alone, got %q", data)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("Expected no backup from Check, got %d files", len(entries))
+	}
+}
+
+func TestApplyBacksUp(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.txt")
+	if err := os.WriteFile(path, []byte("v2\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+	status, err := Apply(File{Format: testFormat, Path: path}, now)
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	wantBackup := path + ".v2-20260301-093000.bak"
+	if status.Backup != wantBackup {
+		t.Errorf("Expected backup %s, got %s", wantBackup, status.Backup)
+	}
+	if backup, _ := os.ReadFile(wantBackup); string(backup) != "v2\n" {
+		t.Errorf("Expected the backup to hold the old file, got %q", backup)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "v3\nstep3\n" {
+		t.Errorf("Expected the migrated file, got %q", data)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("Expected permissions to be kept, got %v", info.Mode().Perm())
+	}
+
+	// Once current, nothing more happens
+	status, err = Apply(File{Format: testFormat, Path: path}, now)
+	if err != nil || status.Backup != "" {
+		t.Errorf("Expected no further migration, got %+v, %v", status, err)
+	}
+}
+
+func TestApplyMissingFile(t *testing.T) {
+	status, err := Apply(File{Format: testFormat, Path: filepath.Join(t.TempDir(), "missing")}, time.Now())
+	if err != nil || status.Exists {
+		t.Errorf("Expected a missing file to be skipped, got %+v, %v", status, err)
+	}
+}
@@ -6,8 +6,14 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+
+	"github.com/anicolao/emrys/internal/audit"
+	"github.com/anicolao/emrys/internal/logging"
 )
 
+// auditInitiator attributes nix-darwin commands in the audit log
+const auditInitiator = "bootstrap:nix-darwin"
+
 // IsInstalled checks if nix-darwin is installed on the system
 func IsInstalled() bool {
 	// Only check if darwin-rebuild command exists
@@ -24,8 +30,8 @@ func IsNixInstalled() bool {
 
 // InstallNix installs Nix on the system
 func InstallNix() error {
-	fmt.Println("Installing Nix (Lix)...")
-	fmt.Println("This will require sudo access and may take several minutes.")
+	logger().Info("Installing Nix (Lix)...")
+	logger().Info("This will require sudo access and may take several minutes.")
 
 	// Use the Lix installer
 	cmd := exec.Command("sh", "-c", "curl -sSf -L https://install.lix.systems/lix | sh -s -- install")
@@ -33,18 +39,18 @@ func InstallNix() error {
 	cmd.Stderr = os.Stderr
 	cmd.Stdin = os.Stdin
 
-	if err := cmd.Run(); err != nil {
+	if err := audit.Run(auditInitiator, cmd); err != nil {
 		return fmt.Errorf("failed to install Nix: %w", err)
 	}
 
-	fmt.Println("✓ Nix installed successfully")
+	logger().Info("✓ Nix installed successfully")
 	return nil
 }
 
 // InstallNixDarwin installs nix-darwin with the provided configuration
 // Deprecated: Use InstallNixDarwinWithFlake instead
 func InstallNixDarwin(configPath string) error {
-	fmt.Println("Installing nix-darwin...")
+	logger().Info("Installing nix-darwin...")
 
 	// First, ensure the configuration is in the right place
 	homeDir, err := os.UserHomeDir()
@@ -63,7 +69,7 @@ func InstallNixDarwin(configPath string) error {
 		return fmt.Errorf("failed to copy configuration: %w", err)
 	}
 
-	fmt.Printf("✓ Configuration copied to %s\n", destConfig)
+	logger().Info("✓ Configuration copied to {path}", "path", destConfig)
 
 	// Note: This function is deprecated. Flake-based installation is now required.
 	return fmt.Errorf("legacy installation method no longer supported, please use flake-based installation")
@@ -71,7 +77,7 @@ func InstallNixDarwin(configPath string) error {
 
 // InstallNixDarwinWithConfig installs nix-darwin with the provided configuration content
 func InstallNixDarwinWithConfig(configContent string) error {
-	fmt.Println("Installing nix-darwin...")
+	logger().Info("Installing nix-darwin...")
 
 	// First, ensure the configuration is in the right place
 	homeDir, err := os.UserHomeDir()
@@ -90,7 +96,7 @@ func InstallNixDarwinWithConfig(configContent string) error {
 		return fmt.Errorf("failed to write configuration: %w", err)
 	}
 
-	fmt.Printf("✓ Configuration written to %s\n", destConfig)
+	logger().Info("✓ Configuration written to {path}", "path", destConfig)
 
 	// Run nix-darwin installation using the flake-based installer
 	// We need to source nix before running nix commands
@@ -108,17 +114,17 @@ func InstallNixDarwinWithConfig(configContent string) error {
 	cmd.Stdin = os.Stdin
 	cmd.Dir = homeDir
 
-	if err := cmd.Run(); err != nil {
+	if err := audit.Run(auditInitiator, cmd); err != nil {
 		return fmt.Errorf("failed to install nix-darwin: %w", err)
 	}
 
-	fmt.Println("✓ nix-darwin installed successfully")
+	logger().Info("✓ nix-darwin installed successfully")
 	return nil
 }
 
 // InstallNixDarwinWithFlake installs nix-darwin with the provided configuration and flake content
 func InstallNixDarwinWithFlake(configContent, flakeContent string) error {
-	fmt.Println("Installing nix-darwin...")
+	logger().Info("Installing nix-darwin...")
 
 	// First, ensure the configuration is in the right place
 	homeDir, err := os.UserHomeDir()
@@ -156,14 +162,11 @@ func InstallNixDarwinWithFlake(configContent, flakeContent string) error {
 		return fmt.Errorf("failed to write flake.nix: %w", err)
 	}
 
-	fmt.Printf("✓ Configuration written to %s\n", destConfig)
-	fmt.Printf("✓ Flake written to %s\n", destFlake)
-	fmt.Printf("✓ Primary user set to: %s\n", username)
-
-	fmt.Println()
-	fmt.Println("Running nix-darwin installation...")
-	fmt.Println("Note: You will be asked for your password (sudo required for system activation)")
-	fmt.Println()
+	logger().Info("✓ Configuration written to {path}", "path", destConfig)
+	logger().Info("✓ Flake written to {path}", "path", destFlake)
+	logger().Info("✓ Primary user set to {user}", "user", username)
+	logger().Info("Running nix-darwin installation...", logging.Break)
+	logger().Info("Note: You will be asked for your password (sudo required for system activation)")
 
 	// Run nix-darwin installation using the flake-based installer
 	// We need to source nix before running nix commands
@@ -182,11 +185,11 @@ func InstallNixDarwinWithFlake(configContent, flakeContent string) error {
 	cmd.Stdin = os.Stdin
 	cmd.Dir = homeDir
 
-	if err := cmd.Run(); err != nil {
+	if err := audit.Run(auditInitiator, cmd); err != nil {
 		return fmt.Errorf("failed to install nix-darwin: %w", err)
 	}
 
-	fmt.Println("✓ nix-darwin installed successfully")
+	logger().Info("✓ nix-darwin installed successfully")
 	return nil
 }
 
@@ -207,9 +210,8 @@ func copyFile(src, dst string) error {
 
 // ApplyConfiguration applies the nix-darwin configuration
 func ApplyConfiguration() error {
-	fmt.Println("Applying nix-darwin configuration...")
-	fmt.Println("Note: This may take several minutes and will require sudo access")
-	fmt.Println()
+	logger().Info("Applying nix-darwin configuration...")
+	logger().Info("Note: This may take several minutes and will require sudo access")
 
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
@@ -231,10 +233,10 @@ func ApplyConfiguration() error {
 	cmd.Stdin = os.Stdin
 	cmd.Dir = homeDir
 
-	if err := cmd.Run(); err != nil {
+	if err := audit.Run(auditInitiator, cmd); err != nil {
 		return fmt.Errorf("failed to apply configuration: %w", err)
 	}
 
-	fmt.Println("✓ Configuration applied successfully")
+	logger().Info("✓ Configuration applied successfully")
 	return nil
 }
@@ -0,0 +1,40 @@
+package supervisor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/anicolao/emrys/internal/audit"
+	"github.com/anicolao/emrys/internal/llm"
+)
+
+// initiator attributes restarts in the audit log
+const initiator = "supervisor"
+
+// Ollama returns a service that probes the Ollama API and restarts the
+// launch agent with the given label
+func Ollama(client *llm.Client, agent string) Service {
+	return Service{
+		Name: "Ollama",
+		Probe: func(ctx context.Context) error {
+			_, err := client.Version(ctx)
+			return err
+		},
+		Restart: func(ctx context.Context) error {
+			return Kickstart(ctx, agent)
+		},
+	}
+}
+
+// Kickstart restarts one of the user's launch agents, starting it if it is
+// not running
+func Kickstart(ctx context.Context, label string) error {
+	target := fmt.Sprintf("gui/%d/%s", os.Getuid(), label)
+	cmd := exec.CommandContext(ctx, "launchctl", "kickstart", "-k", target)
+	if output, err := audit.CombinedOutput(initiator, cmd); err != nil {
+		return fmt.Errorf("failed to restart %s: %w\nOutput: %s", label, err, string(output))
+	}
+	return nil
+}
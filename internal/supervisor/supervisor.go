@@ -0,0 +1,210 @@
+// Package supervisor keeps the services Emrys depends on running: it probes
+// each one, restarts it when the probe fails and says so out loud
+package supervisor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/anicolao/emrys/internal/voice"
+)
+
+// Service is something the supervisor keeps running
+type Service struct {
+	Name    string                          // Spoken in announcements, such as "Ollama"
+	Probe   func(ctx context.Context) error // Returns nil while the service is healthy
+	Restart func(ctx context.Context) error // Asks the service manager to restart it
+}
+
+// State is what the supervisor believes about a service
+type State string
+
+// Service states
+const (
+	Unknown    State = "unknown"    // Not probed yet
+	Healthy    State = "healthy"    // The last probe succeeded
+	Restarting State = "restarting" // Failing; restarts are being tried with backoff
+	Open       State = "open"       // Failed too often; restarts are paused until the cooldown ends
+)
+
+// Options control how often services are probed and restarted
+type Options struct {
+	Interval       time.Duration // Between probes of a healthy service
+	ProbeTimeout   time.Duration // Bound on a single probe
+	InitialBackoff time.Duration // Wait after the first restart
+	MaxBackoff     time.Duration // Longest wait between restarts
+	Jitter         float64       // Random spread of each wait, as a fraction of it
+	Failures       int           // Failed probes in a row that open the circuit
+	Cooldown       time.Duration // Wait before trying a service again once the circuit is open
+}
+
+// DefaultOptions returns the options used when config.yaml does not set them
+func DefaultOptions() Options {
+	return Options{
+		Interval:       30 * time.Second,
+		ProbeTimeout:   5 * time.Second,
+		InitialBackoff: 2 * time.Second,
+		MaxBackoff:     5 * time.Minute,
+		Jitter:         0.2,
+		Failures:       5,
+		Cooldown:       15 * time.Minute,
+	}
+}
+
+// Announcer speaks state changes; *voice.Speaker is one
+type Announcer interface {
+	SpeakPriority(message string, priority voice.Priority)
+}
+
+// Supervisor probes services and restarts those that fail
+type Supervisor struct {
+	opts      Options
+	announcer Announcer
+	logger    *slog.Logger
+	services  []Service
+
+	// Replaced by tests
+	after  func(time.Duration) <-chan time.Time
+	random func() float64
+
+	mu     sync.RWMutex
+	states map[string]State
+}
+
+// New creates a supervisor. announcer may be nil to stay silent.
+func New(opts Options, announcer Announcer, logger *slog.Logger) *Supervisor {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Supervisor{
+		opts:      opts,
+		announcer: announcer,
+		logger:    logger,
+		after:     time.After,
+		random:    rand.Float64,
+		states:    make(map[string]State),
+	}
+}
+
+// Add registers a service; call it before Run
+func (s *Supervisor) Add(service Service) {
+	s.services = append(s.services, service)
+	s.setState(service.Name, Unknown)
+}
+
+// State returns what the supervisor believes about the named service
+func (s *Supervisor) State(name string) State {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.states[name]
+}
+
+// setState records a service's state
+func (s *Supervisor) setState(name string, state State) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states[name] = state
+}
+
+// Run supervises every service until ctx is cancelled
+func (s *Supervisor) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, service := range s.services {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.supervise(ctx, service)
+		}()
+	}
+	wg.Wait()
+}
+
+// supervise probes one service until ctx is cancelled. A failing service is
+// restarted with exponential backoff; after Failures probes in a row fail
+// the circuit opens and the service is only retried once per Cooldown.
+func (s *Supervisor) supervise(ctx context.Context, service Service) {
+	logger := s.logger.With("service", service.Name)
+	state := Unknown
+	failures := 0
+	var wait time.Duration
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.after(wait):
+		}
+
+		err := s.probe(ctx, service)
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			if state == Restarting || state == Open {
+				logger.Info("service recovered", "failures", failures)
+				s.announce(fmt.Sprintf("%s is running again.", service.Name), voice.PriorityNormal)
+			}
+			state, failures, wait = Healthy, 0, s.opts.Interval
+			s.setState(service.Name, state)
+			continue
+		}
+
+		failures++
+		logger.Warn("service probe failed", "failures", failures, "error", err)
+		switch {
+		case state == Open:
+			// Half-open: one more restart, then wait out another cooldown
+			wait = s.opts.Cooldown
+		case failures >= s.opts.Failures:
+			state, wait = Open, s.opts.Cooldown
+			logger.Error("service keeps failing; pausing restarts", "failures", failures, "cooldown", s.opts.Cooldown)
+			s.announce(fmt.Sprintf("%s keeps failing, so I have stopped restarting it. Run emrys doctor for help.", service.Name), voice.PriorityCritical)
+		default:
+			if state != Restarting {
+				s.announce(fmt.Sprintf("%s is not responding. Restarting it.", service.Name), voice.PriorityNormal)
+			}
+			state, wait = Restarting, s.backoff(failures)
+		}
+		s.setState(service.Name, state)
+
+		if err := service.Restart(ctx); err != nil {
+			logger.Warn("service restart failed", "error", err)
+		} else {
+			logger.Info("service restarted", "next_probe", wait)
+		}
+	}
+}
+
+// probe runs the service's probe within ProbeTimeout
+func (s *Supervisor) probe(ctx context.Context, service Service) error {
+	if s.opts.ProbeTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.opts.ProbeTimeout)
+		defer cancel()
+	}
+	return service.Probe(ctx)
+}
+
+// backoff returns the wait after the given number of failures: doubling
+// from InitialBackoff up to MaxBackoff, spread by Jitter so that services
+// restarted together are not probed in lockstep
+func (s *Supervisor) backoff(failures int) time.Duration {
+	d := float64(s.opts.InitialBackoff) * math.Pow(2, float64(failures-1))
+	if limit := float64(s.opts.MaxBackoff); s.opts.MaxBackoff > 0 && d > limit {
+		d = limit
+	}
+	d *= 1 + s.opts.Jitter*(2*s.random()-1)
+	return time.Duration(d)
+}
+
+// announce speaks a message if there is an announcer
+func (s *Supervisor) announce(message string, priority voice.Priority) {
+	if s.announcer != nil {
+		s.announcer.SpeakPriority(message, priority)
+	}
+}
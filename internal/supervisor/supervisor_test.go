@@ -0,0 +1,148 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anicolao/emrys/internal/voice"
+)
+
+// announcement is one message spoken by the supervisor
+type announcement struct {
+	message  string
+	priority voice.Priority
+}
+
+// recorder is an Announcer that remembers what it was asked to say
+type recorder struct {
+	mu   sync.Mutex
+	said []announcement
+}
+
+func (r *recorder) SpeakPriority(message string, priority voice.Priority) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.said = append(r.said, announcement{message, priority})
+}
+
+// script runs a supervisor over a service whose probes return results in
+// turn, and returns the waits between probes and the number of restarts
+func script(t *testing.T, opts Options, announcer Announcer, results []error) ([]time.Duration, int, *Supervisor) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var waits []time.Duration
+	restarts := 0
+	probes := 0
+	s := New(opts, announcer, nil)
+	s.random = func() float64 { return 0.5 } // No jitter
+	s.after = func(d time.Duration) <-chan time.Time {
+		waits = append(waits, d)
+		ch := make(chan time.Time, 1)
+		ch <- time.Time{}
+		return ch
+	}
+	s.Add(Service{
+		Name: "Ollama",
+		Probe: func(context.Context) error {
+			if probes == len(results) {
+				cancel()
+				return errors.New("done")
+			}
+			probes++
+			return results[probes-1]
+		},
+		Restart: func(context.Context) error {
+			restarts++
+			return nil
+		},
+	})
+	s.Run(ctx)
+	return waits, restarts, s
+}
+
+func TestSuperviseHealthy(t *testing.T) {
+	opts := DefaultOptions()
+	var said recorder
+	waits, restarts, s := script(t, opts, &said, []error{nil, nil})
+
+	if restarts != 0 || len(said.said) != 0 {
+		t.Errorf("Expected no restarts or announcements, got %d and %v", restarts, said.said)
+	}
+	if len(waits) != 3 || waits[0] != 0 || waits[1] != opts.Interval || waits[2] != opts.Interval {
+		t.Errorf("Expected to probe at once and then every interval, got %v", waits)
+	}
+	if s.State("Ollama") != Healthy {
+		t.Errorf("Expected healthy, got %s", s.State("Ollama"))
+	}
+}
+
+func TestSuperviseBacksOffAndRecovers(t *testing.T) {
+	opts := DefaultOptions()
+	opts.InitialBackoff = time.Second
+	opts.MaxBackoff = 3 * time.Second
+	down := errors.New("connection refused")
+	var said recorder
+	waits, restarts, s := script(t, opts, &said, []error{nil, down, down, down, nil})
+
+	if restarts != 3 {
+		t.Errorf("Expected 3 restarts, got %d", restarts)
+	}
+	want := []time.Duration{0, opts.Interval, time.Second, 2 * time.Second, 3 * time.Second, opts.Interval}
+	if len(waits) != len(want) {
+		t.Fatalf("Expected waits %v, got %v", want, waits)
+	}
+	for i := range want {
+		if waits[i] != want[i] {
+			t.Errorf("Expected waits %v, got %v", want, waits)
+			break
+		}
+	}
+
+	if len(said.said) != 2 {
+		t.Fatalf("Expected two announcements, got %v", said.said)
+	}
+	if said.said[0].message != "Ollama is not responding. Restarting it." || said.said[1].message != "Ollama is running again." {
+		t.Errorf("Unexpected announcements: %v", said.said)
+	}
+	if s.State("Ollama") != Healthy {
+		t.Errorf("Expected healthy, got %s", s.State("Ollama"))
+	}
+}
+
+func TestSuperviseOpensCircuit(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Failures = 2
+	down := errors.New("connection refused")
+	var said recorder
+	waits, restarts, s := script(t, opts, &said, []error{down, down, down})
+
+	if restarts != 3 {
+		t.Errorf("Expected a restart per failed probe, got %d", restarts)
+	}
+	if waits[len(waits)-1] != opts.Cooldown || waits[len(waits)-2] != opts.Cooldown {
+		t.Errorf("Expected cooldowns once the circuit opened, got %v", waits)
+	}
+	if len(said.said) != 2 || said.said[1].priority != voice.PriorityCritical {
+		t.Errorf("Expected a critical announcement when the circuit opened, got %v", said.said)
+	}
+	if s.State("Ollama") != Open {
+		t.Errorf("Expected open, got %s", s.State("Ollama"))
+	}
+}
+
+func TestBackoffJitter(t *testing.T) {
+	s := New(Options{InitialBackoff: time.Second, MaxBackoff: time.Minute, Jitter: 0.5}, nil, nil)
+	s.random = func() float64 { return 0 }
+	if d := s.backoff(1); d != 500*time.Millisecond {
+		t.Errorf("Expected the lowest jitter to halve the wait, got %s", d)
+	}
+	s.random = func() float64 { return 1 }
+	if d := s.backoff(10); d != 90*time.Second {
+		t.Errorf("Expected the highest jitter on the capped wait, got %s", d)
+	}
+}
@@ -0,0 +1,27 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/anicolao/emrys/internal/audit"
+)
+
+// TestMain keeps commands run by tests out of the user's real audit log
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "emrys-audit")
+	if err != nil {
+		panic(err)
+	}
+
+	log, err := audit.Open(filepath.Join(dir, "audit.jsonl"))
+	if err != nil {
+		panic(err)
+	}
+	audit.SetDefault(log)
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
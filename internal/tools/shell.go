@@ -0,0 +1,608 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/anicolao/emrys/internal/approval"
+	"github.com/anicolao/emrys/internal/audit"
+)
+
+// Default limits for shell commands
+const (
+	DefaultShellTimeout   = 60 * time.Second
+	DefaultMaxOutputBytes = 64 * 1024
+)
+
+// DefaultShellEnv lists the environment variables passed through to commands
+// when the policy does not specify its own list. Everything else is scrubbed
+// so that tokens and credentials in Emrys' environment do not leak.
+var DefaultShellEnv = []string{"PATH", "HOME", "USER", "LOGNAME", "LANG", "LC_ALL", "TMPDIR", "TERM", "SHELL"}
+
+// ErrCommandDenied is returned when the policy refuses to run a command
+var ErrCommandDenied = errors.New("command denied by shell policy")
+
+// ShellPolicy limits what the shell tool may run and how
+type ShellPolicy struct {
+	Allow          []string      // Regexps; when set, a command must match at least one
+	Deny           []string      // Regexps; a command matching any of them is refused
+	Timeout        time.Duration // Maximum runtime (default: DefaultShellTimeout)
+	MaxOutputBytes int           // Maximum bytes kept per stream (default: DefaultMaxOutputBytes)
+	Env            []string      // Environment variables passed through (default: DefaultShellEnv)
+	WorkDir        string        // Working directory when none is requested (default: Jail, then current directory)
+	Jail           string        // When set, the working directory must be inside this directory
+	Shell          string        // Shell used to run commands (default: /bin/sh)
+}
+
+// SudoApprover decides whether a command that needs elevated privileges may run
+type SudoApprover func(ctx context.Context, command string) (bool, error)
+
+// ShellRequest is the argument object the model passes to the shell tool
+type ShellRequest struct {
+	Command        string `json:"command"`
+	Cwd            string `json:"cwd,omitempty"`
+	TimeoutSeconds int    `json:"timeout_seconds,omitempty"`
+}
+
+// ShellResult is the structured outcome of running a command
+type ShellResult struct {
+	Command         string `json:"command"`
+	Cwd             string `json:"cwd"`
+	Stdout          string `json:"stdout"`
+	Stderr          string `json:"stderr"`
+	ExitCode        int    `json:"exit_code"`
+	StdoutTruncated bool   `json:"stdout_truncated,omitempty"`
+	StderrTruncated bool   `json:"stderr_truncated,omitempty"`
+	TimedOut        bool   `json:"timed_out,omitempty"`
+	DurationMS      int64  `json:"duration_ms"`
+}
+
+// ShellTool runs terminal commands for the agent under a ShellPolicy
+type ShellTool struct {
+	policy  ShellPolicy
+	allow   []*regexp.Regexp
+	deny    []*regexp.Regexp
+	approve SudoApprover
+}
+
+// NewShellTool creates a shell tool. Commands that need sudo are refused
+// unless approve is non-nil and grants them.
+func NewShellTool(policy ShellPolicy, approve SudoApprover) (*ShellTool, error) {
+	if policy.Timeout <= 0 {
+		policy.Timeout = DefaultShellTimeout
+	}
+	if policy.MaxOutputBytes <= 0 {
+		policy.MaxOutputBytes = DefaultMaxOutputBytes
+	}
+	if policy.Env == nil {
+		policy.Env = DefaultShellEnv
+	}
+	if policy.Shell == "" {
+		policy.Shell = "/bin/sh"
+	}
+
+	if policy.Jail != "" {
+		jail, err := filepath.Abs(policy.Jail)
+		if err != nil {
+			return nil, fmt.Errorf("invalid jail directory: %w", err)
+		}
+		if resolved, err := filepath.EvalSymlinks(jail); err == nil {
+			jail = resolved
+		}
+		policy.Jail = jail
+		if policy.WorkDir == "" {
+			policy.WorkDir = jail
+		}
+	}
+
+	allow, err := compilePatterns(policy.Allow)
+	if err != nil {
+		return nil, fmt.Errorf("invalid allow pattern: %w", err)
+	}
+	deny, err := compilePatterns(policy.Deny)
+	if err != nil {
+		return nil, fmt.Errorf("invalid deny pattern: %w", err)
+	}
+
+	return &ShellTool{policy: policy, allow: allow, deny: deny, approve: approve}, nil
+}
+
+// Name returns the tool name
+func (s *ShellTool) Name() string {
+	return "shell"
+}
+
+// Description tells the model what the tool does
+func (s *ShellTool) Description() string {
+	return "Run a shell command on the Mac and return its stdout, stderr and exit code."
+}
+
+// Parameters returns the JSON-schema for ShellRequest
+func (s *ShellTool) Parameters() json.RawMessage {
+	return json.RawMessage(`{
+  "type": "object",
+  "properties": {
+    "command": {"type": "string", "description": "The command line to run with /bin/sh"},
+    "cwd": {"type": "string", "description": "Working directory for the command"},
+    "timeout_seconds": {"type": "integer", "description": "Maximum runtime in seconds"}
+  },
+  "required": ["command"]
+}`)
+}
+
+// Invoke parses the model's arguments, runs the command and returns the
+// ShellResult as JSON
+func (s *ShellTool) Invoke(ctx context.Context, args json.RawMessage) (string, error) {
+	var req ShellRequest
+	if err := json.Unmarshal(args, &req); err != nil {
+		return "", fmt.Errorf("invalid shell arguments: %w", err)
+	}
+
+	result, err := s.Run(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	output, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode shell result: %w", err)
+	}
+	return string(output), nil
+}
+
+// networkPattern matches commands that commonly reach the network
+var networkPattern = regexp.MustCompile(`(^|[;&|(\s])(curl|wget|ssh|scp|sftp|rsync|nc|ping|ftp|brew|git\s+(clone|fetch|pull|push)|ollama\s+pull|nix\s+(run|build|profile|flake))(\s|$)`)
+
+// DescribeCall reports the paths, network use, sudo and destruction a
+// command implies, so approval rules can apply to it. Relative paths are
+// resolved against the working directory, following any cd in the command.
+func (s *ShellTool) DescribeCall(args json.RawMessage) approval.Facts {
+	var req ShellRequest
+	json.Unmarshal(args, &req)
+
+	facts := approval.Facts{
+		Sudo:        NeedsSudo(req.Command),
+		Network:     networkPattern.MatchString(req.Command),
+		Destructive: IsDestructive(req.Command),
+		Summary:     "$ " + req.Command,
+	}
+
+	dir := s.policy.WorkDir
+	if dir == "" {
+		dir, _ = os.Getwd()
+	}
+	if req.Cwd != "" {
+		dir = resolvePath(dir, req.Cwd)
+		facts.Paths = append(facts.Paths, dir)
+	}
+
+	for _, segment := range segmentSeparator.Split(req.Command, -1) {
+		words := strings.Fields(segment)
+		for len(words) > 0 && isAssignment(words[0]) {
+			words = words[1:]
+		}
+		if len(words) == 0 {
+			continue
+		}
+
+		if programName(words[0]) == "cd" {
+			target := "~"
+			if len(words) > 1 {
+				target = strings.Trim(words[1], `"'`)
+			}
+			dir = resolvePath(dir, target)
+			facts.Paths = append(facts.Paths, dir)
+			continue
+		}
+		for _, word := range words {
+			word = strings.Trim(word, `"'`)
+			if isPathWord(word) {
+				facts.Paths = append(facts.Paths, resolvePath(dir, word))
+			}
+		}
+	}
+
+	return facts
+}
+
+// isPathWord reports whether a command word names a file: an absolute
+// path, one under the home directory, or a relative one with a slash or
+// dots. Options, assignments and URLs are not paths.
+func isPathWord(word string) bool {
+	switch {
+	case word == "" || strings.HasPrefix(word, "-") || strings.Contains(word, "://"):
+		return false
+	case word == "~" || strings.HasPrefix(word, "~/") || strings.HasPrefix(word, "$HOME"):
+		return true
+	case isAssignment(word):
+		return false
+	}
+	return word == "." || word == ".." || strings.Contains(word, "/")
+}
+
+// resolvePath returns the absolute path a command word names when run in
+// dir, expanding ~ and $HOME
+func resolvePath(dir, word string) string {
+	if rest, ok := homeRelative(word); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return filepath.Clean("/" + rest)
+		}
+		return filepath.Join(home, rest)
+	}
+	if filepath.IsAbs(word) {
+		return filepath.Clean(word)
+	}
+	return filepath.Join(dir, word)
+}
+
+// homeRelative reports whether word starts at the home directory, as in
+// ~, ~/src, $HOME or ${HOME}/src, and returns the rest of the path
+func homeRelative(word string) (string, bool) {
+	for _, home := range []string{"~", "${HOME}", "$HOME"} {
+		if word == home {
+			return "", true
+		}
+		if rest, ok := strings.CutPrefix(word, home+"/"); ok {
+			return rest, true
+		}
+	}
+	return "", false
+}
+
+// Run checks the command against the policy and executes it
+// A non-zero exit status is reported in the result, not as an error.
+func (s *ShellTool) Run(ctx context.Context, req ShellRequest) (*ShellResult, error) {
+	command := strings.TrimSpace(req.Command)
+	if command == "" {
+		return nil, fmt.Errorf("command cannot be empty")
+	}
+
+	if err := s.check(ctx, command); err != nil {
+		return nil, err
+	}
+
+	cwd, err := s.resolveCwd(req.Cwd)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := s.policy.Timeout
+	if req.TimeoutSeconds > 0 {
+		if requested := time.Duration(req.TimeoutSeconds) * time.Second; requested < timeout {
+			timeout = requested
+		}
+	}
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	stdout := &limitedBuffer{limit: s.policy.MaxOutputBytes}
+	stderr := &limitedBuffer{limit: s.policy.MaxOutputBytes}
+
+	cmd := exec.CommandContext(runCtx, s.policy.Shell, "-c", command)
+	cmd.Dir = cwd
+	cmd.Env = s.env(cwd)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	// Run in a new process group so a timeout kills the whole pipeline
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = time.Second
+
+	start := time.Now()
+	err = audit.Run(audit.InitiatorFrom(ctx, audit.InitiatorAgent), cmd)
+
+	result := &ShellResult{
+		Command:         command,
+		Cwd:             cwd,
+		Stdout:          stdout.String(),
+		Stderr:          stderr.String(),
+		StdoutTruncated: stdout.truncated,
+		StderrTruncated: stderr.truncated,
+		TimedOut:        errors.Is(runCtx.Err(), context.DeadlineExceeded),
+		DurationMS:      time.Since(start).Milliseconds(),
+	}
+
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("failed to run command: %w", err)
+		}
+		result.ExitCode = exitErr.ExitCode()
+	}
+
+	return result, nil
+}
+
+// check applies the allow/deny patterns and the sudo rule
+func (s *ShellTool) check(ctx context.Context, command string) error {
+	for _, re := range s.deny {
+		if re.MatchString(command) {
+			return fmt.Errorf("%w: matches deny pattern %q", ErrCommandDenied, re.String())
+		}
+	}
+
+	if len(s.allow) > 0 {
+		allowed := false
+		for _, re := range s.allow {
+			if re.MatchString(command) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("%w: does not match any allow pattern", ErrCommandDenied)
+		}
+	}
+
+	if NeedsSudo(command) {
+		if s.approve == nil {
+			return fmt.Errorf("%w: requires elevated privileges", ErrCommandDenied)
+		}
+		granted, err := s.approve(ctx, command)
+		if err != nil {
+			return fmt.Errorf("sudo approval failed: %w", err)
+		}
+		if !granted {
+			return fmt.Errorf("%w: elevated privileges were not approved", ErrCommandDenied)
+		}
+	}
+
+	return nil
+}
+
+// resolveCwd returns the absolute working directory for a request and
+// enforces the jail
+func (s *ShellTool) resolveCwd(requested string) (string, error) {
+	base := s.policy.WorkDir
+	if base == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return "", fmt.Errorf("failed to get working directory: %w", err)
+		}
+		base = wd
+	}
+
+	cwd := base
+	if requested != "" {
+		if filepath.IsAbs(requested) {
+			cwd = requested
+		} else {
+			cwd = filepath.Join(base, requested)
+		}
+	}
+
+	cwd, err := filepath.Abs(cwd)
+	if err != nil {
+		return "", fmt.Errorf("invalid working directory: %w", err)
+	}
+	// Resolve symlinks so a link inside the jail cannot point outside it
+	resolved, err := filepath.EvalSymlinks(cwd)
+	if err != nil {
+		return "", fmt.Errorf("invalid working directory: %w", err)
+	}
+
+	if s.policy.Jail != "" && !isWithin(s.policy.Jail, resolved) {
+		return "", fmt.Errorf("%w: working directory %s is outside %s", ErrCommandDenied, resolved, s.policy.Jail)
+	}
+
+	return resolved, nil
+}
+
+// env builds the scrubbed environment for a command
+func (s *ShellTool) env(cwd string) []string {
+	env := []string{"PWD=" + cwd}
+	for _, name := range s.policy.Env {
+		if value, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+value)
+		}
+	}
+	return env
+}
+
+// privileged lists the programs that run commands with elevated privileges
+var privileged = map[string]bool{"sudo": true, "doas": true, "su": true, "pkexec": true}
+
+// wrappers lists programs and shell keywords that run the command that
+// follows them, so "env sudo ..." needs sudo as much as "sudo ..." does
+var wrappers = map[string]bool{
+	"env": true, "command": true, "exec": true, "nice": true, "nohup": true, "xargs": true, "time": true,
+	"builtin": true, "if": true, "then": true, "else": true, "elif": true, "do": true, "while": true, "until": true, "!": true, "{": true,
+}
+
+// segmentSeparator splits a command line into the commands of its
+// pipelines, lists and substitutions
+var segmentSeparator = regexp.MustCompile("[;&|()`\n]")
+
+// NeedsSudo reports whether a command line asks for elevated privileges
+// A command needs them if the program it runs, by base name, is a
+// privilege-escalation program. After a wrapper such as env, nice or xargs,
+// whose options and arguments vary, any later word naming one counts.
+func NeedsSudo(command string) bool {
+	for _, segment := range segmentSeparator.Split(command, -1) {
+		words := strings.Fields(segment)
+		// Skip variable assignments such as "LANG=C sudo ..."
+		for len(words) > 0 && isAssignment(words[0]) {
+			words = words[1:]
+		}
+		if len(words) == 0 {
+			continue
+		}
+
+		if privileged[programName(words[0])] {
+			return true
+		}
+		if wrappers[programName(words[0])] {
+			for _, word := range words[1:] {
+				if privileged[programName(word)] {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// diskPattern matches commands that erase or overwrite a disk
+var diskPattern = regexp.MustCompile(`(^|[;&|(\s])((mkfs(\.\w+)?|newfs(_\w+)?|diskutil\s+(secureErase|erase\w*|zero\w*|reformat))(\s|$)|dd\s+.*of=/dev/)`)
+
+// IsDestructive reports whether a command line would erase a disk or
+// recursively remove the root or home directory. The rm options are parsed
+// the way rm does, so "rm -r -f /", "rm -Rf ~" and "rm --recursive /*" all
+// count, however they are placed or spelled. As with NeedsSudo, rm after a
+// wrapper or sudo counts too.
+func IsDestructive(command string) bool {
+	if diskPattern.MatchString(command) {
+		return true
+	}
+	for _, segment := range segmentSeparator.Split(command, -1) {
+		words := strings.Fields(segment)
+		for len(words) > 0 && isAssignment(words[0]) {
+			words = words[1:]
+		}
+		if len(words) == 0 {
+			continue
+		}
+
+		if programName(words[0]) == "rm" {
+			if removesEverything(words[1:]) {
+				return true
+			}
+			continue
+		}
+		if wrappers[programName(words[0])] || privileged[programName(words[0])] {
+			for i, word := range words[1:] {
+				if programName(word) == "rm" && removesEverything(words[i+2:]) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// removesEverything reports whether rm with args would recursively remove
+// the root or home directory, or everything in them
+func removesEverything(args []string) bool {
+	recursive := false
+	var targets []string
+	options := true
+	for _, arg := range args {
+		arg = strings.Trim(arg, `"'`)
+		switch {
+		case !options || arg == "-" || !strings.HasPrefix(arg, "-"):
+			targets = append(targets, arg)
+		case arg == "--":
+			options = false
+		case arg == "--recursive":
+			recursive = true
+		case strings.HasPrefix(arg, "--"):
+		default:
+			recursive = recursive || strings.ContainsAny(arg[1:], "rR")
+		}
+	}
+	if !recursive {
+		return false
+	}
+
+	for _, target := range targets {
+		if isSweepingTarget(target) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSweepingTarget reports whether an rm target is the root or home
+// directory, or a glob of everything in it
+func isSweepingTarget(target string) bool {
+	target = strings.TrimSuffix(target, "*")
+	if rest, ok := homeRelative(target); ok {
+		return filepath.Clean("/"+rest) == "/"
+	}
+	if !strings.HasPrefix(target, "/") {
+		return false
+	}
+	target = filepath.Clean(target)
+	if target == "/" {
+		return true
+	}
+	home, err := os.UserHomeDir()
+	return err == nil && target == filepath.Clean(home)
+}
+
+// programName returns the base name of a command word, without quotes or
+// the backslash that bypasses aliases
+func programName(word string) string {
+	word = strings.Trim(word, `"'\`)
+	return filepath.Base(word)
+}
+
+// isAssignment reports whether a word sets a variable for the command
+func isAssignment(word string) bool {
+	name, _, ok := strings.Cut(word, "=")
+	return ok && name != "" && !strings.ContainsAny(name, "-/$\"'")
+}
+
+// isWithin reports whether path is dir or is inside it
+func isWithin(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+// compilePatterns compiles a list of regular expressions
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	var compiled []*regexp.Regexp
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// limitedBuffer keeps the first limit bytes written and discards the rest
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+// Write stores as much of p as fits, always reporting full success so the
+// command is not killed by a broken pipe
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	remaining := b.limit - b.buf.Len()
+	if remaining <= 0 {
+		b.truncated = b.truncated || len(p) > 0
+		return len(p), nil
+	}
+	if len(p) > remaining {
+		b.buf.Write(p[:remaining])
+		b.truncated = true
+		return len(p), nil
+	}
+	b.buf.Write(p)
+	return len(p), nil
+}
+
+// String returns the captured output
+func (b *limitedBuffer) String() string {
+	return b.buf.String()
+}
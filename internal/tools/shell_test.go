@@ -0,0 +1,320 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newShell(t *testing.T, policy ShellPolicy, approve SudoApprover) *ShellTool {
+	t.Helper()
+	shell, err := NewShellTool(policy, approve)
+	if err != nil {
+		t.Fatalf("NewShellTool failed: %v", err)
+	}
+	return shell
+}
+
+func TestShellRunCapturesOutput(t *testing.T) {
+	shell := newShell(t, ShellPolicy{}, nil)
+
+	result, err := shell.Run(context.Background(), ShellRequest{Command: "echo out; echo err >&2; exit 3"})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if result.Stdout != "out\n" {
+		t.Errorf("Expected stdout 'out\\n', got %q", result.Stdout)
+	}
+	if result.Stderr != "err\n" {
+		t.Errorf("Expected stderr 'err\\n', got %q", result.Stderr)
+	}
+	if result.ExitCode != 3 {
+		t.Errorf("Expected exit code 3, got %d", result.ExitCode)
+	}
+}
+
+func TestShellInvokeReturnsJSON(t *testing.T) {
+	shell := newShell(t, ShellPolicy{}, nil)
+
+	output, err := shell.Invoke(context.Background(), json.RawMessage(`{"command":"printf hi"}`))
+	if err != nil {
+		t.Fatalf("Invoke failed: %v", err)
+	}
+
+	var result ShellResult
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		t.Fatalf("Failed to parse result: %v", err)
+	}
+	if result.Stdout != "hi" || result.ExitCode != 0 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+}
+
+func TestShellAllowDeny(t *testing.T) {
+	shell := newShell(t, ShellPolicy{
+		Allow: []string{`^(echo|ls)\b`},
+		Deny:  []string{`rm\s+-rf`},
+	}, nil)
+
+	tests := []struct {
+		command string
+		denied  bool
+	}{
+		{"echo hello", false},
+		{"ls", false},
+		{"cat /etc/passwd", true},
+		{"echo x; rm -rf /tmp/x", true},
+	}
+
+	for _, tt := range tests {
+		_, err := shell.Run(context.Background(), ShellRequest{Command: tt.command})
+		if denied := errors.Is(err, ErrCommandDenied); denied != tt.denied {
+			t.Errorf("Run(%q): denied = %v, want %v (err: %v)", tt.command, denied, tt.denied, err)
+		}
+	}
+}
+
+func TestShellTimeout(t *testing.T) {
+	shell := newShell(t, ShellPolicy{Timeout: 200 * time.Millisecond}, nil)
+
+	start := time.Now()
+	result, err := shell.Run(context.Background(), ShellRequest{Command: "sleep 5 | cat"})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if !result.TimedOut {
+		t.Error("Expected command to time out")
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("Timeout took too long: %v", elapsed)
+	}
+}
+
+func TestShellOutputLimit(t *testing.T) {
+	shell := newShell(t, ShellPolicy{MaxOutputBytes: 10}, nil)
+
+	result, err := shell.Run(context.Background(), ShellRequest{Command: "printf 'abcdefghijklmnopqrstuvwxyz'"})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if result.Stdout != "abcdefghij" {
+		t.Errorf("Expected truncated stdout, got %q", result.Stdout)
+	}
+	if !result.StdoutTruncated {
+		t.Error("Expected StdoutTruncated to be set")
+	}
+}
+
+func TestShellScrubsEnvironment(t *testing.T) {
+	t.Setenv("EMRYS_TEST_SECRET", "hunter2")
+	shell := newShell(t, ShellPolicy{}, nil)
+
+	result, err := shell.Run(context.Background(), ShellRequest{Command: "env"})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if strings.Contains(result.Stdout, "hunter2") {
+		t.Error("Expected secret environment variable to be scrubbed")
+	}
+	if !strings.Contains(result.Stdout, "PATH=") {
+		t.Error("Expected PATH to be passed through")
+	}
+}
+
+func TestShellJail(t *testing.T) {
+	jail := t.TempDir()
+	inside := filepath.Join(jail, "work")
+	if err := os.Mkdir(inside, 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+
+	shell := newShell(t, ShellPolicy{Jail: jail}, nil)
+
+	result, err := shell.Run(context.Background(), ShellRequest{Command: "pwd", Cwd: "work"})
+	if err != nil {
+		t.Fatalf("Run inside jail failed: %v", err)
+	}
+	if !strings.HasSuffix(strings.TrimSpace(result.Stdout), "work") {
+		t.Errorf("Expected to run in jail/work, got %q", result.Stdout)
+	}
+
+	for _, cwd := range []string{"..", "/", filepath.Join(jail, "..")} {
+		if _, err := shell.Run(context.Background(), ShellRequest{Command: "pwd", Cwd: cwd}); !errors.Is(err, ErrCommandDenied) {
+			t.Errorf("Expected cwd %q to be refused, got %v", cwd, err)
+		}
+	}
+}
+
+func TestShellSudoRequiresApproval(t *testing.T) {
+	refused := newShell(t, ShellPolicy{}, nil)
+	if _, err := refused.Run(context.Background(), ShellRequest{Command: "sudo true"}); !errors.Is(err, ErrCommandDenied) {
+		t.Errorf("Expected sudo to be refused without approver, got %v", err)
+	}
+
+	var asked string
+	approver := func(ctx context.Context, command string) (bool, error) {
+		asked = command
+		return false, nil
+	}
+	denied := newShell(t, ShellPolicy{}, approver)
+	if _, err := denied.Run(context.Background(), ShellRequest{Command: "echo a && sudo true"}); !errors.Is(err, ErrCommandDenied) {
+		t.Errorf("Expected sudo to be refused when approver declines, got %v", err)
+	}
+	if asked != "echo a && sudo true" {
+		t.Errorf("Expected approver to see the command, got %q", asked)
+	}
+}
+
+func TestNeedsSudo(t *testing.T) {
+	tests := []struct {
+		command string
+		want    bool
+	}{
+		{"sudo darwin-rebuild switch", true},
+		{"ls && sudo rm x", true},
+		{"echo $(sudo cat /etc/sudoers)", true},
+		{"su - root", true},
+		{"/usr/bin/sudo rm -rf /var/db", true},
+		{"env sudo rm x", true},
+		{"env -i PATH=/bin sudo rm x", true},
+		{"command sudo rm x", true},
+		{"find . -name x | xargs sudo rm", true},
+		{"xargs -I {} sudo rm {}", true},
+		{"nice sudo rm x", true},
+		{"nice -n 10 sudo rm x", true},
+		{"nohup sudo rm x &", true},
+		{"exec sudo rm x", true},
+		{"time sudo rm x", true},
+		{"LANG=C sudo rm x", true},
+		{`"sudo" rm x`, true},
+		{`\sudo rm x`, true},
+		{"if true; then sudo rm x; fi", true},
+		{"/usr/bin/pkexec true", true},
+		{"echo sudo", false},
+		{"env FOO=1 echo hi", false},
+		{"grep su /etc/passwd", false},
+		{"summary", false},
+		{"ls -la", false},
+	}
+
+	for _, tt := range tests {
+		if got := NeedsSudo(tt.command); got != tt.want {
+			t.Errorf("NeedsSudo(%q) = %v, want %v", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestIsDestructive(t *testing.T) {
+	home, _ := os.UserHomeDir()
+
+	tests := []struct {
+		command string
+		want    bool
+	}{
+		{"rm -rf /", true},
+		{"rm -fr /", true},
+		{"rm -r -f /", true},
+		{"rm -Rf /", true},
+		{"rm -rfv /", true},
+		{"rm --recursive --force /", true},
+		{"rm --force --recursive --no-preserve-root /", true},
+		{"rm -rf /*", true},
+		{`rm -rf "/"`, true},
+		{"rm -rf /.", true},
+		{"rm -rf ~", true},
+		{"rm -rf ~/", true},
+		{"rm -rf ~/*", true},
+		{"rm -rf $HOME", true},
+		{"rm -rf ${HOME}/*", true},
+		{"rm -rf " + home, true},
+		{"rm -rf -- /", true},
+		{"rm -r /", true},
+		{"rm -f tmp -r /", true},
+		{"cd /tmp && rm -rf /", true},
+		{"sudo rm -rf /", true},
+		{"sudo -u root /bin/rm -rf /", true},
+		{"mkfs.apfs /dev/disk2", true},
+		{"diskutil eraseDisk APFS X disk2", true},
+		{"dd if=/dev/zero of=/dev/disk2", true},
+		{"rm -rf /tmp/build", false},
+		{"rm -rf ~/src/build", false},
+		{"rm -rf ./build", false},
+		{"rm -f /", false},
+		{"rm -- -rf /tmp/x", false},
+		{"echo rm -rf /", false},
+		{"ls -la", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsDestructive(tt.command); got != tt.want {
+			t.Errorf("IsDestructive(%q) = %v, want %v", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestShellDescribeCallResolvesPaths(t *testing.T) {
+	home, _ := os.UserHomeDir()
+	shell := newShell(t, ShellPolicy{WorkDir: "/work/project"}, nil)
+
+	tests := []struct {
+		name    string
+		command string
+		cwd     string
+		want    string
+	}{
+		{"absolute", "cat /etc/hosts", "", "/etc/hosts"},
+		{"relative", "cat ../../../etc/hosts", "", "/etc/hosts"},
+		{"dot dot", "ls ../../..", "", "/"},
+		{"cd then relative", "cd ../../../etc && cat hosts", "", "/etc"},
+		{"cd twice", "cd .. ; cd ../usr/local", "", "/usr/local"},
+		{"relative cwd", "ls", "../../etc", "/etc"},
+		{"relative to cwd", "cat ../etc/hosts", "/private", "/etc/hosts"},
+		{"bare home", "ls ~", "", home},
+		{"home variable", "cat $HOME/.ssh/config", "", filepath.Join(home, ".ssh/config")},
+		{"cd home", "cd && ls", "", home},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, _ := json.Marshal(ShellRequest{Command: tt.command, Cwd: tt.cwd})
+			facts := shell.DescribeCall(args)
+			for _, path := range facts.Paths {
+				if path == tt.want {
+					return
+				}
+			}
+			t.Errorf("Expected paths to include %s, got %v", tt.want, facts.Paths)
+		})
+	}
+}
+
+func TestShellDescribeCall(t *testing.T) {
+	shell := newShell(t, ShellPolicy{}, nil)
+
+	facts := shell.DescribeCall(json.RawMessage(`{"command":"sudo curl -o /etc/hosts https://example.com","cwd":"/tmp"}`))
+	if !facts.Sudo {
+		t.Error("Expected sudo to be detected")
+	}
+	if !facts.Network {
+		t.Error("Expected network use to be detected")
+	}
+
+	paths := strings.Join(facts.Paths, " ")
+	if !strings.Contains(paths, "/etc/hosts") || !strings.Contains(paths, "/tmp") {
+		t.Errorf("Expected touched paths to include /etc/hosts and /tmp, got %v", facts.Paths)
+	}
+
+	facts = shell.DescribeCall(json.RawMessage(`{"command":"ls -la"}`))
+	if facts.Sudo || facts.Network || len(facts.Paths) != 0 {
+		t.Errorf("Expected plain command to have no facts, got %+v", facts)
+	}
+}
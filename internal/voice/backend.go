@@ -0,0 +1,167 @@
+package voice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"slices"
+)
+
+// auditInitiator attributes speech and notification commands in the audit log
+const auditInitiator = "voice"
+
+// Backend names accepted in Config.Backend
+const (
+	BackendAuto   = "auto"
+	BackendSay    = "say"
+	BackendEspeak = "espeak-ng"
+	BackendPiper  = "piper"
+)
+
+// ErrNoBackend is returned when no text-to-speech backend is available
+var ErrNoBackend = errors.New("no text-to-speech backend available")
+
+// Utterance is a piece of text to speak with the settings to speak it with
+type Utterance struct {
+	Text  string
+	Voice string // Backend-specific voice name; empty for the backend default
+	Rate  int    // Words per minute; 0 for the backend default
+
+	// Volume from 0.0 to 1.0, relative to the system volume. 0 and values of
+	// 1 or more play at full volume; the Speaker never sends muted text.
+	Volume float64
+}
+
+// gain returns the volume to apply, or 0 if the utterance plays at full volume
+func (u Utterance) gain() float64 {
+	if u.Volume <= 0 || u.Volume >= 1 {
+		return 0
+	}
+	return u.Volume
+}
+
+// Capabilities describes what a backend supports
+type Capabilities struct {
+	Voices bool // Voice can be selected
+	Rate   bool // Speech rate can be changed
+	Volume bool // Volume can be changed
+}
+
+// Backend is a text-to-speech engine
+type Backend interface {
+	// Name returns the backend name as used in Config.Backend
+	Name() string
+
+	// Available reports whether the backend can be used on this system
+	Available() bool
+
+	// Speak speaks the utterance and returns when it has finished.
+	// Cancelling ctx stops speech immediately.
+	Speak(ctx context.Context, u Utterance) error
+
+	// ListVoices returns the voices the backend can use
+	ListVoices(ctx context.Context) ([]string, error)
+
+	// Capabilities describes what the backend supports
+	Capabilities() Capabilities
+}
+
+// ErrRenderUnsupported is returned when a backend cannot render to a file
+var ErrRenderUnsupported = errors.New("backend cannot render speech to a file")
+
+// Renderer is implemented by backends that can synthesize speech to an audio
+// file instead of speaking it
+type Renderer interface {
+	Backend
+
+	// Render writes the utterance to an audio file at path
+	Render(ctx context.Context, u Utterance, path string) error
+
+	// Extension returns the file extension of rendered audio, such as ".wav"
+	Extension() string
+}
+
+// NewBackend creates the named backend configured from config
+func NewBackend(name string, config Config) (Backend, error) {
+	switch name {
+	case BackendSay:
+		return NewSayBackend(), nil
+	case BackendEspeak:
+		return NewEspeakBackend(), nil
+	case BackendPiper:
+		return NewPiperBackend(config.PiperModelDir), nil
+	default:
+		return nil, fmt.Errorf("unknown voice backend: %s", name)
+	}
+}
+
+// fallbackOrder lists the backends tried when the preferred one is unavailable
+func fallbackOrder() []string {
+	if runtime.GOOS == "darwin" {
+		return []string{BackendSay, BackendPiper, BackendEspeak}
+	}
+	return []string{BackendPiper, BackendEspeak, BackendSay}
+}
+
+// SelectBackend returns the backend named in config.Backend if it is
+// available, otherwise the first available backend in fallback order.
+// If nothing is available it returns a backend whose Speak fails with
+// ErrNoBackend, so the Speaker still works and reports the problem.
+func SelectBackend(config Config) Backend {
+	var candidates []Backend
+	if config.Backend != "" && config.Backend != BackendAuto {
+		if b, err := NewBackend(config.Backend, config); err == nil {
+			candidates = append(candidates, b)
+		}
+	}
+	for _, name := range fallbackOrder() {
+		if name == config.Backend {
+			continue
+		}
+		b, _ := NewBackend(name, config)
+		candidates = append(candidates, b)
+	}
+	return selectBackend(candidates)
+}
+
+// BackendVoice returns voice if backend lists it, and "" otherwise so that
+// the backend speaks with its default voice. A voice chosen for one backend,
+// such as the macOS voice Jamie, would otherwise break a fallback backend
+// that has no voice by that name.
+func BackendVoice(ctx context.Context, backend Backend, voice string) string {
+	if voice == "" || !backend.Capabilities().Voices {
+		return ""
+	}
+	voices, err := backend.ListVoices(ctx)
+	if err != nil || !slices.Contains(voices, voice) {
+		return ""
+	}
+	return voice
+}
+
+// selectBackend returns the first available candidate
+func selectBackend(candidates []Backend) Backend {
+	for _, b := range candidates {
+		if b.Available() {
+			return b
+		}
+	}
+	return unavailableBackend{}
+}
+
+// commandExists reports whether a program is on the PATH
+func commandExists(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
+
+// unavailableBackend is used when no real backend can be found
+type unavailableBackend struct{}
+
+func (unavailableBackend) Name() string                                 { return "none" }
+func (unavailableBackend) Available() bool                              { return false }
+func (unavailableBackend) Speak(context.Context, Utterance) error       { return ErrNoBackend }
+func (unavailableBackend) ListVoices(context.Context) ([]string, error) { return nil, ErrNoBackend }
+func (unavailableBackend) Capabilities() Capabilities                   { return Capabilities{} }
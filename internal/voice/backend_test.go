@@ -0,0 +1,185 @@
+package voice
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSelectBackendPrefersFirstAvailable(t *testing.T) {
+	missing := &FakeBackend{Disabled: true}
+	first := NewFakeBackend()
+	second := NewFakeBackend()
+
+	if got := selectBackend([]Backend{missing, first, second}); got != first {
+		t.Errorf("Expected first available backend, got %v", got)
+	}
+}
+
+func TestSelectBackendNoneAvailable(t *testing.T) {
+	backend := selectBackend([]Backend{&FakeBackend{Disabled: true}})
+
+	if backend.Available() {
+		t.Error("Expected unavailable backend")
+	}
+	if err := backend.Speak(context.Background(), Utterance{Text: "hi"}); !errors.Is(err, ErrNoBackend) {
+		t.Errorf("Expected ErrNoBackend, got %v", err)
+	}
+}
+
+func TestNewBackendUnknown(t *testing.T) {
+	if _, err := NewBackend("nonexistent", DefaultConfig()); err == nil {
+		t.Error("Expected error for unknown backend")
+	}
+}
+
+func TestSpeakerUsesBackend(t *testing.T) {
+	fake := NewFakeBackend()
+	fake.Voices = []string{"Samantha"}
+	config := DefaultConfig()
+	config.Voice = "Samantha"
+	config.Rate = 180
+	speaker := NewSpeakerWithBackend(config, fake)
+	defer speaker.Close()
+
+	if err := speaker.SpeakSync("Hello there"); err != nil {
+		t.Fatalf("SpeakSync failed: %v", err)
+	}
+
+	spoken := fake.Spoken()
+	if len(spoken) != 1 {
+		t.Fatalf("Expected 1 utterance, got %d", len(spoken))
+	}
+	want := Utterance{Text: "Hello there", Voice: "Samantha", Rate: 180, Volume: 0.7}
+	if spoken[0] != want {
+		t.Errorf("Expected %+v, got %+v", want, spoken[0])
+	}
+}
+
+func TestSpeakerFallbackUsesDefaultVoice(t *testing.T) {
+	// The default config names the macOS voice Jamie, which a fallback
+	// backend such as espeak-ng does not have
+	say := NewFakeBackend()
+	say.Disabled = true
+	espeak := NewFakeBackend()
+	espeak.Voices = []string{"English_(Great_Britain)"}
+
+	speaker := NewSpeakerWithBackend(DefaultConfig(), selectBackend([]Backend{say, espeak}))
+	defer speaker.Close()
+
+	if err := speaker.SpeakSync("Hello there"); err != nil {
+		t.Fatalf("SpeakSync failed: %v", err)
+	}
+
+	spoken := espeak.Spoken()
+	if len(spoken) != 1 || spoken[0].Voice != "" {
+		t.Errorf("Expected the fallback to speak with its default voice, got %+v", spoken)
+	}
+}
+
+func TestBackendVoice(t *testing.T) {
+	fake := NewFakeBackend()
+	fake.Voices = []string{"Alex", "Jamie"}
+	ctx := context.Background()
+
+	if got := BackendVoice(ctx, fake, "Jamie"); got != "Jamie" {
+		t.Errorf("Expected a listed voice to be used, got %q", got)
+	}
+	if got := BackendVoice(ctx, fake, "Samantha"); got != "" {
+		t.Errorf("Expected an unlisted voice to be dropped, got %q", got)
+	}
+	if got := BackendVoice(ctx, unavailableBackend{}, "Jamie"); got != "" {
+		t.Errorf("Expected no voice for a backend without voices, got %q", got)
+	}
+}
+
+func TestSpeakerQueueUsesBackend(t *testing.T) {
+	fake := NewFakeBackend()
+	speaker := NewSpeakerWithBackend(DefaultConfig(), fake)
+	defer speaker.Close()
+
+	speaker.Speak("one")
+	speaker.Speak("two")
+
+	deadline := time.Now().Add(time.Second)
+	for len(fake.Spoken()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if got := fake.Texts(); !reflect.DeepEqual(got, []string{"one", "two"}) {
+		t.Errorf("Expected [one two], got %v", got)
+	}
+}
+
+func TestFakeBackendCancel(t *testing.T) {
+	fake := &FakeBackend{Delay: time.Second}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := fake.Speak(ctx, Utterance{Text: "hi"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if len(fake.Spoken()) != 0 {
+		t.Error("Expected cancelled utterance not to be recorded")
+	}
+}
+
+func TestSayArgs(t *testing.T) {
+	tests := []struct {
+		u    Utterance
+		want []string
+	}{
+		{Utterance{Text: "hi"}, []string{"hi"}},
+		{Utterance{Text: "hi", Voice: "Jamie", Rate: 200}, []string{"-v", "Jamie", "hi"}},
+		{Utterance{Text: "hi", Rate: 150}, []string{"-r", "150", "hi"}},
+	}
+
+	for _, tt := range tests {
+		if got := sayArgs(tt.u); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("sayArgs(%+v) = %v, want %v", tt.u, got, tt.want)
+		}
+	}
+}
+
+func TestParseSayVoices(t *testing.T) {
+	output := `Albert              en_US    # Hello! My name is Albert.
+Jamie (Premium)     en_GB    # Hello! My name is Jamie.
+Samantha            en_US    # Hello! My name is Samantha.
+`
+	want := []string{"Albert", "Jamie", "Samantha"}
+	if got := parseSayVoices(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseSayVoices = %v, want %v", got, want)
+	}
+}
+
+func TestParseEspeakVoices(t *testing.T) {
+	output := `Pty Language       Age/Gender VoiceName          File                 Other Languages
+ 5  af              --/M      Afrikaans          gmw/af
+ 5  en-gb           --/M      English_(Great_Britain) gmw/en           (en 2)
+`
+	want := []string{"Afrikaans", "English_(Great_Britain)"}
+	if got := parseEspeakVoices(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseEspeakVoices = %v, want %v", got, want)
+	}
+}
+
+func TestPiperListVoices(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"en_US-lessac-medium.onnx", "en_GB-alan-low.onnx", "notes.txt"} {
+		os.WriteFile(filepath.Join(dir, name), nil, 0644)
+	}
+
+	voices, err := NewPiperBackend(dir).ListVoices(context.Background())
+	if err != nil {
+		t.Fatalf("ListVoices failed: %v", err)
+	}
+
+	want := []string{"en_GB-alan-low", "en_US-lessac-medium"}
+	if !reflect.DeepEqual(voices, want) {
+		t.Errorf("Expected %v, got %v", want, voices)
+	}
+}
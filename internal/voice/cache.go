@@ -0,0 +1,256 @@
+package voice
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultCacheMaxMB is the default size limit of the speech cache
+const DefaultCacheMaxMB = 100
+
+// DefaultCacheDir returns the directory rendered speech is cached in:
+// ~/Library/Caches/emrys/speech on macOS and ~/.cache/emrys/speech elsewhere
+func DefaultCacheDir() string {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		homeDir, _ := os.UserHomeDir()
+		cacheDir = filepath.Join(homeDir, ".cache")
+	}
+	return filepath.Join(cacheDir, "emrys", "speech")
+}
+
+// Cache stores rendered utterances by a hash of their content, so phrases
+// that are spoken repeatedly are only synthesized once
+type Cache struct {
+	Dir      string
+	MaxBytes int64 // Least recently used files are removed beyond this size (0: no limit)
+
+	mu sync.Mutex
+}
+
+// NewCache creates a cache in dir limited to maxBytes
+// An empty dir uses DefaultCacheDir.
+func NewCache(dir string, maxBytes int64) *Cache {
+	if dir == "" {
+		dir = DefaultCacheDir()
+	}
+	return &Cache{Dir: dir, MaxBytes: maxBytes}
+}
+
+// CacheKey returns the content address of an utterance rendered by backend
+// Volume is not part of the key because it is applied during playback.
+func CacheKey(backend string, u Utterance) string {
+	h := sha256.New()
+	for _, field := range []string{backend, u.Voice, strconv.Itoa(u.Rate), u.Text} {
+		io.WriteString(h, field)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Lookup returns the path of the cached rendering of u, if there is one
+func (c *Cache) Lookup(r Renderer, u Utterance) (string, bool) {
+	path := c.path(r, u)
+	if _, err := os.Stat(path); err != nil {
+		return "", false
+	}
+	// Record the use so pruning removes the least recently used files
+	now := time.Now()
+	os.Chtimes(path, now, now)
+	return path, true
+}
+
+// Get returns the path of the cached rendering of u, rendering it with r
+// first if it is not cached. hit reports whether it was already cached.
+func (c *Cache) Get(ctx context.Context, r Renderer, u Utterance) (path string, hit bool, err error) {
+	if path, ok := c.Lookup(r, u); ok {
+		return path, true, nil
+	}
+	path = c.path(r, u)
+
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return "", false, fmt.Errorf("failed to create speech cache: %w", err)
+	}
+
+	// Render to a temporary name so a cancelled render never looks cached
+	tmp, err := os.CreateTemp(c.Dir, "render-*"+r.Extension())
+	if err != nil {
+		return "", false, fmt.Errorf("failed to create cache file: %w", err)
+	}
+	tmp.Close()
+
+	if err := r.Render(ctx, u, tmp.Name()); err != nil {
+		os.Remove(tmp.Name())
+		return "", false, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return "", false, fmt.Errorf("failed to store cache file: %w", err)
+	}
+
+	if err := c.prune(path); err != nil {
+		return "", false, err
+	}
+	return path, false, nil
+}
+
+// path returns where the rendering of u by r is cached
+func (c *Cache) path(r Renderer, u Utterance) string {
+	u.Volume = 0
+	return filepath.Join(c.Dir, CacheKey(r.Name(), u)+r.Extension())
+}
+
+// maxRememberedPhrases bounds how many spoken phrases a Speaker remembers
+// to tell which ones repeat
+const maxRememberedPhrases = 1000
+
+// phraseMemory remembers the phrases a Speaker has spoken, so that only
+// phrases that repeat, or were marked with CachePhrases, are rendered into
+// the cache. A one-off sentence is spoken directly instead of waiting for
+// a file to be written first.
+type phraseMemory struct {
+	mu     sync.Mutex
+	marked map[string]bool
+	seen   map[string]bool
+}
+
+// newPhraseMemory returns an empty phraseMemory
+func newPhraseMemory() *phraseMemory {
+	return &phraseMemory{marked: make(map[string]bool), seen: make(map[string]bool)}
+}
+
+// mark makes text cached the first time it is spoken
+func (m *phraseMemory) mark(text string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.marked[text] = true
+}
+
+// repeat records that text is being spoken and reports whether it is
+// marked or was spoken before
+func (m *phraseMemory) repeat(text string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.marked[text] || m.seen[text] {
+		return true
+	}
+	if len(m.seen) >= maxRememberedPhrases {
+		m.seen = make(map[string]bool)
+	}
+	m.seen[text] = true
+	return false
+}
+
+// prune removes the least recently used files until the cache fits in
+// MaxBytes. The file at keep is never removed.
+func (c *Cache) prune(keep string) error {
+	if c.MaxBytes <= 0 {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries, err := os.ReadDir(c.Dir)
+	if err != nil {
+		return fmt.Errorf("failed to read speech cache: %w", err)
+	}
+
+	type file struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	var files []file
+	var total int64
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		files = append(files, file{filepath.Join(c.Dir, entry.Name()), info.Size(), info.ModTime()})
+		total += info.Size()
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+	for _, f := range files {
+		if total <= c.MaxBytes {
+			break
+		}
+		if f.path == keep {
+			continue
+		}
+		if err := os.Remove(f.path); err == nil {
+			total -= f.size
+		}
+	}
+	return nil
+}
+
+// RenderToFile renders text with the voice settings in config to an audio
+// file at path, for use as an attachment. The format is the backend's: AIFF
+// for 'say' unless path ends in .wav, and WAV for espeak-ng and Piper.
+// Unless config.Cache is off, renderings come from and go to the cache.
+func RenderToFile(ctx context.Context, config Config, text, path string) error {
+	return renderToFile(ctx, SelectBackend(config), config, text, path)
+}
+
+// renderToFile implements RenderToFile with the given backend
+func renderToFile(ctx context.Context, backend Backend, config Config, text, path string) error {
+	renderer, ok := backend.(Renderer)
+	if !ok {
+		return fmt.Errorf("%s: %w", backend.Name(), ErrRenderUnsupported)
+	}
+
+	u := Utterance{Text: text, Voice: BackendVoice(ctx, backend, config.Voice), Rate: config.Rate}
+	cache := cacheFor(config)
+	if cache == nil || filepath.Ext(path) != renderer.Extension() {
+		return renderer.Render(ctx, u, path)
+	}
+
+	cached, _, err := cache.Get(ctx, renderer, u)
+	if err != nil {
+		return err
+	}
+	return copyFile(cached, path)
+}
+
+// copyFile copies the file at src to dst
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", src, err)
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", dst, err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to write %s: %w", dst, err)
+	}
+	return out.Close()
+}
+
+// cacheFor returns the speech cache config describes, or nil if it is off
+func cacheFor(config Config) *Cache {
+	if !config.Cache {
+		return nil
+	}
+	maxMB := config.CacheMaxMB
+	if maxMB <= 0 {
+		maxMB = DefaultCacheMaxMB
+	}
+	return NewCache(config.CacheDir, int64(maxMB)*1024*1024)
+}
@@ -0,0 +1,287 @@
+package voice
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// renderingBackend is a FakeBackend that can also render to files
+type renderingBackend struct {
+	*FakeBackend
+
+	mu      sync.Mutex
+	renders int
+}
+
+func (b *renderingBackend) Render(ctx context.Context, u Utterance, path string) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	b.mu.Lock()
+	b.renders++
+	b.mu.Unlock()
+	return os.WriteFile(path, []byte(u.Voice+":"+u.Text), 0644)
+}
+
+func (b *renderingBackend) Extension() string {
+	return ".wav"
+}
+
+func (b *renderingBackend) Renders() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.renders
+}
+
+func TestCacheGet(t *testing.T) {
+	cache := NewCache(t.TempDir(), 0)
+	backend := &renderingBackend{FakeBackend: NewFakeBackend()}
+	u := Utterance{Text: "Ollama service restarted", Voice: "Jamie", Rate: 200}
+
+	path, hit, err := cache.Get(context.Background(), backend, u)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if hit {
+		t.Error("Expected a miss on first use")
+	}
+
+	u.Volume = 0.3
+	again, hit, err := cache.Get(context.Background(), backend, u)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if !hit || again != path {
+		t.Errorf("Expected a hit on %s, got hit=%v path=%s", path, hit, again)
+	}
+	if backend.Renders() != 1 {
+		t.Errorf("Expected 1 render, got %d", backend.Renders())
+	}
+
+	data, _ := os.ReadFile(path)
+	if string(data) != "Jamie:Ollama service restarted" {
+		t.Errorf("Unexpected cached content %q", data)
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	base := Utterance{Text: "hello", Voice: "Jamie", Rate: 200}
+	key := CacheKey("say", base)
+
+	variants := []struct {
+		backend string
+		u       Utterance
+	}{
+		{"piper", base},
+		{"say", Utterance{Text: "hello!", Voice: "Jamie", Rate: 200}},
+		{"say", Utterance{Text: "hello", Voice: "Alex", Rate: 200}},
+		{"say", Utterance{Text: "hello", Voice: "Jamie", Rate: 180}},
+	}
+	for _, v := range variants {
+		if CacheKey(v.backend, v.u) == key {
+			t.Errorf("Expected %s %+v to have a different key", v.backend, v.u)
+		}
+	}
+
+	// Field boundaries matter: "ab"+"c" must not collide with "a"+"bc"
+	if CacheKey("say", Utterance{Voice: "ab", Text: "c"}) == CacheKey("say", Utterance{Voice: "a", Text: "bc"}) {
+		t.Error("Expected field boundaries to be part of the key")
+	}
+}
+
+func TestCachePrune(t *testing.T) {
+	dir := t.TempDir()
+	cache := NewCache(dir, 40)
+	backend := &renderingBackend{FakeBackend: NewFakeBackend()}
+
+	old, _, _ := cache.Get(context.Background(), backend, Utterance{Text: "first phrase here"})
+	past := time.Now().Add(-time.Hour)
+	os.Chtimes(old, past, past)
+
+	newest, _, err := cache.Get(context.Background(), backend, Utterance{Text: "second phrase here and more"})
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Error("Expected least recently used file to be pruned")
+	}
+	if _, err := os.Stat(newest); err != nil {
+		t.Errorf("Expected newest file to be kept: %v", err)
+	}
+}
+
+func TestCacheCancelledRender(t *testing.T) {
+	dir := t.TempDir()
+	cache := NewCache(dir, 0)
+	backend := &renderingBackend{FakeBackend: NewFakeBackend()}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := cache.Get(ctx, backend, Utterance{Text: "hi"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("Expected no files after a cancelled render, got %d", len(entries))
+	}
+}
+
+func TestSpeakerPlaysFromCache(t *testing.T) {
+	config := DefaultConfig()
+	config.CacheDir = t.TempDir()
+	config.Volume = 0.5
+	backend := &renderingBackend{FakeBackend: NewFakeBackend()}
+	speaker := NewSpeakerWithBackend(config, backend)
+	defer speaker.Close()
+
+	var mu sync.Mutex
+	var played []string
+	var gains []float64
+	speaker.play = func(_ context.Context, path string, gain float64) error {
+		mu.Lock()
+		defer mu.Unlock()
+		played = append(played, path)
+		gains = append(gains, gain)
+		return nil
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := speaker.SpeakSync("Ollama service restarted."); err != nil {
+			t.Fatalf("SpeakSync failed: %v", err)
+		}
+	}
+
+	if len(backend.Spoken()) != 1 {
+		t.Errorf("Expected the first occurrence to be spoken directly, got %d", len(backend.Spoken()))
+	}
+	if backend.Renders() != 1 {
+		t.Errorf("Expected the repeated phrase to be rendered once, got %d", backend.Renders())
+	}
+	if len(played) != 2 || played[0] != played[1] {
+		t.Errorf("Expected the cached file to be played twice, got %v", played)
+	}
+	if !reflect.DeepEqual(gains, []float64{0.5, 0.5}) {
+		t.Errorf("Expected playback gain 0.5, got %v", gains)
+	}
+}
+
+func TestSpeakerCachesMarkedPhrases(t *testing.T) {
+	config := DefaultConfig()
+	config.CacheDir = t.TempDir()
+	backend := &renderingBackend{FakeBackend: NewFakeBackend()}
+	speaker := NewSpeakerWithBackend(config, backend)
+	defer speaker.Close()
+	played := 0
+	speaker.play = func(context.Context, string, float64) error {
+		played++
+		return nil
+	}
+
+	speaker.CachePhrases("Going back to sleep.")
+	for _, text := range []string{"Going back to sleep.", "Something new."} {
+		if err := speaker.SpeakSync(text); err != nil {
+			t.Fatalf("SpeakSync failed: %v", err)
+		}
+	}
+
+	if backend.Renders() != 1 || played != 1 {
+		t.Errorf("Expected the marked phrase to be rendered and played, got %d renders and %d plays", backend.Renders(), played)
+	}
+	if !reflect.DeepEqual(backend.Texts(), []string{"Something new."}) {
+		t.Errorf("Expected only the unmarked phrase to be spoken directly, got %v", backend.Texts())
+	}
+}
+
+func TestSpeakerFallsBackWhenPlaybackFails(t *testing.T) {
+	config := DefaultConfig()
+	config.CacheDir = t.TempDir()
+	backend := &renderingBackend{FakeBackend: NewFakeBackend()}
+	speaker := NewSpeakerWithBackend(config, backend)
+	defer speaker.Close()
+	speaker.play = func(context.Context, string, float64) error {
+		return errors.New("no audio player")
+	}
+
+	speaker.CachePhrases("Hello.")
+	if err := speaker.SpeakSync("Hello."); err != nil {
+		t.Fatalf("Expected the failed playback to fall back, got %v", err)
+	}
+	if !reflect.DeepEqual(backend.Texts(), []string{"Hello."}) {
+		t.Errorf("Expected the phrase to be spoken directly, got %v", backend.Texts())
+	}
+}
+
+func TestSpeakerCacheDisabled(t *testing.T) {
+	config := DefaultConfig()
+	config.Cache = false
+	backend := &renderingBackend{FakeBackend: NewFakeBackend()}
+	speaker := NewSpeakerWithBackend(config, backend)
+	defer speaker.Close()
+
+	if err := speaker.SpeakSync("Hello."); err != nil {
+		t.Fatalf("SpeakSync failed: %v", err)
+	}
+	if backend.Renders() != 0 || len(backend.Spoken()) != 1 {
+		t.Errorf("Expected direct speech, got %d renders and %d spoken", backend.Renders(), len(backend.Spoken()))
+	}
+}
+
+func TestRenderToFile(t *testing.T) {
+	config := DefaultConfig()
+	config.CacheDir = t.TempDir()
+	backend := &renderingBackend{FakeBackend: NewFakeBackend()}
+	backend.Voices = []string{"Jamie"}
+	out := filepath.Join(t.TempDir(), "announcement.wav")
+
+	for i := 0; i < 2; i++ {
+		if err := renderToFile(context.Background(), backend, config, "Backup complete", out); err != nil {
+			t.Fatalf("renderToFile failed: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "Jamie:Backup complete" {
+		t.Errorf("Unexpected file content %q", data)
+	}
+	if backend.Renders() != 1 {
+		t.Errorf("Expected the second render to come from the cache, got %d renders", backend.Renders())
+	}
+}
+
+func TestRenderToFileUnsupported(t *testing.T) {
+	err := renderToFile(context.Background(), NewFakeBackend(), DefaultConfig(), "hi", filepath.Join(t.TempDir(), "x.wav"))
+	if !errors.Is(err, ErrRenderUnsupported) {
+		t.Errorf("Expected ErrRenderUnsupported, got %v", err)
+	}
+}
+
+func TestPlayerArgs(t *testing.T) {
+	tests := []struct {
+		player string
+		gain   float64
+		want   []string
+	}{
+		{"afplay", 0, []string{"a.wav"}},
+		{"afplay", 0.5, []string{"-v", "0.50", "a.wav"}},
+		{"paplay", 0.5, []string{"--volume=32768", "a.wav"}},
+		{"ffplay", 0.25, []string{"-volume", "25", "-nodisp", "-autoexit", "-loglevel", "quiet", "a.wav"}},
+		{"aplay", 0.5, []string{"a.wav"}},
+	}
+
+	for _, tt := range tests {
+		if got := playerArgs(tt.player, "a.wav", tt.gain); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("playerArgs(%s, %g) = %v, expected %v", tt.player, tt.gain, got, tt.want)
+		}
+	}
+}
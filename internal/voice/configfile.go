@@ -0,0 +1,218 @@
+package voice
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Limits enforced by Config.Validate
+const (
+	MinRate = 50
+	MaxRate = 500
+)
+
+// FieldError is a validation error for one configuration key
+type FieldError struct {
+	Key string
+	Err error
+}
+
+// Error returns the message, which names the key
+func (e *FieldError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// Validate checks that configuration values are within range
+// Each problem is reported as a *FieldError, joined with errors.Join.
+func (c Config) Validate() error {
+	var errs []error
+	invalid := func(key, format string, args ...any) {
+		errs = append(errs, &FieldError{Key: key, Err: fmt.Errorf(key+" "+format, args...)})
+	}
+
+	if c.Rate != 0 && (c.Rate < MinRate || c.Rate > MaxRate) {
+		invalid("rate", "must be between %d and %d, got %d", MinRate, MaxRate, c.Rate)
+	}
+	for _, v := range []struct {
+		key   string
+		value float64
+	}{
+		{"volume", c.Volume},
+		{"critical_volume", c.CriticalVolume},
+		{"chatter_volume", c.ChatterVolume},
+	} {
+		if v.value < 0 || v.value > 1 {
+			invalid(v.key, "must be between 0.0 and 1.0, got %g", v.value)
+		}
+	}
+	if c.QuietStart < 0 || c.QuietStart > 23 {
+		invalid("quiet_start", "must be an hour from 0 to 23, got %d", c.QuietStart)
+	}
+	if c.QuietEnd < 0 || c.QuietEnd > 23 {
+		invalid("quiet_end", "must be an hour from 0 to 23, got %d", c.QuietEnd)
+	}
+	switch c.Backend {
+	case "", BackendAuto, BackendSay, BackendEspeak, BackendPiper:
+	default:
+		invalid("backend", "must be one of auto, say, espeak-ng or piper, got %q", c.Backend)
+	}
+
+	if c.CacheMaxMB < 0 {
+		invalid("cache_max_mb", "must not be negative, got %d", c.CacheMaxMB)
+	}
+	if c.QueueSize < 0 {
+		invalid("queue_size", "must not be negative, got %d", c.QueueSize)
+	}
+	switch c.Overflow {
+	case "", OverflowDropOldest, OverflowDropNew:
+	default:
+		invalid("overflow", "must be %s or %s, got %q", OverflowDropOldest, OverflowDropNew, c.Overflow)
+	}
+	switch c.QuietPolicy {
+	case "", QuietDrop, QuietDefer, QuietNotify:
+	default:
+		invalid("quiet_policy", "must be %s, %s or %s, got %q", QuietDrop, QuietDefer, QuietNotify, c.QuietPolicy)
+	}
+
+	// Check the parts of the quiet-hours schedule separately so that each
+	// error names its key
+	for _, part := range []struct {
+		key                         string
+		windows, timezone, holidays string
+	}{
+		{key: "quiet_windows", windows: c.QuietWindows},
+		{key: "timezone", timezone: c.Timezone},
+		{key: "holidays", holidays: c.Holidays},
+	} {
+		if _, err := ParseSchedule(part.windows, part.timezone, part.holidays); err != nil {
+			errs = append(errs, &FieldError{Key: part.key, Err: err})
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// LoadConfig reads and validates a voice.conf file
+func LoadConfig(path string) (Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to open voice configuration: %w", err)
+	}
+	defer f.Close()
+
+	config, err := ParseConfig(f)
+	if err != nil {
+		return Config{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return config, nil
+}
+
+// ParseConfig parses the "key = value" voice.conf format
+// Blank lines and lines starting with '#' are ignored. Keys that are not
+// present keep their DefaultConfig values. Unknown keys are errors so that
+// typos do not go unnoticed.
+func ParseConfig(r io.Reader) (Config, error) {
+	config := DefaultConfig()
+	var errs []error
+
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			errs = append(errs, fmt.Errorf("line %d: expected 'key = value'", lineNum))
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		if key == "version" {
+			// Written by older versions of Emrys; voice.conf is only imported now
+			continue
+		}
+		if err := setConfigValue(&config, key, value); err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %w", lineNum, err))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Config{}, fmt.Errorf("failed to read voice configuration: %w", err)
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return Config{}, err
+	}
+	if err := config.Validate(); err != nil {
+		return Config{}, err
+	}
+	return config, nil
+}
+
+// setConfigValue assigns a single key from the configuration file
+func setConfigValue(config *Config, key, value string) error {
+	var err error
+	switch key {
+	case "enabled":
+		config.Enabled, err = strconv.ParseBool(value)
+	case "voice":
+		config.Voice = value
+	case "rate":
+		config.Rate, err = strconv.Atoi(value)
+	case "volume":
+		config.Volume, err = strconv.ParseFloat(value, 64)
+	case "quiet_hours":
+		config.QuietHours, err = strconv.ParseBool(value)
+	case "quiet_start":
+		config.QuietStart, err = strconv.Atoi(value)
+	case "quiet_end":
+		config.QuietEnd, err = strconv.Atoi(value)
+	case "backend":
+		config.Backend = value
+	case "piper_model_dir":
+		config.PiperModelDir = value
+	case "queue_size":
+		config.QueueSize, err = strconv.Atoi(value)
+	case "overflow":
+		config.Overflow = value
+	case "quiet_windows":
+		config.QuietWindows = value
+	case "timezone":
+		config.Timezone = value
+	case "holidays":
+		config.Holidays = value
+	case "quiet_policy":
+		config.QuietPolicy = value
+	case "critical_volume":
+		config.CriticalVolume, err = strconv.ParseFloat(value, 64)
+	case "chatter_volume":
+		config.ChatterVolume, err = strconv.ParseFloat(value, 64)
+	case "cache":
+		config.Cache, err = strconv.ParseBool(value)
+	case "cache_dir":
+		config.CacheDir = value
+	case "cache_max_mb":
+		config.CacheMaxMB, err = strconv.Atoi(value)
+	default:
+		return fmt.Errorf("unknown key %q", key)
+	}
+
+	if err != nil {
+		return fmt.Errorf("invalid value %q for %s", value, key)
+	}
+	return nil
+}
@@ -0,0 +1,293 @@
+package voice
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseConfig(t *testing.T) {
+	input := `# Emrys Voice Output Configuration
+version = 1
+enabled = false
+
+voice = Samantha
+rate = 180
+volume = 0.5
+quiet_hours = false
+quiet_start = 23
+quiet_end = 6
+backend = espeak-ng
+piper_model_dir = /opt/piper
+queue_size = 20
+overflow = drop-new
+quiet_windows = mon-fri 22:30-07:00; sat,sun 23:00-09:00
+timezone = America/Toronto
+holidays = 2026-12-25, 2027-01-01
+quiet_policy = defer
+cache = false
+cache_dir = /tmp/speech
+cache_max_mb = 10
+`
+	config, err := ParseConfig(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseConfig failed: %v", err)
+	}
+
+	want := Config{
+		Enabled:       false,
+		Voice:         "Samantha",
+		Rate:          180,
+		Volume:        0.5,
+		QuietHours:    false,
+		QuietStart:    23,
+		QuietEnd:      6,
+		Backend:       BackendEspeak,
+		PiperModelDir: "/opt/piper",
+		QueueSize:     20,
+		Overflow:      OverflowDropNew,
+		QuietWindows:  "mon-fri 22:30-07:00; sat,sun 23:00-09:00",
+		Timezone:      "America/Toronto",
+		Holidays:      "2026-12-25, 2027-01-01",
+		QuietPolicy:   QuietDefer,
+		Cache:         false,
+		CacheDir:      "/tmp/speech",
+		CacheMaxMB:    10,
+	}
+	if config != want {
+		t.Errorf("Expected %+v, got %+v", want, config)
+	}
+}
+
+func TestParseConfigDefaults(t *testing.T) {
+	config, err := ParseConfig(strings.NewReader("voice = Alex\n"))
+	if err != nil {
+		t.Fatalf("ParseConfig failed: %v", err)
+	}
+
+	want := DefaultConfig()
+	want.Voice = "Alex"
+	if config != want {
+		t.Errorf("Expected defaults with voice Alex, got %+v", config)
+	}
+}
+
+func TestParseConfigErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"missing equals", "voice Jamie", "line 1: expected 'key = value'"},
+		{"unknown key", "\nspeed = 200", `line 2: unknown key "speed"`},
+		{"bad bool", "enabled = maybe", `invalid value "maybe" for enabled`},
+		{"bad int", "rate = fast", `invalid value "fast" for rate`},
+		{"rate out of range", "rate = 1000", "rate must be between"},
+		{"volume out of range", "volume = 1.5", "volume must be between"},
+		{"hour out of range", "quiet_start = 24", "quiet_start must be an hour"},
+		{"unknown backend", "backend = festival", "backend must be one of"},
+		{"negative queue size", "queue_size = -1", "queue_size must not be negative"},
+		{"unknown overflow", "overflow = drop-all", "overflow must be"},
+		{"unknown quiet policy", "quiet_policy = mute", "quiet_policy must be"},
+		{"bad quiet window", "quiet_windows = 22:00", "expected HH:MM-HH:MM"},
+		{"bad timezone", "timezone = Mars/Olympus", "invalid timezone"},
+		{"bad holiday", "holidays = 12/25", "invalid holiday"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseConfig(strings.NewReader(tt.input))
+			if err == nil {
+				t.Fatal("Expected error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %q", tt.want, err.Error())
+			}
+		})
+	}
+}
+
+func TestParseConfigReportsAllErrors(t *testing.T) {
+	_, err := ParseConfig(strings.NewReader("rate = fast\nvolume = loud\n"))
+	if err == nil {
+		t.Fatal("Expected error")
+	}
+	for _, want := range []string{"line 1", "line 2"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to mention %s, got %q", want, err.Error())
+		}
+	}
+}
+
+func TestLoadConfigMissingFile(t *testing.T) {
+	_, err := LoadConfig(filepath.Join(t.TempDir(), "voice.conf"))
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected not-exist error, got %v", err)
+	}
+}
+
+// errorLog collects watcher errors
+type errorLog struct {
+	mu   sync.Mutex
+	errs []error
+}
+
+func (l *errorLog) add(err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.errs = append(l.errs, err)
+}
+
+func (l *errorLog) count() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.errs)
+}
+
+// waitFor polls cond until it is true or the deadline passes
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for condition")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// loadConfig returns a loader for the voice.conf at path
+func loadConfig(path string) func() (Config, error) {
+	return func() (Config, error) { return LoadConfig(path) }
+}
+
+func TestWatchConfigReloads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "voice.conf")
+	if err := os.WriteFile(path, []byte("voice = Jamie\nrate = 200\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	speaker := NewSpeakerWithBackend(DefaultConfig(), NewFakeBackend())
+	defer speaker.Close()
+
+	var errs errorLog
+	watcher, err := WatchConfig(path, loadConfig(path), speaker, 10*time.Millisecond, errs.add)
+	if err != nil {
+		t.Fatalf("WatchConfig failed: %v", err)
+	}
+	defer watcher.Close()
+
+	if got := speaker.GetConfig().Voice; got != "Jamie" {
+		t.Errorf("Expected initial voice Jamie, got %s", got)
+	}
+
+	if err := os.WriteFile(path, []byte("voice = Samantha\nrate = 170\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return speaker.GetConfig().Voice == "Samantha" })
+
+	if got := speaker.GetConfig().Rate; got != 170 {
+		t.Errorf("Expected rate 170, got %d", got)
+	}
+	if errs.count() != 0 {
+		t.Errorf("Expected no errors, got %v", errs.errs)
+	}
+}
+
+func TestWatchConfigKeepsLastGoodConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "voice.conf")
+	if err := os.WriteFile(path, []byte("voice = Jamie\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	speaker := NewSpeakerWithBackend(DefaultConfig(), NewFakeBackend())
+	defer speaker.Close()
+
+	var errs errorLog
+	watcher, err := WatchConfig(path, loadConfig(path), speaker, 10*time.Millisecond, errs.add)
+	if err != nil {
+		t.Fatalf("WatchConfig failed: %v", err)
+	}
+	defer watcher.Close()
+
+	if err := os.WriteFile(path, []byte("voice = Samantha\nrate = 9000\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return errs.count() > 0 })
+
+	// Give the watcher a few more polls to make sure the error is not repeated
+	time.Sleep(50 * time.Millisecond)
+	if got := errs.count(); got != 1 {
+		t.Errorf("Expected the error to be reported once, got %d", got)
+	}
+	if got := speaker.GetConfig().Voice; got != "Jamie" {
+		t.Errorf("Expected last good voice Jamie, got %s", got)
+	}
+
+	if err := os.WriteFile(path, []byte("voice = Samantha\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return speaker.GetConfig().Voice == "Samantha" })
+}
+
+func TestWatchConfigInvalidInitialFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "voice.conf")
+	if err := os.WriteFile(path, []byte("volume = 3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	speaker := NewSpeakerWithBackend(DefaultConfig(), NewFakeBackend())
+	defer speaker.Close()
+
+	if _, err := WatchConfig(path, loadConfig(path), speaker, time.Second, nil); err == nil {
+		t.Error("Expected error for invalid initial config")
+	}
+}
+
+func TestWatchConfigMissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "voice.conf")
+	load := func() (Config, error) {
+		config, err := LoadConfig(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return DefaultConfig(), nil
+		}
+		return config, err
+	}
+
+	speaker := NewSpeakerWithBackend(DefaultConfig(), NewFakeBackend())
+	defer speaker.Close()
+
+	var errs errorLog
+	watcher, err := WatchConfig(path, load, speaker, 10*time.Millisecond, errs.add)
+	if err != nil {
+		t.Fatalf("Expected a missing file to be watched, got %v", err)
+	}
+	defer watcher.Close()
+
+	// Creating the file is a change
+	if err := os.WriteFile(path, []byte("voice = Samantha\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return speaker.GetConfig().Voice == "Samantha" })
+	if errs.count() != 0 {
+		t.Errorf("Expected no errors, got %v", errs.errs)
+	}
+}
+
+func TestUpdateConfigKeepsExplicitBackend(t *testing.T) {
+	backend := NewFakeBackend()
+	speaker := NewSpeakerWithBackend(DefaultConfig(), backend)
+	defer speaker.Close()
+
+	config := DefaultConfig()
+	config.Backend = BackendEspeak
+	speaker.UpdateConfig(config)
+
+	if speaker.Backend() != backend {
+		t.Error("Expected explicitly supplied backend to be kept")
+	}
+}
@@ -0,0 +1,104 @@
+package voice
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os/exec"
+	"strings"
+
+	"github.com/anicolao/emrys/internal/audit"
+)
+
+// EspeakBackend speaks with espeak-ng, available on most Linux systems
+type EspeakBackend struct{}
+
+// NewEspeakBackend creates a backend for espeak-ng
+func NewEspeakBackend() *EspeakBackend {
+	return &EspeakBackend{}
+}
+
+// Name returns "espeak-ng"
+func (b *EspeakBackend) Name() string {
+	return BackendEspeak
+}
+
+// Available reports whether espeak-ng is installed
+func (b *EspeakBackend) Available() bool {
+	return commandExists("espeak-ng")
+}
+
+// Capabilities reports voice and rate support
+func (b *EspeakBackend) Capabilities() Capabilities {
+	return Capabilities{Voices: true, Rate: true, Volume: true}
+}
+
+// Speak runs espeak-ng with the utterance's voice, rate and volume
+func (b *EspeakBackend) Speak(ctx context.Context, u Utterance) error {
+	cmd := exec.CommandContext(ctx, "espeak-ng", espeakArgs(u)...)
+	if err := audit.Run(auditInitiator, cmd); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("espeak-ng failed: %w", err)
+	}
+	return nil
+}
+
+// Render writes the utterance to a WAV file with 'espeak-ng -w'
+func (b *EspeakBackend) Render(ctx context.Context, u Utterance, path string) error {
+	args := append([]string{"-w", path}, espeakArgs(u)...)
+	cmd := exec.CommandContext(ctx, "espeak-ng", args...)
+	if output, err := audit.CombinedOutput(auditInitiator, cmd); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("espeak-ng failed: %w (output: %s)", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// Extension returns ".wav"
+func (b *EspeakBackend) Extension() string {
+	return ".wav"
+}
+
+// espeakArgs builds the espeak-ng arguments for an utterance
+func espeakArgs(u Utterance) []string {
+	args := []string{}
+	if u.Voice != "" {
+		args = append(args, "-v", u.Voice)
+	}
+	if u.Rate != 0 {
+		args = append(args, "-s", fmt.Sprintf("%d", u.Rate))
+	}
+	// Amplitude runs from 0 to 200 with 100 as the default
+	if gain := u.gain(); gain > 0 {
+		args = append(args, "-a", fmt.Sprintf("%d", int(math.Round(gain*100))))
+	}
+	return append(args, u.Text)
+}
+
+// ListVoices runs 'espeak-ng --voices' and returns the voice names
+func (b *EspeakBackend) ListVoices(ctx context.Context) ([]string, error) {
+	output, err := audit.Output(auditInitiator, exec.CommandContext(ctx, "espeak-ng", "--voices"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list voices: %w", err)
+	}
+	return parseEspeakVoices(string(output)), nil
+}
+
+// parseEspeakVoices extracts voice names from 'espeak-ng --voices' output
+// Listing format: "Pty Language Age/Gender VoiceName File Other Languages"
+func parseEspeakVoices(output string) []string {
+	var voices []string
+	for i, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		// Skip the header line
+		if i == 0 || len(fields) < 4 {
+			continue
+		}
+		voices = append(voices, fields[3])
+	}
+	return voices
+}
@@ -0,0 +1,144 @@
+package voice
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// FakeBackend records utterances instead of speaking them, for tests
+type FakeBackend struct {
+	Voices   []string      // Returned by ListVoices
+	Delay    time.Duration // Simulated speaking time per utterance
+	Err      error         // Returned by Speak when set
+	Disabled bool          // Makes Available return false
+
+	mu     sync.Mutex
+	spoken []Utterance
+}
+
+// NewFakeBackend creates a recording backend
+func NewFakeBackend() *FakeBackend {
+	return &FakeBackend{}
+}
+
+// Name returns "fake"
+func (f *FakeBackend) Name() string {
+	return "fake"
+}
+
+// Available returns true unless Disabled is set
+func (f *FakeBackend) Available() bool {
+	return !f.Disabled
+}
+
+// Capabilities reports full support
+func (f *FakeBackend) Capabilities() Capabilities {
+	return Capabilities{Voices: true, Rate: true, Volume: true}
+}
+
+// Speak waits for Delay, then records the utterance
+// If ctx is cancelled first, the utterance is not recorded.
+func (f *FakeBackend) Speak(ctx context.Context, u Utterance) error {
+	if f.Err != nil {
+		return f.Err
+	}
+
+	if f.Delay > 0 {
+		select {
+		case <-time.After(f.Delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.spoken = append(f.spoken, u)
+	return nil
+}
+
+// ListVoices returns Voices
+func (f *FakeBackend) ListVoices(ctx context.Context) ([]string, error) {
+	return f.Voices, nil
+}
+
+// Spoken returns the utterances spoken so far
+func (f *FakeBackend) Spoken() []Utterance {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Utterance(nil), f.spoken...)
+}
+
+// Texts returns the text of the utterances spoken so far
+func (f *FakeBackend) Texts() []string {
+	var texts []string
+	for _, u := range f.Spoken() {
+		texts = append(texts, u.Text)
+	}
+	return texts
+}
+
+// FakeClock is a Clock that only moves when told to, for tests
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+// fakeWaiter is a pending After call
+type fakeWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+// NewFakeClock creates a clock stopped at now
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now returns the clock's current time
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// After returns a channel that receives once the clock is advanced by d
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	at := c.now.Add(d)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, fakeWaiter{at: at, ch: ch})
+	return ch
+}
+
+// Advance moves the clock forward and fires any After channels now due
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	pending := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			pending = append(pending, w)
+			continue
+		}
+		w.ch <- c.now
+	}
+	c.waiters = pending
+}
+
+// Waiters returns the number of After calls that have not fired yet
+func (c *FakeClock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
@@ -0,0 +1,142 @@
+package voice
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultHistorySize is the number of utterances a Speaker remembers
+const DefaultHistorySize = 100
+
+// Status is what happened to an utterance
+type Status string
+
+// Utterance statuses
+const (
+	StatusQueued      Status = "queued"      // Waiting to be spoken
+	StatusSpeaking    Status = "speaking"    // Being spoken now
+	StatusSpoken      Status = "spoken"      // Spoken in full
+	StatusQuiet       Status = "quiet"       // Skipped for quiet hours
+	StatusNotified    Status = "notified"    // Shown as a notification during quiet hours
+	StatusMuted       Status = "muted"       // Skipped because its volume is zero
+	StatusDropped     Status = "dropped"     // Removed from the queue before it was spoken
+	StatusInterrupted Status = "interrupted" // Cut off while being spoken
+	StatusFailed      Status = "failed"      // The backend reported an error
+)
+
+// Record describes one utterance: a sentence of a message
+type Record struct {
+	Message  uint64 // Sentences of the same message share this id
+	Text     string
+	Priority Priority
+	Status   Status
+	Backend  string
+	Cached   bool   // Played from the speech cache
+	Error    string // Set when Status is StatusFailed
+	Queued   time.Time
+	Started  time.Time // Zero if the utterance never started
+	Finished time.Time // Zero while queued or speaking
+}
+
+// EventKind distinguishes Speaker events
+type EventKind string
+
+// Event kinds
+const (
+	EventQueued   EventKind = "queued"
+	EventStarted  EventKind = "started"
+	EventFinished EventKind = "finished"
+)
+
+// Event reports a change in an utterance's status
+type Event struct {
+	Kind   EventKind
+	Record Record
+}
+
+// history keeps the most recent finished utterances and fans events out to
+// subscribers
+type history struct {
+	mu          sync.Mutex
+	size        int
+	records     []Record
+	subscribers map[int]chan Event
+	nextSub     int
+}
+
+// newHistory creates a history holding up to size records
+func newHistory(size int) *history {
+	return &history{size: size, subscribers: make(map[int]chan Event)}
+}
+
+// publish sends an event to subscribers and records finished utterances
+// Subscribers that are not keeping up miss events rather than stall speech.
+func (h *history) publish(kind EventKind, rec Record) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if kind == EventFinished {
+		if len(h.records) == h.size {
+			h.records = append(h.records[:0], h.records[1:]...)
+		}
+		h.records = append(h.records, rec)
+	}
+
+	for _, ch := range h.subscribers {
+		select {
+		case ch <- Event{Kind: kind, Record: rec}:
+		default:
+		}
+	}
+}
+
+// subscribe registers a subscriber channel with the given buffer size
+func (h *history) subscribe(buffer int) (<-chan Event, func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	id := h.nextSub
+	h.nextSub++
+	ch := make(chan Event, buffer)
+	h.subscribers[id] = ch
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			delete(h.subscribers, id)
+			close(ch)
+		})
+	}
+}
+
+// list returns a copy of the records, oldest first
+func (h *history) list() []Record {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]Record(nil), h.records...)
+}
+
+// History returns the most recent utterances, oldest first
+func (s *Speaker) History() []Record {
+	return s.history.list()
+}
+
+// LastSpoken returns the most recent utterance that was spoken in full
+func (s *Speaker) LastSpoken() (Record, bool) {
+	records := s.history.list()
+	for i := len(records) - 1; i >= 0; i-- {
+		if records[i].Status == StatusSpoken {
+			return records[i], true
+		}
+	}
+	return Record{}, false
+}
+
+// Subscribe returns a channel of events for every utterance and a function
+// that ends the subscription and closes the channel. Events are dropped for
+// a subscriber whose buffer is full.
+func (s *Speaker) Subscribe(buffer int) (<-chan Event, func()) {
+	return s.history.subscribe(buffer)
+}
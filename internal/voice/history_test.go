@@ -0,0 +1,186 @@
+package voice
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// statuses returns the status of each record in the speaker's history
+func statuses(speaker *Speaker) map[string]Status {
+	got := make(map[string]Status)
+	for _, rec := range speaker.History() {
+		got[rec.Text] = rec.Status
+	}
+	return got
+}
+
+func TestHistoryRing(t *testing.T) {
+	h := newHistory(2)
+	for _, text := range []string{"one", "two", "three"} {
+		h.publish(EventQueued, Record{Text: text})
+		h.publish(EventFinished, Record{Text: text, Status: StatusSpoken})
+	}
+
+	records := h.list()
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 records, got %d", len(records))
+	}
+	if records[0].Text != "two" || records[1].Text != "three" {
+		t.Errorf("Expected the two most recent records, got %v", records)
+	}
+}
+
+func TestHistoryRecordsSpokenMessages(t *testing.T) {
+	speaker := NewSpeakerWithBackend(DefaultConfig(), NewFakeBackend())
+	defer speaker.Close()
+
+	if _, ok := speaker.LastSpoken(); ok {
+		t.Error("Expected no last spoken message before speaking")
+	}
+
+	speaker.Speak("Hello there.")
+	waitFor(t, func() bool { _, ok := speaker.LastSpoken(); return ok })
+
+	rec, _ := speaker.LastSpoken()
+	if rec.Text != "Hello there." {
+		t.Errorf("Expected 'Hello there.', got %q", rec.Text)
+	}
+	if rec.Status != StatusSpoken {
+		t.Errorf("Expected status %q, got %q", StatusSpoken, rec.Status)
+	}
+	if rec.Backend != "fake" {
+		t.Errorf("Expected backend 'fake', got %q", rec.Backend)
+	}
+	if rec.Priority != PriorityNormal {
+		t.Errorf("Expected normal priority, got %s", rec.Priority)
+	}
+	if rec.Queued.IsZero() || rec.Started.IsZero() || rec.Finished.IsZero() {
+		t.Errorf("Expected all timestamps to be set, got %+v", rec)
+	}
+	if rec.Finished.Before(rec.Started) || rec.Started.Before(rec.Queued) {
+		t.Errorf("Expected queued <= started <= finished, got %+v", rec)
+	}
+}
+
+func TestHistoryDroppedAndInterrupted(t *testing.T) {
+	backend := &FakeBackend{Delay: time.Second}
+	speaker := NewSpeakerWithBackend(DefaultConfig(), backend)
+	defer speaker.Close()
+
+	speaker.Speak("interrupted")
+	waitFor(t, speaker.IsSpeaking)
+	speaker.Speak("flushed")
+	speaker.Flush()
+
+	waitFor(t, func() bool { return len(speaker.History()) == 2 })
+
+	want := map[string]Status{"interrupted": StatusInterrupted, "flushed": StatusDropped}
+	got := statuses(speaker)
+	for text, status := range want {
+		if got[text] != status {
+			t.Errorf("Expected %q to be %q, got %q", text, status, got[text])
+		}
+	}
+}
+
+func TestHistoryQuietMessages(t *testing.T) {
+	speaker, _, _ := quietSpeaker(QuietDrop)
+	defer speaker.Close()
+
+	speaker.Speak("Too late.")
+	waitFor(t, func() bool { return len(speaker.History()) == 1 })
+
+	if got := speaker.History()[0].Status; got != StatusQuiet {
+		t.Errorf("Expected status %q, got %q", StatusQuiet, got)
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent writes
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestHistoryFailuresAreLogged(t *testing.T) {
+	backend := &FakeBackend{Err: errors.New("no audio device")}
+	speaker := NewSpeakerWithBackend(DefaultConfig(), backend)
+	defer speaker.Close()
+
+	var logs syncBuffer
+	speaker.SetLogger(slog.New(slog.NewTextHandler(&logs, nil)))
+
+	speaker.Speak("Broken.")
+	waitFor(t, func() bool { return len(speaker.History()) == 1 })
+
+	rec := speaker.History()[0]
+	if rec.Status != StatusFailed {
+		t.Errorf("Expected status %q, got %q", StatusFailed, rec.Status)
+	}
+	if rec.Error != "no audio device" {
+		t.Errorf("Expected the backend error, got %q", rec.Error)
+	}
+
+	output := logs.String()
+	for _, want := range []string{"level=ERROR", "backend=fake", `error="no audio device"`} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected log to contain %s, got %q", want, output)
+		}
+	}
+}
+
+func TestSpeakerSubscribe(t *testing.T) {
+	speaker := NewSpeakerWithBackend(DefaultConfig(), NewFakeBackend())
+	defer speaker.Close()
+
+	events, unsubscribe := speaker.Subscribe(10)
+	speaker.Speak("Watched.")
+
+	var kinds []EventKind
+	timeout := time.After(2 * time.Second)
+	for len(kinds) < 3 {
+		select {
+		case event := <-events:
+			if event.Record.Text != "Watched." {
+				t.Errorf("Expected events for 'Watched.', got %q", event.Record.Text)
+			}
+			kinds = append(kinds, event.Kind)
+		case <-timeout:
+			t.Fatalf("Timed out waiting for events, got %v", kinds)
+		}
+	}
+
+	want := []EventKind{EventQueued, EventStarted, EventFinished}
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Errorf("Expected events %v, got %v", want, kinds)
+			break
+		}
+	}
+
+	unsubscribe()
+	unsubscribe()
+	if _, ok := <-events; ok {
+		t.Error("Expected the channel to be closed after unsubscribing")
+	}
+
+	// Speaking after unsubscribing must not panic or block
+	speaker.Speak("Unwatched.")
+	waitFor(t, func() bool { return len(speaker.History()) == 2 })
+}
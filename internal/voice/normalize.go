@@ -0,0 +1,470 @@
+package voice
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultMaxChunk is the longest chunk, in characters, the default pipeline
+// produces. Longer sentences are split at clause boundaries.
+const DefaultMaxChunk = 250
+
+// TextStage transforms text on its way to the speech backend
+type TextStage func(string) string
+
+// Pipeline turns model output into short, speakable chunks
+// Each stage runs in order on the whole text, then the result is split into
+// sentences of at most MaxChunk characters. Chunks are queued separately so
+// that interruption and priorities apply between sentences.
+type Pipeline struct {
+	Stages   []TextStage
+	MaxChunk int // 0 disables splitting
+}
+
+// DefaultPipeline returns the pipeline a Speaker uses unless configured otherwise
+func DefaultPipeline() Pipeline {
+	return Pipeline{
+		Stages: []TextStage{
+			SummarizeCodeBlocks,
+			SpeakURLs,
+			StripMarkdown,
+			SpeakPaths,
+			ExpandNumbers,
+			ExpandUnits,
+		},
+		MaxChunk: DefaultMaxChunk,
+	}
+}
+
+// Process runs the stages and splits the result into chunks
+// It returns nil if nothing speakable is left.
+func (p Pipeline) Process(text string) []string {
+	for _, stage := range p.Stages {
+		text = stage(text)
+	}
+
+	text = collapseSpace(text)
+	if text == "" {
+		return nil
+	}
+	if p.MaxChunk <= 0 {
+		return []string{text}
+	}
+	return SplitSentences(text, p.MaxChunk)
+}
+
+// collapseSpace replaces runs of whitespace with single spaces
+func collapseSpace(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// SummarizeCodeBlocks replaces fenced code blocks with a short spoken summary
+// An unterminated block, as seen mid-stream, runs to the end of the text.
+func SummarizeCodeBlocks(text string) string {
+	lines := strings.Split(text, "\n")
+	var out []string
+
+	for i := 0; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if !strings.HasPrefix(trimmed, "```") && !strings.HasPrefix(trimmed, "~~~") {
+			out = append(out, lines[i])
+			continue
+		}
+
+		fence := trimmed[:3]
+		lang := strings.TrimSpace(strings.TrimLeft(trimmed, fence[:1]))
+		if fields := strings.Fields(lang); len(fields) > 0 {
+			lang = fields[0]
+		}
+
+		count := 0
+		for i+1 < len(lines) {
+			i++
+			if strings.HasPrefix(strings.TrimSpace(lines[i]), fence) {
+				break
+			}
+			count++
+		}
+
+		out = append(out, "", codeSummary(lang, count), "")
+	}
+
+	return strings.Join(out, "\n")
+}
+
+// codeSummary describes a code block in a short sentence
+func codeSummary(lang string, lines int) string {
+	noun := "lines"
+	if lines == 1 {
+		noun = "line"
+	}
+	if lang == "" {
+		return fmt.Sprintf("Code block, %d %s.", lines, noun)
+	}
+	return fmt.Sprintf("Code block in %s, %d %s.", lang, lines, noun)
+}
+
+var (
+	urlPattern     = regexp.MustCompile(`\bhttps?://[^\s<>()\[\]"']+|\bwww\.[^\s<>()\[\]"']+`)
+	trailingPunct  = ".,;:!?"
+	pathPattern    = regexp.MustCompile(`(^|[\s(])((?:~|\.\.?)?/(?:[\w.@+-]+/)*[\w.@+-]+/?)`)
+	imagePattern   = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	linkPattern    = regexp.MustCompile(`\[([^\]]+)\]\([^)]*\)`)
+	boldPattern    = regexp.MustCompile(`\*\*(.+?)\*\*|__(.+?)__`)
+	italicPattern  = regexp.MustCompile(`(^|[^\w*])\*([^*\s](?:[^*]*[^*\s])?)\*|(^|[^\w])_([^_\s](?:[^_]*[^_\s])?)_($|[^\w])`)
+	strikePattern  = regexp.MustCompile(`~~(.+?)~~`)
+	codePattern    = regexp.MustCompile("`([^`]*)`")
+	htmlPattern    = regexp.MustCompile(`</?[a-zA-Z][^>]*>`)
+	headingPattern = regexp.MustCompile(`^#{1,6}\s+`)
+	bulletPattern  = regexp.MustCompile(`^(?:[-*+]|\d+[.)])\s+`)
+	rulePattern    = regexp.MustCompile(`^(?:[-*_]\s*){3,}$`)
+	tableRule      = regexp.MustCompile(`^\|?\s*:?-{3,}:?\s*(?:\|\s*:?-{3,}:?\s*)*\|?$`)
+)
+
+// SpeakURLs replaces URLs with their domain name
+func SpeakURLs(text string) string {
+	return urlPattern.ReplaceAllStringFunc(text, func(match string) string {
+		trimmed := strings.TrimRight(match, trailingPunct)
+		suffix := match[len(trimmed):]
+
+		raw := trimmed
+		if strings.HasPrefix(raw, "www.") {
+			raw = "http://" + raw
+		}
+		u, err := url.Parse(raw)
+		if err != nil || u.Hostname() == "" {
+			return match
+		}
+		return strings.TrimPrefix(u.Hostname(), "www.") + suffix
+	})
+}
+
+// SpeakPaths replaces absolute and relative file paths with their last element
+func SpeakPaths(text string) string {
+	return pathPattern.ReplaceAllStringFunc(text, func(match string) string {
+		sub := pathPattern.FindStringSubmatch(match)
+		prefix, p := sub[1], sub[2]
+
+		trimmed := strings.TrimRight(p, trailingPunct)
+		suffix := p[len(trimmed):]
+		base := path.Base(strings.TrimSuffix(trimmed, "/"))
+		if base == "/" || base == "." || base == "~" {
+			return match
+		}
+		return prefix + base + suffix
+	})
+}
+
+// StripMarkdown removes markdown syntax, keeping the text a listener needs
+// Paragraphs, headings, list items and table rows become separate sentences.
+func StripMarkdown(text string) string {
+	var out []string
+	var paragraph []string
+
+	flush := func() {
+		if len(paragraph) > 0 {
+			out = append(out, strings.Join(paragraph, " "))
+			paragraph = nil
+		}
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimSpace(strings.TrimLeft(line, ">"))
+
+		switch {
+		case line == "" || rulePattern.MatchString(line) || tableRule.MatchString(line):
+			flush()
+		case headingPattern.MatchString(line):
+			flush()
+			out = append(out, stripInline(headingPattern.ReplaceAllString(line, "")))
+		case bulletPattern.MatchString(line):
+			flush()
+			out = append(out, stripInline(bulletPattern.ReplaceAllString(line, "")))
+		case strings.HasPrefix(line, "|"):
+			flush()
+			cells := strings.Split(strings.Trim(line, "|"), "|")
+			for i := range cells {
+				cells[i] = strings.TrimSpace(stripInline(cells[i]))
+			}
+			out = append(out, strings.Join(cells, ", "))
+		default:
+			paragraph = append(paragraph, stripInline(line))
+		}
+	}
+	flush()
+
+	// Every block but the last needs a full stop to be split from the next
+	for i := 0; i < len(out)-1; i++ {
+		out[i] = endSentence(out[i])
+	}
+	return strings.Join(out, "\n")
+}
+
+// stripInline removes inline markdown from a single line
+func stripInline(line string) string {
+	line = imagePattern.ReplaceAllString(line, "$1")
+	line = linkPattern.ReplaceAllString(line, "$1")
+	line = codePattern.ReplaceAllString(line, "$1")
+	line = boldPattern.ReplaceAllString(line, "$1$2")
+	line = italicPattern.ReplaceAllString(line, "$1$2$3$4$5")
+	line = strikePattern.ReplaceAllString(line, "$1")
+	line = htmlPattern.ReplaceAllString(line, " ")
+	return strings.TrimSpace(line)
+}
+
+// endSentence adds a full stop unless s already ends a sentence or clause
+func endSentence(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return s
+	}
+	last, _ := utf8.DecodeLastRuneInString(s)
+	if strings.ContainsRune(".!?:;", last) {
+		return s
+	}
+	return s + "."
+}
+
+var (
+	thousandsPattern = regexp.MustCompile(`\b\d{1,3}(?:,\d{3})+\b`)
+	versionPattern   = regexp.MustCompile(`\bv(\d+(?:\.\d+)+)\b`)
+	rangePattern     = regexp.MustCompile(`(\d+)\s*[-–]\s*(\d+)`)
+	approxPattern    = regexp.MustCompile(`(^|\s)~(\d)`)
+	hashNumPattern   = regexp.MustCompile(`(^|\s)#(\d+)\b`)
+)
+
+// ExpandNumbers rewrites numbers the way they should be read aloud:
+// thousands separators are removed, "v1.2" becomes "version 1.2", "5-10"
+// becomes "5 to 10", "~5" becomes "about 5" and "#3" becomes "number 3"
+func ExpandNumbers(text string) string {
+	text = thousandsPattern.ReplaceAllStringFunc(text, func(match string) string {
+		return strings.ReplaceAll(match, ",", "")
+	})
+	text = versionPattern.ReplaceAllString(text, "version $1")
+	text = approxPattern.ReplaceAllString(text, "${1}about $2")
+	text = hashNumPattern.ReplaceAllString(text, "${1}number $2")
+	return expandRanges(text)
+}
+
+// expandRanges turns "5-10" into "5 to 10" but leaves dates, phone numbers
+// and other hyphenated digit groups alone
+func expandRanges(text string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range rangePattern.FindAllStringSubmatchIndex(text, -1) {
+		start, end := m[0], m[1]
+		if start > 0 && strings.ContainsAny(text[start-1:start], "-–/.:") {
+			continue
+		}
+		if end < len(text) && strings.ContainsAny(text[end:end+1], "-–/.:") && !atSentenceEnd(text, end) {
+			continue
+		}
+		b.WriteString(text[last:start])
+		b.WriteString(text[m[2]:m[3]] + " to " + text[m[4]:m[5]])
+		last = end
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// atSentenceEnd reports whether the punctuation at i ends a sentence
+func atSentenceEnd(text string, i int) bool {
+	return text[i] == '.' && (i+1 == len(text) || text[i+1] == ' ' || text[i+1] == '\n')
+}
+
+// units maps abbreviations to their singular and plural spoken forms
+var units = map[string][2]string{
+	"%":   {"percent", "percent"},
+	"°C":  {"degree Celsius", "degrees Celsius"},
+	"°F":  {"degree Fahrenheit", "degrees Fahrenheit"},
+	"KB":  {"kilobyte", "kilobytes"},
+	"kB":  {"kilobyte", "kilobytes"},
+	"MB":  {"megabyte", "megabytes"},
+	"GB":  {"gigabyte", "gigabytes"},
+	"TB":  {"terabyte", "terabytes"},
+	"ms":  {"millisecond", "milliseconds"},
+	"km":  {"kilometer", "kilometers"},
+	"kg":  {"kilogram", "kilograms"},
+	"mph": {"mile per hour", "miles per hour"},
+	"MHz": {"megahertz", "megahertz"},
+	"GHz": {"gigahertz", "gigahertz"},
+}
+
+var unitPattern = regexp.MustCompile(`(\d+(?:\.\d+)?)\s?(%|°C|°F|KB|kB|MB|GB|TB|ms|km|kg|mph|MHz|GHz)`)
+
+// ExpandUnits spells out unit abbreviations after numbers, e.g. "4GB" becomes
+// "4 gigabytes" and "50%" becomes "50 percent"
+func ExpandUnits(text string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range unitPattern.FindAllStringSubmatchIndex(text, -1) {
+		start, end := m[0], m[1]
+		// The unit must not run into a longer word ("5 msgs")
+		if end < len(text) {
+			next, _ := utf8.DecodeRuneInString(text[end:])
+			if unicode.IsLetter(next) || unicode.IsDigit(next) {
+				continue
+			}
+		}
+
+		number, unit := text[m[2]:m[3]], text[m[4]:m[5]]
+		forms := units[unit]
+		spoken := forms[1]
+		if number == "1" {
+			spoken = forms[0]
+		}
+
+		b.WriteString(text[last:start])
+		b.WriteString(number + " " + spoken)
+		last = end
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// abbreviations end in a full stop without ending the sentence
+var abbreviations = map[string]bool{
+	"mr": true, "mrs": true, "ms": true, "dr": true, "st": true, "jr": true,
+	"sr": true, "vs": true, "etc": true, "e.g": true, "i.e": true, "approx": true,
+	"no": true, "fig": true, "inc": true, "ltd": true,
+}
+
+// SplitSentences splits text into sentences of at most maxLen characters
+// Sentences longer than maxLen are broken at the last clause boundary
+// (comma, semicolon, colon or dash) before the limit, or at a space.
+func SplitSentences(text string, maxLen int) []string {
+	var chunks []string
+	for _, sentence := range sentences(text) {
+		chunks = append(chunks, splitLong(sentence, maxLen)...)
+	}
+	return chunks
+}
+
+// sentences splits text at sentence-ending punctuation followed by a space
+func sentences(text string) []string {
+	var result []string
+	start := 0
+
+	for _, end := range sentenceEnds(text) {
+		if s := strings.TrimSpace(text[start:end]); s != "" {
+			result = append(result, s)
+		}
+		start = end
+	}
+
+	if s := strings.TrimSpace(text[start:]); s != "" {
+		result = append(result, s)
+	}
+	return result
+}
+
+// sentenceEnds returns the offsets just past each sentence-ending
+// punctuation mark in text that is followed by whitespace or the end of
+// the text. Repeated punctuation and closing quotes belong to the sentence.
+func sentenceEnds(text string) []int {
+	var ends []int
+	start := 0
+
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if c != '.' && c != '!' && c != '?' {
+			continue
+		}
+
+		dot := i
+		end := i + 1
+		for end < len(text) {
+			r, size := utf8.DecodeRuneInString(text[end:])
+			if !strings.ContainsRune(".!?\"')]”’", r) {
+				break
+			}
+			end += size
+		}
+		i = end - 1
+
+		if end < len(text) {
+			next, _ := utf8.DecodeRuneInString(text[end:])
+			if !unicode.IsSpace(next) {
+				continue
+			}
+		}
+		if c == '.' && isAbbreviation(text[start:dot]) {
+			continue
+		}
+
+		ends = append(ends, end)
+		start = end
+	}
+
+	return ends
+}
+
+// isAbbreviation reports whether the word before a full stop is an
+// abbreviation or an initial, so the stop does not end the sentence
+func isAbbreviation(before string) bool {
+	word := before
+	if i := strings.LastIndexByte(before, ' '); i >= 0 {
+		word = before[i+1:]
+	}
+	word = strings.ToLower(strings.TrimLeft(word, "(\"'"))
+	if abbreviations[word] {
+		return true
+	}
+	// A single capital letter is an initial, as in "J. R. R. Tolkien"
+	r, size := utf8.DecodeRuneInString(before[len(before)-len(word):])
+	return size == len(word) && unicode.IsUpper(r)
+}
+
+// splitLong breaks a sentence longer than maxLen into clauses
+func splitLong(sentence string, maxLen int) []string {
+	var chunks []string
+	for utf8.RuneCountInString(sentence) > maxLen {
+		cut := cutPoint(sentence, maxLen)
+		chunks = append(chunks, strings.TrimSpace(sentence[:cut]))
+		sentence = strings.TrimSpace(sentence[cut:])
+	}
+	if sentence != "" {
+		chunks = append(chunks, sentence)
+	}
+	return chunks
+}
+
+// cutPoint returns the byte offset to split s at so the first part has at
+// most maxLen characters, preferring clause boundaries over word boundaries
+func cutPoint(s string, maxLen int) int {
+	limit := len(s)
+	count := 0
+	for i := range s {
+		if count == maxLen {
+			limit = i
+			break
+		}
+		count++
+	}
+
+	window := s[:limit]
+	best := -1
+	for _, sep := range []string{"; ", ": ", ", ", " - ", " – ", " — "} {
+		i := strings.LastIndex(window, sep)
+		if i < 0 {
+			continue
+		}
+		if cut := i + len(strings.TrimRight(sep, " ")); cut > best {
+			best = cut
+		}
+	}
+	// Only take a clause boundary that keeps a reasonable amount of text
+	if best > limit/3 {
+		return best
+	}
+	if i := strings.LastIndexByte(window, ' '); i > 0 {
+		return i
+	}
+	return limit
+}
@@ -0,0 +1,216 @@
+package voice
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestSummarizeCodeBlocks(t *testing.T) {
+	input := "Try this:\n```go\nfunc main() {\n\tfmt.Println(\"hi\")\n}\n```\nThen run it."
+	got := collapseSpace(SummarizeCodeBlocks(input))
+	want := "Try this: Code block in go, 3 lines. Then run it."
+	if got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestSummarizeUnterminatedCodeBlock(t *testing.T) {
+	got := collapseSpace(SummarizeCodeBlocks("Here:\n```\nls -la"))
+	if got != "Here: Code block, 1 line." {
+		t.Errorf("Unexpected summary %q", got)
+	}
+}
+
+func TestSpeakURLs(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"See https://github.com/anicolao/emrys/issues/12 for details.", "See github.com for details."},
+		{"Visit www.example.org.", "Visit example.org."},
+		{"Docs at http://localhost:11434/api/chat", "Docs at localhost"},
+		{"No links here", "No links here"},
+	}
+
+	for _, tt := range tests {
+		if got := SpeakURLs(tt.input); got != tt.want {
+			t.Errorf("SpeakURLs(%q) = %q, expected %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestSpeakPaths(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"Edit /etc/nix/nix.conf now", "Edit nix.conf now"},
+		{"Saved to ~/.config/emrys/voice.conf.", "Saved to voice.conf."},
+		{"Run ./scripts/build.sh", "Run build.sh"},
+		{"input/output and and/or stay", "input/output and and/or stay"},
+	}
+
+	for _, tt := range tests {
+		if got := SpeakPaths(tt.input); got != tt.want {
+			t.Errorf("SpeakPaths(%q) = %q, expected %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestStripMarkdown(t *testing.T) {
+	input := `# Results
+
+The **build** passed with _no_ warnings, see [the log](build.log).
+
+- Compile ` + "`main.go`" + `
+- Run *all* tests
+
+| Name | Status |
+|------|--------|
+| vet  | ok     |`
+
+	got := collapseSpace(StripMarkdown(input))
+	want := "Results. The build passed with no warnings, see the log. Compile main.go. Run all tests. Name, Status. vet, ok"
+	if got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestStripMarkdownKeepsIdentifiers(t *testing.T) {
+	input := "Set snake_case_name and 2 * 3 * 4"
+	if got := StripMarkdown(input); got != input {
+		t.Errorf("Expected %q unchanged, got %q", input, got)
+	}
+}
+
+func TestExpandNumbers(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"About 1,234,567 rows", "About 1234567 rows"},
+		{"Upgrade to v1.2.3 today", "Upgrade to version 1.2.3 today"},
+		{"Takes 5-10 minutes", "Takes 5 to 10 minutes"},
+		{"Wait 5–10.", "Wait 5 to 10."},
+		{"Released 2024-01-15", "Released 2024-01-15"},
+		{"Roughly ~30 files", "Roughly about 30 files"},
+		{"Fixed in #42", "Fixed in number 42"},
+	}
+
+	for _, tt := range tests {
+		if got := ExpandNumbers(tt.input); got != tt.want {
+			t.Errorf("ExpandNumbers(%q) = %q, expected %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestExpandUnits(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"The model needs 4GB of memory", "The model needs 4 gigabytes of memory"},
+		{"Only 1 GB free", "Only 1 gigabyte free"},
+		{"Latency is 250ms.", "Latency is 250 milliseconds."},
+		{"CPU at 95%", "CPU at 95 percent"},
+		{"It is 21°C outside", "It is 21 degrees Celsius outside"},
+		{"Sent 5 msgs", "Sent 5 msgs"},
+	}
+
+	for _, tt := range tests {
+		if got := ExpandUnits(tt.input); got != tt.want {
+			t.Errorf("ExpandUnits(%q) = %q, expected %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestSplitSentences(t *testing.T) {
+	input := `Dr. Smith arrived at 3.30 today. Did he bring the files? Yes! He said "it's done." J. R. R. Tolkien wrote it, e.g. The Hobbit.`
+	want := []string{
+		"Dr. Smith arrived at 3.30 today.",
+		"Did he bring the files?",
+		"Yes!",
+		`He said "it's done."`,
+		"J. R. R. Tolkien wrote it, e.g. The Hobbit.",
+	}
+
+	if got := SplitSentences(input, 250); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestSplitSentencesLong(t *testing.T) {
+	input := "This sentence is long, because it keeps going on and on; it never seems to stop and there is no full stop in sight for a very long while"
+	chunks := SplitSentences(input, 60)
+
+	if len(chunks) < 2 {
+		t.Fatalf("Expected the sentence to be split, got %q", chunks)
+	}
+	for _, chunk := range chunks {
+		if utf8.RuneCountInString(chunk) > 60 {
+			t.Errorf("Chunk longer than 60 characters: %q", chunk)
+		}
+	}
+	if chunks[0] != "This sentence is long, because it keeps going on and on;" {
+		t.Errorf("Expected split at the clause boundary, got %q", chunks[0])
+	}
+	if got := strings.Join(chunks, " "); got != input {
+		t.Errorf("Expected chunks to rejoin to the input, got %q", got)
+	}
+}
+
+func TestPipelineProcess(t *testing.T) {
+	input := "## Done\n\nI wrote the config to `/home/me/.config/emrys/config.yaml`. See https://ollama.com/library for 2,000+ models.\n\n```yaml\nvoice: Jamie\n```"
+	want := []string{
+		"Done.",
+		"I wrote the config to config.yaml.",
+		"See ollama.com for 2000+ models.",
+		"Code block in yaml, 1 line.",
+	}
+
+	if got := DefaultPipeline().Process(input); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestPipelineEmpty(t *testing.T) {
+	if got := DefaultPipeline().Process("  \n\n---\n"); got != nil {
+		t.Errorf("Expected no chunks, got %q", got)
+	}
+	if got := (Pipeline{}).Process("**raw** text. More."); !reflect.DeepEqual(got, []string{"**raw** text. More."}) {
+		t.Errorf("Expected zero pipeline to pass text through, got %q", got)
+	}
+}
+
+func TestSpeakerQueuesSentences(t *testing.T) {
+	backend := NewFakeBackend()
+	speaker := NewSpeakerWithBackend(DefaultConfig(), backend)
+	defer speaker.Close()
+
+	speaker.Speak("First sentence. Second sentence.")
+	waitFor(t, func() bool { return len(backend.Texts()) == 2 })
+
+	want := []string{"First sentence.", "Second sentence."}
+	if got := backend.Texts(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestSpeakerSkipDropsRemainingSentences(t *testing.T) {
+	backend := &FakeBackend{Delay: 100 * time.Millisecond}
+	speaker := NewSpeakerWithBackend(DefaultConfig(), backend)
+	defer speaker.Close()
+
+	speaker.Speak("One. Two. Three.")
+	speaker.Speak("Next message.")
+	waitFor(t, speaker.IsSpeaking)
+	speaker.Skip()
+
+	waitFor(t, func() bool { return speaker.Pending() == 0 && !speaker.IsSpeaking() })
+	if got := backend.Texts(); !reflect.DeepEqual(got, []string{"Next message."}) {
+		t.Errorf("Expected only the next message, got %v", got)
+	}
+}
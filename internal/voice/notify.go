@@ -0,0 +1,46 @@
+package voice
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/anicolao/emrys/internal/audit"
+)
+
+// Notifier shows a message without speaking it
+type Notifier interface {
+	Notify(ctx context.Context, title, message string) error
+}
+
+// SystemNotifier shows desktop notifications with osascript on macOS and
+// notify-send elsewhere
+type SystemNotifier struct{}
+
+// Notify shows a desktop notification
+func (SystemNotifier) Notify(ctx context.Context, title, message string) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "darwin" {
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptString(message), appleScriptString(title))
+		cmd = exec.CommandContext(ctx, "osascript", "-e", script)
+	} else {
+		if !commandExists("notify-send") {
+			return fmt.Errorf("notify-send is not installed")
+		}
+		cmd = exec.CommandContext(ctx, "notify-send", title, message)
+	}
+
+	if output, err := audit.CombinedOutput(auditInitiator, cmd); err != nil {
+		return fmt.Errorf("failed to show notification: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// appleScriptString quotes s as an AppleScript string literal
+func appleScriptString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
@@ -0,0 +1,216 @@
+package voice
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/anicolao/emrys/internal/audit"
+)
+
+// DefaultPiperModelDir returns the directory searched for Piper voice models
+func DefaultPiperModelDir() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".config", "emrys", "piper")
+}
+
+// PiperBackend speaks with Piper, a local neural TTS engine. Piper renders
+// to a WAV file which is then played with the system audio player.
+type PiperBackend struct {
+	ModelDir string // Directory containing <voice>.onnx models
+}
+
+// NewPiperBackend creates a Piper backend using models in modelDir
+// An empty modelDir uses DefaultPiperModelDir.
+func NewPiperBackend(modelDir string) *PiperBackend {
+	if modelDir == "" {
+		modelDir = DefaultPiperModelDir()
+	}
+	return &PiperBackend{ModelDir: modelDir}
+}
+
+// Name returns "piper"
+func (b *PiperBackend) Name() string {
+	return BackendPiper
+}
+
+// Available reports whether piper, an audio player and at least one model exist
+func (b *PiperBackend) Available() bool {
+	if !commandExists("piper") || audioPlayer() == "" {
+		return false
+	}
+	voices, _ := b.ListVoices(context.Background())
+	return len(voices) > 0
+}
+
+// Capabilities reports voice selection; Piper's rate is fixed per model
+func (b *PiperBackend) Capabilities() Capabilities {
+	return Capabilities{Voices: true, Volume: true}
+}
+
+// Speak renders the utterance with Piper and plays the result
+func (b *PiperBackend) Speak(ctx context.Context, u Utterance) error {
+	tmp, err := os.CreateTemp("", "emrys-piper-*.wav")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+
+	if err := b.Render(ctx, u, tmp.Name()); err != nil {
+		return err
+	}
+	return playFile(ctx, tmp.Name(), u.gain())
+}
+
+// Render writes the utterance to a WAV file
+// Volume is not applied; it is a playback setting.
+func (b *PiperBackend) Render(ctx context.Context, u Utterance, path string) error {
+	model, err := b.model(ctx, u.Voice)
+	if err != nil {
+		return err
+	}
+
+	render := exec.CommandContext(ctx, "piper", "--model", model, "--output_file", path)
+	render.Stdin = strings.NewReader(u.Text)
+	if output, err := audit.CombinedOutput(auditInitiator, render); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("piper failed: %w (output: %s)", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// Extension returns ".wav"
+func (b *PiperBackend) Extension() string {
+	return ".wav"
+}
+
+// model returns the model path for a voice, or the first model if voice is empty
+func (b *PiperBackend) model(ctx context.Context, voice string) (string, error) {
+	if voice == "" {
+		voices, err := b.ListVoices(ctx)
+		if err != nil {
+			return "", err
+		}
+		if len(voices) == 0 {
+			return "", fmt.Errorf("no piper models found in %s", b.ModelDir)
+		}
+		voice = voices[0]
+	}
+
+	model := filepath.Join(b.ModelDir, voice+".onnx")
+	if _, err := os.Stat(model); err != nil {
+		return "", fmt.Errorf("piper voice %q not found in %s", voice, b.ModelDir)
+	}
+	return model, nil
+}
+
+// ListVoices returns the names of the .onnx models in ModelDir
+func (b *PiperBackend) ListVoices(ctx context.Context) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(b.ModelDir, "*.onnx"))
+	if err != nil {
+		return nil, err
+	}
+
+	var voices []string
+	for _, m := range matches {
+		voices = append(voices, strings.TrimSuffix(filepath.Base(m), ".onnx"))
+	}
+	sort.Strings(voices)
+	return voices, nil
+}
+
+// audioPlayer returns the command used to play audio files
+func audioPlayer() string {
+	candidates := []string{"paplay", "aplay", "ffplay"}
+	if runtime.GOOS == "darwin" {
+		candidates = []string{"afplay"}
+	}
+	for _, c := range candidates {
+		if commandExists(c) {
+			return c
+		}
+	}
+	return ""
+}
+
+// playFile plays an audio file with the system audio player
+// A gain between 0 and 1 is applied by the player where it supports it, and
+// otherwise by scaling the samples of a temporary copy of a PCM WAV file.
+func playFile(ctx context.Context, path string, gain float64) error {
+	player := audioPlayer()
+	if player == "" {
+		return fmt.Errorf("no audio player found")
+	}
+
+	if gain > 0 && gain < 1 && player == "aplay" {
+		scaled, err := scaledCopy(path, gain)
+		if err != nil {
+			return err
+		}
+		defer os.Remove(scaled)
+		path = scaled
+	}
+
+	args := playerArgs(player, path, gain)
+
+	if err := audit.Run(auditInitiator, exec.CommandContext(ctx, player, args...)); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("%s failed: %w", player, err)
+	}
+	return nil
+}
+
+// playerArgs builds the arguments to play path with player at the given gain
+func playerArgs(player, path string, gain float64) []string {
+	var args []string
+	if gain > 0 && gain < 1 {
+		switch player {
+		case "afplay":
+			args = append(args, "-v", fmt.Sprintf("%.2f", gain))
+		case "paplay":
+			// 65536 is 100%
+			args = append(args, fmt.Sprintf("--volume=%d", int(gain*65536)))
+		case "ffplay":
+			args = append(args, "-volume", fmt.Sprintf("%d", int(math.Round(gain*100))))
+		}
+	}
+	if player == "ffplay" {
+		args = append(args, "-nodisp", "-autoexit", "-loglevel", "quiet")
+	}
+	return append(args, path)
+}
+
+// scaledCopy writes a copy of a WAV file with gain applied and returns its path
+func scaledCopy(path string, gain float64) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read audio: %w", err)
+	}
+
+	tmp, err := os.CreateTemp("", "emrys-gain-*.wav")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmp.Close()
+
+	if err := os.WriteFile(tmp.Name(), data, 0644); err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("failed to write audio: %w", err)
+	}
+	if err := applyGain(tmp.Name(), gain); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
@@ -0,0 +1,172 @@
+package voice
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Priority orders queued messages. Higher priorities are spoken first.
+type Priority int
+
+// Priority levels
+const (
+	PriorityChatter  Priority = iota // Nice-to-have commentary, dropped first when the queue is full
+	PriorityNormal                   // Regular messages
+	PriorityCritical                 // Urgent alerts: interrupt other speech and ignore quiet hours
+)
+
+// String returns the priority name
+func (p Priority) String() string {
+	switch p {
+	case PriorityChatter:
+		return "chatter"
+	case PriorityNormal:
+		return "normal"
+	case PriorityCritical:
+		return "critical"
+	default:
+		return fmt.Sprintf("priority(%d)", int(p))
+	}
+}
+
+// ParsePriority parses a priority name
+func ParsePriority(s string) (Priority, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "chatter":
+		return PriorityChatter, nil
+	case "normal", "":
+		return PriorityNormal, nil
+	case "critical":
+		return PriorityCritical, nil
+	default:
+		return PriorityNormal, fmt.Errorf("unknown priority %q", s)
+	}
+}
+
+// Overflow policies for a full queue
+const (
+	// OverflowDropOldest evicts the oldest message of the lowest queued
+	// priority, as long as it is not more important than the new message
+	OverflowDropOldest = "drop-oldest"
+	// OverflowDropNew rejects the new message
+	OverflowDropNew = "drop-new"
+)
+
+// DefaultQueueSize is the number of messages a Speaker queues by default
+const DefaultQueueSize = 100
+
+// queuedMessage is a message waiting to be spoken
+type queuedMessage struct {
+	text     string
+	priority Priority
+	id       uint64 // Chunks of the same Speak call share an id
+	queued   time.Time
+}
+
+// record returns a history record for the message
+func (m queuedMessage) record(status Status) Record {
+	return Record{
+		Message:  m.id,
+		Text:     m.text,
+		Priority: m.priority,
+		Status:   status,
+		Queued:   m.queued,
+	}
+}
+
+// messageQueue holds pending messages, highest priority first and FIFO
+// within a priority. It is not safe for concurrent use.
+type messageQueue struct {
+	items []queuedMessage
+}
+
+// push adds a message, applying the overflow policy when the queue already
+// holds size messages. It returns the message that was dropped, if any.
+func (q *messageQueue) push(msg queuedMessage, size int, overflow string) (dropped *queuedMessage) {
+	if len(q.items) >= size {
+		victim := -1
+		if overflow != OverflowDropNew {
+			victim = q.oldestLowest()
+			if victim >= 0 && q.items[victim].priority > msg.priority {
+				victim = -1
+			}
+		}
+		if victim < 0 {
+			return &msg
+		}
+		evicted := q.items[victim]
+		q.items = append(q.items[:victim], q.items[victim+1:]...)
+		dropped = &evicted
+	}
+
+	// Insert after every message of the same or higher priority
+	i := len(q.items)
+	for i > 0 && q.items[i-1].priority < msg.priority {
+		i--
+	}
+	q.items = append(q.items, queuedMessage{})
+	copy(q.items[i+1:], q.items[i:])
+	q.items[i] = msg
+	return dropped
+}
+
+// oldestLowest returns the index of the oldest message with the lowest priority
+func (q *messageQueue) oldestLowest() int {
+	if len(q.items) == 0 {
+		return -1
+	}
+	// Items are sorted by descending priority, so the lowest priority run is
+	// at the end and its oldest member is the first of that run
+	i := len(q.items) - 1
+	lowest := q.items[i].priority
+	for i > 0 && q.items[i-1].priority == lowest {
+		i--
+	}
+	return i
+}
+
+// pop removes and returns the next message to speak
+func (q *messageQueue) pop() (queuedMessage, bool) {
+	if len(q.items) == 0 {
+		return queuedMessage{}, false
+	}
+	msg := q.items[0]
+	q.items = q.items[1:]
+	return msg, true
+}
+
+// peek returns the next message to speak without removing it
+func (q *messageQueue) peek() (queuedMessage, bool) {
+	if len(q.items) == 0 {
+		return queuedMessage{}, false
+	}
+	return q.items[0], true
+}
+
+// take removes and returns all messages with the given id, in order
+func (q *messageQueue) take(id uint64) []queuedMessage {
+	var taken []queuedMessage
+	kept := q.items[:0]
+	for _, item := range q.items {
+		if item.id == id {
+			taken = append(taken, item)
+		} else {
+			kept = append(kept, item)
+		}
+	}
+	q.items = kept
+	return taken
+}
+
+// remove deletes all messages with the given id and returns them
+func (q *messageQueue) remove(id uint64) []queuedMessage {
+	return q.take(id)
+}
+
+// clear removes all messages and returns them
+func (q *messageQueue) clear() []queuedMessage {
+	items := q.items
+	q.items = nil
+	return items
+}
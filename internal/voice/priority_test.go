@@ -0,0 +1,184 @@
+package voice
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// queueTexts returns the texts in the queue in order
+func queueTexts(q *messageQueue) []string {
+	var texts []string
+	for _, item := range q.items {
+		texts = append(texts, item.text)
+	}
+	return texts
+}
+
+func TestMessageQueueOrder(t *testing.T) {
+	var q messageQueue
+	q.push(queuedMessage{text: "chatter 1", priority: PriorityChatter}, 10, OverflowDropOldest)
+	q.push(queuedMessage{text: "normal 1", priority: PriorityNormal}, 10, OverflowDropOldest)
+	q.push(queuedMessage{text: "critical", priority: PriorityCritical}, 10, OverflowDropOldest)
+	q.push(queuedMessage{text: "normal 2", priority: PriorityNormal}, 10, OverflowDropOldest)
+	q.push(queuedMessage{text: "chatter 2", priority: PriorityChatter}, 10, OverflowDropOldest)
+
+	want := []string{"critical", "normal 1", "normal 2", "chatter 1", "chatter 2"}
+	if got := queueTexts(&q); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestMessageQueueDropOldestChatter(t *testing.T) {
+	var q messageQueue
+	q.push(queuedMessage{text: "chatter 1", priority: PriorityChatter}, 3, OverflowDropOldest)
+	q.push(queuedMessage{text: "chatter 2", priority: PriorityChatter}, 3, OverflowDropOldest)
+	q.push(queuedMessage{text: "normal 1", priority: PriorityNormal}, 3, OverflowDropOldest)
+
+	dropped := q.push(queuedMessage{text: "normal 2", priority: PriorityNormal}, 3, OverflowDropOldest)
+	if dropped == nil || dropped.text != "chatter 1" {
+		t.Errorf("Expected oldest chatter to be dropped, got %v", dropped)
+	}
+
+	want := []string{"normal 1", "normal 2", "chatter 2"}
+	if got := queueTexts(&q); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestMessageQueueKeepsMoreImportantMessages(t *testing.T) {
+	var q messageQueue
+	q.push(queuedMessage{text: "critical", priority: PriorityCritical}, 1, OverflowDropOldest)
+
+	dropped := q.push(queuedMessage{text: "chatter", priority: PriorityChatter}, 1, OverflowDropOldest)
+	if dropped == nil || dropped.text != "chatter" {
+		t.Errorf("Expected the new chatter to be dropped, got %v", dropped)
+	}
+	if got := queueTexts(&q); !reflect.DeepEqual(got, []string{"critical"}) {
+		t.Errorf("Expected critical message to be kept, got %v", got)
+	}
+}
+
+func TestMessageQueueDropNew(t *testing.T) {
+	var q messageQueue
+	q.push(queuedMessage{text: "chatter", priority: PriorityChatter}, 1, OverflowDropNew)
+
+	dropped := q.push(queuedMessage{text: "critical", priority: PriorityCritical}, 1, OverflowDropNew)
+	if dropped == nil || dropped.text != "critical" {
+		t.Errorf("Expected the new message to be dropped, got %v", dropped)
+	}
+}
+
+func TestParsePriority(t *testing.T) {
+	for _, p := range []Priority{PriorityChatter, PriorityNormal, PriorityCritical} {
+		got, err := ParsePriority(p.String())
+		if err != nil || got != p {
+			t.Errorf("Expected %s to round-trip, got %v (%v)", p, got, err)
+		}
+	}
+	if _, err := ParsePriority("urgent"); err == nil {
+		t.Error("Expected error for unknown priority")
+	}
+}
+
+func TestSpeakerPriorityOrder(t *testing.T) {
+	backend := &FakeBackend{Delay: 50 * time.Millisecond}
+	speaker := NewSpeakerWithBackend(DefaultConfig(), backend)
+	defer speaker.Close()
+
+	speaker.Speak("first")
+	waitFor(t, speaker.IsSpeaking)
+
+	speaker.SpeakPriority("chatter", PriorityChatter)
+	speaker.Speak("normal")
+
+	waitFor(t, func() bool { return len(backend.Texts()) == 3 })
+
+	want := []string{"first", "normal", "chatter"}
+	if got := backend.Texts(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestSpeakerCriticalInterrupts(t *testing.T) {
+	backend := &FakeBackend{Delay: 300 * time.Millisecond}
+	speaker := NewSpeakerWithBackend(DefaultConfig(), backend)
+	defer speaker.Close()
+
+	speaker.Speak("a long monologue")
+	waitFor(t, speaker.IsSpeaking)
+
+	speaker.SpeakPriority("alert", PriorityCritical)
+
+	waitFor(t, func() bool { return len(backend.Texts()) == 1 })
+	if got := backend.Texts(); got[0] != "alert" {
+		t.Errorf("Expected the alert to interrupt the monologue, got %v", got)
+	}
+}
+
+func TestSpeakerSkip(t *testing.T) {
+	backend := &FakeBackend{Delay: 200 * time.Millisecond}
+	speaker := NewSpeakerWithBackend(DefaultConfig(), backend)
+	defer speaker.Close()
+
+	if speaker.Skip() {
+		t.Error("Expected Skip to return false when nothing is playing")
+	}
+
+	speaker.Speak("skipped")
+	speaker.Speak("spoken")
+	waitFor(t, speaker.IsSpeaking)
+
+	if !speaker.Skip() {
+		t.Error("Expected Skip to interrupt the current utterance")
+	}
+
+	waitFor(t, func() bool { return len(backend.Texts()) == 1 })
+	if got := backend.Texts(); got[0] != "spoken" {
+		t.Errorf("Expected only the second message to be spoken, got %v", got)
+	}
+}
+
+func TestSpeakerFlush(t *testing.T) {
+	backend := &FakeBackend{Delay: time.Second}
+	speaker := NewSpeakerWithBackend(DefaultConfig(), backend)
+	defer speaker.Close()
+
+	speaker.Speak("one")
+	waitFor(t, speaker.IsSpeaking)
+	speaker.Speak("two")
+	speaker.Speak("three")
+
+	if n := speaker.Flush(); n != 2 {
+		t.Errorf("Expected 2 discarded messages, got %d", n)
+	}
+	waitFor(t, func() bool { return !speaker.IsSpeaking() })
+
+	if speaker.Pending() != 0 {
+		t.Errorf("Expected empty queue, got %d pending", speaker.Pending())
+	}
+	if got := backend.Texts(); len(got) != 0 {
+		t.Errorf("Expected nothing spoken, got %v", got)
+	}
+}
+
+func TestSpeakerCriticalBypassesQuietHours(t *testing.T) {
+	config := DefaultConfig()
+	config.QuietHours = true
+	config.QuietStart = 22
+	config.QuietEnd = 7
+
+	backend := NewFakeBackend()
+	speaker := NewSpeakerWithBackend(config, backend)
+	defer speaker.Close()
+	speaker.SetClock(NewFakeClock(time.Date(2026, time.March, 4, 23, 0, 0, 0, time.Local)))
+
+	speaker.Speak("quiet please")
+	speaker.SpeakPriority("fire alarm", PriorityCritical)
+
+	waitFor(t, func() bool { return len(backend.Texts()) == 1 })
+	waitFor(t, func() bool { return speaker.Pending() == 0 && !speaker.IsSpeaking() })
+	if got := backend.Texts(); !reflect.DeepEqual(got, []string{"fire alarm"}) {
+		t.Errorf("Expected only the critical message during quiet hours, got %v", got)
+	}
+}
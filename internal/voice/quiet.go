@@ -0,0 +1,320 @@
+package voice
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Quiet-hours policies for messages that are not critical
+const (
+	QuietDrop   = "drop"   // Discard the message
+	QuietDefer  = "defer"  // Keep the message queued until quiet hours end
+	QuietNotify = "notify" // Show the message as a desktop notification instead
+)
+
+// Clock tells the time and waits. It is replaced in tests.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// systemClock is the real clock
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// SystemClock returns the real clock
+func SystemClock() Clock {
+	return systemClock{}
+}
+
+// Weekdays is a set of days of the week
+type Weekdays uint8
+
+// Common day sets
+const (
+	EveryDay Weekdays = 1<<7 - 1
+	WorkDays Weekdays = 1<<time.Monday | 1<<time.Tuesday | 1<<time.Wednesday | 1<<time.Thursday | 1<<time.Friday
+	Weekends Weekdays = 1<<time.Saturday | 1<<time.Sunday
+)
+
+// Has reports whether day is in the set
+func (d Weekdays) Has(day time.Weekday) bool {
+	return d&(1<<day) != 0
+}
+
+var dayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// ParseWeekdays parses a day set such as "mon-fri", "sat,sun", "weekdays",
+// "weekends" or "daily"
+func ParseWeekdays(s string) (Weekdays, error) {
+	var days Weekdays
+	for _, part := range strings.Split(strings.ToLower(s), ",") {
+		part = strings.TrimSpace(part)
+		switch part {
+		case "daily", "*", "all":
+			days |= EveryDay
+			continue
+		case "weekdays":
+			days |= WorkDays
+			continue
+		case "weekends":
+			days |= Weekends
+			continue
+		}
+
+		from, to, isRange := strings.Cut(part, "-")
+		first, err := parseDay(from)
+		if err != nil {
+			return 0, err
+		}
+		last := first
+		if isRange {
+			if last, err = parseDay(to); err != nil {
+				return 0, err
+			}
+		}
+		// Ranges may wrap around the week, as in "fri-mon"
+		for d := first; ; d = (d + 1) % 7 {
+			days |= 1 << d
+			if d == last {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+// parseDay parses a day name such as "mon" or "Monday"
+func parseDay(s string) (time.Weekday, error) {
+	s = strings.TrimSpace(s)
+	if len(s) >= 3 {
+		for i, name := range dayNames {
+			if strings.HasPrefix(s, name) {
+				return time.Weekday(i), nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unknown day %q", s)
+}
+
+// String formats the set in the form ParseWeekdays accepts
+func (d Weekdays) String() string {
+	switch d {
+	case EveryDay:
+		return "daily"
+	case WorkDays:
+		return "mon-fri"
+	case Weekends:
+		return "sat,sun"
+	}
+	var names []string
+	for i, name := range dayNames {
+		if d.Has(time.Weekday(i)) {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// Window is a daily period of quiet, in minutes since midnight
+// A window whose end is before its start runs past midnight and belongs to
+// the day it starts on. A window whose start equals its end lasts all day.
+type Window struct {
+	Start int
+	End   int
+	Days  Weekdays // Days the window starts on (0: every day)
+}
+
+// ParseWindow parses a window such as "22:00-07:00" or "mon-fri 22:30-06:45"
+func ParseWindow(s string) (Window, error) {
+	s = strings.TrimSpace(s)
+	w := Window{Days: EveryDay}
+
+	span := s
+	if i := strings.LastIndexByte(s, ' '); i >= 0 {
+		days, err := ParseWeekdays(s[:i])
+		if err != nil {
+			return Window{}, fmt.Errorf("invalid quiet window %q: %w", s, err)
+		}
+		w.Days = days
+		span = s[i+1:]
+	}
+
+	from, to, found := strings.Cut(span, "-")
+	if !found {
+		return Window{}, fmt.Errorf("invalid quiet window %q: expected HH:MM-HH:MM", s)
+	}
+	var err error
+	if w.Start, err = parseClockTime(from); err != nil {
+		return Window{}, fmt.Errorf("invalid quiet window %q: %w", s, err)
+	}
+	if w.End, err = parseClockTime(to); err != nil {
+		return Window{}, fmt.Errorf("invalid quiet window %q: %w", s, err)
+	}
+	return w, nil
+}
+
+// parseClockTime parses "HH:MM" or "HH" into minutes since midnight
+func parseClockTime(s string) (int, error) {
+	hours, minutes, hasMinutes := strings.Cut(strings.TrimSpace(s), ":")
+	h, err := strconv.Atoi(hours)
+	if err != nil || h < 0 || h > 23 {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	m := 0
+	if hasMinutes {
+		m, err = strconv.Atoi(minutes)
+		if err != nil || m < 0 || m > 59 || len(minutes) != 2 {
+			return 0, fmt.Errorf("invalid time %q", s)
+		}
+	}
+	return h*60 + m, nil
+}
+
+// String formats the window in the form ParseWindow accepts
+func (w Window) String() string {
+	span := fmt.Sprintf("%02d:%02d-%02d:%02d", w.Start/60, w.Start%60, w.End/60, w.End%60)
+	if w.Days == 0 || w.Days == EveryDay {
+		return span
+	}
+	return w.Days.String() + " " + span
+}
+
+// contains reports whether local time t falls inside the window
+func (w Window) contains(t time.Time) bool {
+	days := w.Days
+	if days == 0 {
+		days = EveryDay
+	}
+	minute := t.Hour()*60 + t.Minute()
+	today := t.Weekday()
+	yesterday := (today + 6) % 7
+
+	switch {
+	case w.Start == w.End:
+		return days.Has(today)
+	case w.Start < w.End:
+		return days.Has(today) && minute >= w.Start && minute < w.End
+	default:
+		return (days.Has(today) && minute >= w.Start) || (days.Has(yesterday) && minute < w.End)
+	}
+}
+
+// Schedule describes when Emrys should stay quiet
+type Schedule struct {
+	Windows  []Window
+	Location *time.Location // Time zone the windows are in (nil: local time)
+	Holidays []string       // Dates in YYYY-MM-DD form that are quiet all day
+}
+
+// ParseSchedule builds a schedule from the voice.conf forms: windows
+// separated by ';', an IANA time zone name and comma-separated dates
+func ParseSchedule(windows, timezone, holidays string) (Schedule, error) {
+	var s Schedule
+	var errs []error
+
+	for _, part := range strings.Split(windows, ";") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		w, err := ParseWindow(part)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		s.Windows = append(s.Windows, w)
+	}
+
+	if timezone != "" {
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid timezone %q: %w", timezone, err))
+		}
+		s.Location = loc
+	}
+
+	for _, day := range strings.Split(holidays, ",") {
+		day = strings.TrimSpace(day)
+		if day == "" {
+			continue
+		}
+		if _, err := time.Parse(time.DateOnly, day); err != nil {
+			errs = append(errs, fmt.Errorf("invalid holiday %q: expected YYYY-MM-DD", day))
+			continue
+		}
+		s.Holidays = append(s.Holidays, day)
+	}
+
+	return s, errors.Join(errs...)
+}
+
+// local converts t to the schedule's time zone
+func (s Schedule) local(t time.Time) time.Time {
+	if s.Location == nil {
+		return t.Local()
+	}
+	return t.In(s.Location)
+}
+
+// IsQuiet reports whether t falls in quiet hours
+func (s Schedule) IsQuiet(t time.Time) bool {
+	t = s.local(t)
+
+	date := t.Format(time.DateOnly)
+	for _, day := range s.Holidays {
+		if day == date {
+			return true
+		}
+	}
+
+	for _, w := range s.Windows {
+		if w.contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// QuietUntil returns when the quiet period containing t ends. It returns t
+// if t is not quiet, and the zero time if quiet hours never end.
+func (s Schedule) QuietUntil(t time.Time) time.Time {
+	if !s.IsQuiet(t) {
+		return t
+	}
+
+	// Quiet periods can only end at midnight or at the end of a window, so
+	// check those moments over the next week in order
+	local := s.local(t)
+	var candidates []time.Time
+	for offset := 0; offset <= 8; offset++ {
+		day := time.Date(local.Year(), local.Month(), local.Day()+offset, 0, 0, 0, 0, local.Location())
+		candidates = append(candidates, day)
+		for _, w := range s.Windows {
+			candidates = append(candidates, day.Add(time.Duration(w.End)*time.Minute))
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Before(candidates[j]) })
+
+	for _, c := range candidates {
+		if c.After(t) && !s.IsQuiet(c) {
+			return c
+		}
+	}
+	return time.Time{}
+}
+
+// Schedule returns the quiet-hours schedule the configuration describes
+// QuietWindows takes precedence over the whole-hour QuietStart and QuietEnd.
+func (c Config) Schedule() (Schedule, error) {
+	windows := c.QuietWindows
+	if strings.TrimSpace(windows) == "" {
+		windows = fmt.Sprintf("%02d:00-%02d:00", c.QuietStart, c.QuietEnd)
+	}
+	return ParseSchedule(windows, c.Timezone, c.Holidays)
+}
@@ -0,0 +1,284 @@
+package voice
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// date returns a local time on the given day; 2026-03-02 is a Monday
+func date(day, hour, minute int) time.Time {
+	return time.Date(2026, time.March, day, hour, minute, 0, 0, time.Local)
+}
+
+func TestParseWeekdays(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Weekdays
+	}{
+		{"mon-fri", WorkDays},
+		{"weekdays", WorkDays},
+		{"sat,sun", Weekends},
+		{"Saturday, Sunday", Weekends},
+		{"daily", EveryDay},
+		{"fri-mon", 1<<time.Friday | 1<<time.Saturday | 1<<time.Sunday | 1<<time.Monday},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseWeekdays(tt.input)
+		if err != nil {
+			t.Errorf("ParseWeekdays(%q) failed: %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseWeekdays(%q) = %s, expected %s", tt.input, got, tt.want)
+		}
+	}
+
+	if _, err := ParseWeekdays("someday"); err == nil {
+		t.Error("Expected error for unknown day")
+	}
+}
+
+func TestParseWindow(t *testing.T) {
+	w, err := ParseWindow("mon-fri 22:30-06:45")
+	if err != nil {
+		t.Fatalf("ParseWindow failed: %v", err)
+	}
+	want := Window{Start: 22*60 + 30, End: 6*60 + 45, Days: WorkDays}
+	if w != want {
+		t.Errorf("Expected %+v, got %+v", want, w)
+	}
+	if w.String() != "mon-fri 22:30-06:45" {
+		t.Errorf("Expected window to format back, got %q", w.String())
+	}
+
+	for _, bad := range []string{"22:00", "25:00-07:00", "22:5-07:00", "someday 22:00-07:00"} {
+		if _, err := ParseWindow(bad); err == nil {
+			t.Errorf("Expected error for %q", bad)
+		}
+	}
+}
+
+func TestScheduleWindows(t *testing.T) {
+	schedule, err := ParseSchedule("mon-fri 22:30-07:00; sat,sun 23:00-09:00; 12:00-12:15", "", "")
+	if err != nil {
+		t.Fatalf("ParseSchedule failed: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		t     time.Time
+		quiet bool
+	}{
+		{"weekday evening before window", date(4, 22, 29), false},
+		{"weekday evening in window", date(4, 22, 30), true},
+		{"weekday morning in window", date(5, 6, 59), true},
+		{"weekday window end", date(5, 7, 0), false},
+		{"friday night runs into saturday", date(7, 6, 0), true},
+		{"saturday 8am uses weekend window", date(7, 8, 0), false},
+		{"saturday late night", date(7, 23, 30), true},
+		{"sunday night runs into monday", date(9, 8, 30), true},
+		{"monday after weekend window", date(9, 9, 0), false},
+		{"lunch window", date(4, 12, 10), true},
+		{"after lunch", date(4, 12, 15), false},
+	}
+
+	for _, tt := range tests {
+		if got := schedule.IsQuiet(tt.t); got != tt.quiet {
+			t.Errorf("%s: IsQuiet(%s) = %v, expected %v", tt.name, tt.t.Format("Mon 15:04"), got, tt.quiet)
+		}
+	}
+}
+
+func TestScheduleHolidays(t *testing.T) {
+	schedule, err := ParseSchedule("", "", "2026-03-04")
+	if err != nil {
+		t.Fatalf("ParseSchedule failed: %v", err)
+	}
+
+	if !schedule.IsQuiet(date(4, 12, 0)) {
+		t.Error("Expected holiday to be quiet")
+	}
+	if schedule.IsQuiet(date(5, 0, 0)) {
+		t.Error("Expected the day after the holiday not to be quiet")
+	}
+	if got := schedule.QuietUntil(date(4, 12, 0)); !got.Equal(date(5, 0, 0)) {
+		t.Errorf("Expected quiet until midnight, got %s", got)
+	}
+}
+
+func TestScheduleTimezone(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skip("Time zone data not available")
+	}
+
+	schedule, err := ParseSchedule("22:00-07:00", "Asia/Tokyo", "")
+	if err != nil {
+		t.Fatalf("ParseSchedule failed: %v", err)
+	}
+
+	// 23:00 in Tokyo is 14:00 UTC
+	at := time.Date(2026, time.March, 4, 14, 0, 0, 0, time.UTC)
+	if !schedule.IsQuiet(at) {
+		t.Error("Expected 23:00 Tokyo time to be quiet")
+	}
+
+	until := schedule.QuietUntil(at)
+	want := time.Date(2026, time.March, 5, 7, 0, 0, 0, tokyo)
+	if !until.Equal(want) {
+		t.Errorf("Expected quiet until %s, got %s", want, until)
+	}
+}
+
+func TestScheduleQuietUntil(t *testing.T) {
+	schedule, _ := ParseSchedule("22:00-07:00; 06:30-08:00", "", "")
+
+	// Overlapping windows end at the later one
+	if got := schedule.QuietUntil(date(4, 23, 0)); !got.Equal(date(5, 8, 0)) {
+		t.Errorf("Expected quiet until 08:00, got %s", got)
+	}
+	if got := schedule.QuietUntil(date(4, 12, 0)); !got.Equal(date(4, 12, 0)) {
+		t.Errorf("Expected a time outside quiet hours to be returned unchanged, got %s", got)
+	}
+
+	always, _ := ParseSchedule("00:00-00:00", "", "")
+	if got := always.QuietUntil(date(4, 12, 0)); !got.IsZero() {
+		t.Errorf("Expected quiet hours that never end to return zero, got %s", got)
+	}
+}
+
+// recordingNotifier records notifications
+type recordingNotifier struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (n *recordingNotifier) Notify(_ context.Context, _, message string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.messages = append(n.messages, message)
+	return nil
+}
+
+func (n *recordingNotifier) Messages() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]string(nil), n.messages...)
+}
+
+// quietSpeaker returns a speaker in quiet hours (22:00-07:00) at 23:00
+func quietSpeaker(policy string) (*Speaker, *FakeBackend, *FakeClock) {
+	config := DefaultConfig()
+	config.QuietHours = true
+	config.QuietPolicy = policy
+
+	backend := NewFakeBackend()
+	clock := NewFakeClock(date(4, 23, 0))
+	speaker := NewSpeakerWithBackend(config, backend)
+	speaker.SetClock(clock)
+	return speaker, backend, clock
+}
+
+func TestQuietPolicyDrop(t *testing.T) {
+	speaker, backend, _ := quietSpeaker(QuietDrop)
+	defer speaker.Close()
+
+	speaker.Speak("Dropped.")
+	waitFor(t, func() bool { return speaker.Pending() == 0 && !speaker.IsSpeaking() })
+
+	if got := backend.Texts(); len(got) != 0 {
+		t.Errorf("Expected nothing spoken, got %v", got)
+	}
+}
+
+func TestQuietPolicyDefer(t *testing.T) {
+	speaker, backend, clock := quietSpeaker(QuietDefer)
+	defer speaker.Close()
+
+	speaker.Speak("Good morning.")
+	waitFor(t, func() bool { return clock.Waiters() > 0 })
+
+	if speaker.Pending() != 1 || len(backend.Texts()) != 0 {
+		t.Fatalf("Expected message to wait, pending %d, spoken %v", speaker.Pending(), backend.Texts())
+	}
+
+	// Not yet: 06:59
+	clock.Advance(7*time.Hour + 59*time.Minute)
+	time.Sleep(20 * time.Millisecond)
+	if len(backend.Texts()) != 0 {
+		t.Fatal("Expected message to wait until quiet hours end")
+	}
+
+	clock.Advance(time.Minute)
+	waitFor(t, func() bool { return len(backend.Texts()) == 1 })
+	if got := backend.Texts(); got[0] != "Good morning." {
+		t.Errorf("Expected deferred message, got %v", got)
+	}
+}
+
+func TestWaitDoesNotWaitForDeferredMessages(t *testing.T) {
+	speaker, _, _ := quietSpeaker(QuietDefer)
+	defer speaker.Close()
+
+	speaker.Speak("Good morning.")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := speaker.Wait(ctx); err != nil {
+		t.Errorf("Expected Wait to return while the message is deferred, got %v", err)
+	}
+	if speaker.Pending() != 1 {
+		t.Errorf("Expected the message to stay queued, got %d pending", speaker.Pending())
+	}
+}
+
+func TestQuietPolicyDeferLetsCriticalThrough(t *testing.T) {
+	speaker, backend, clock := quietSpeaker(QuietDefer)
+	defer speaker.Close()
+
+	speaker.Speak("Later.")
+	waitFor(t, func() bool { return clock.Waiters() > 0 })
+	speaker.SpeakPriority("Now!", PriorityCritical)
+
+	waitFor(t, func() bool { return len(backend.Texts()) == 1 })
+	if got := backend.Texts(); got[0] != "Now!" {
+		t.Errorf("Expected critical message, got %v", got)
+	}
+	if speaker.Pending() != 1 {
+		t.Errorf("Expected deferred message to stay queued, got %d pending", speaker.Pending())
+	}
+}
+
+func TestQuietPolicyNotify(t *testing.T) {
+	speaker, backend, _ := quietSpeaker(QuietNotify)
+	defer speaker.Close()
+	notifier := &recordingNotifier{}
+	speaker.SetNotifier(notifier)
+
+	speaker.Speak("Build finished. All tests passed.")
+	waitFor(t, func() bool { return len(notifier.Messages()) == 1 })
+
+	if got := notifier.Messages(); !reflect.DeepEqual(got, []string{"Build finished. All tests passed."}) {
+		t.Errorf("Expected one notification for the whole message, got %v", got)
+	}
+	if got := backend.Texts(); len(got) != 0 {
+		t.Errorf("Expected nothing spoken, got %v", got)
+	}
+}
+
+func TestUpdateConfigReleasesDeferredMessages(t *testing.T) {
+	speaker, backend, clock := quietSpeaker(QuietDefer)
+	defer speaker.Close()
+
+	speaker.Speak("Waiting.")
+	waitFor(t, func() bool { return clock.Waiters() > 0 })
+
+	config := speaker.GetConfig()
+	config.QuietHours = false
+	speaker.UpdateConfig(config)
+
+	waitFor(t, func() bool { return len(backend.Texts()) == 1 })
+}
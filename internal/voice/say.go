@@ -0,0 +1,117 @@
+package voice
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/anicolao/emrys/internal/audit"
+)
+
+// SayBackend speaks with the macOS 'say' command
+type SayBackend struct{}
+
+// NewSayBackend creates a backend for macOS 'say'
+func NewSayBackend() *SayBackend {
+	return &SayBackend{}
+}
+
+// Name returns "say"
+func (b *SayBackend) Name() string {
+	return BackendSay
+}
+
+// Available reports whether 'say' is installed
+func (b *SayBackend) Available() bool {
+	return commandExists("say")
+}
+
+// Capabilities reports voice and rate support
+func (b *SayBackend) Capabilities() Capabilities {
+	return Capabilities{Voices: true, Rate: true, Volume: true}
+}
+
+// Speak runs 'say' with the utterance's voice, rate and volume
+func (b *SayBackend) Speak(ctx context.Context, u Utterance) error {
+	cmd := exec.CommandContext(ctx, "say", sayArgs(u)...)
+	if err := audit.Run(auditInitiator, cmd); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("say failed: %w", err)
+	}
+	return nil
+}
+
+// Render writes the utterance to an audio file with 'say -o'
+// Paths ending in .wav get 16-bit PCM WAV; anything else gets AIFF.
+func (b *SayBackend) Render(ctx context.Context, u Utterance, path string) error {
+	args := []string{"-o", path}
+	if strings.EqualFold(filepath.Ext(path), ".wav") {
+		args = append(args, "--file-format=WAVE", "--data-format=LEI16@22050")
+	}
+	args = append(args, sayArgs(u)...)
+
+	cmd := exec.CommandContext(ctx, "say", args...)
+	if output, err := audit.CombinedOutput(auditInitiator, cmd); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("say failed: %w (output: %s)", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// Extension returns ".aiff", the format 'say' renders natively
+func (b *SayBackend) Extension() string {
+	return ".aiff"
+}
+
+// sayArgs builds the 'say' arguments for an utterance
+func sayArgs(u Utterance) []string {
+	args := []string{}
+
+	// Add voice if specified
+	if u.Voice != "" {
+		args = append(args, "-v", u.Voice)
+	}
+
+	// Add rate if not default
+	if u.Rate != 0 && u.Rate != 200 {
+		args = append(args, "-r", fmt.Sprintf("%d", u.Rate))
+	}
+
+	// 'say' has no volume flag, but honors an embedded volume command
+	text := u.Text
+	if gain := u.gain(); gain > 0 {
+		text = fmt.Sprintf("[[volm %.2f]] %s", gain, text)
+	}
+
+	// Add the message
+	return append(args, text)
+}
+
+// ListVoices runs 'say -v ?' and returns the voice names
+func (b *SayBackend) ListVoices(ctx context.Context) ([]string, error) {
+	output, err := audit.Output(auditInitiator, exec.CommandContext(ctx, "say", "-v", "?"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list voices: %w", err)
+	}
+	return parseSayVoices(string(output)), nil
+}
+
+// parseSayVoices extracts voice names from 'say -v ?' output
+// Voice listing format: "VoiceName    language    # comment"
+func parseSayVoices(output string) []string {
+	var voices []string
+	for _, line := range strings.Split(output, "\n") {
+		// Extract voice name (first field before whitespace)
+		fields := strings.Fields(line)
+		if len(fields) > 0 {
+			voices = append(voices, fields[0])
+		}
+	}
+	return voices
+}
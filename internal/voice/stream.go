@@ -0,0 +1,159 @@
+package voice
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// Stream speaks text that arrives in pieces, such as tokens from a streaming
+// model response. Each sentence is queued as soon as it is complete, so
+// speech starts before the whole reply has been generated. All sentences of
+// a stream belong to one message: Skip and Cancel drop the rest of it.
+type Stream struct {
+	speaker  *Speaker
+	id       uint64
+	priority Priority
+	stop     func() bool
+
+	mu     sync.Mutex
+	buf    strings.Builder
+	closed bool
+}
+
+// NewStream starts a stream at the given priority
+// When ctx is cancelled, anything not yet spoken is discarded and the
+// current sentence is interrupted.
+func (s *Speaker) NewStream(ctx context.Context, priority Priority) *Stream {
+	st := &Stream{
+		speaker:  s,
+		id:       s.newMessageID(),
+		priority: priority,
+	}
+	st.stop = context.AfterFunc(ctx, st.Cancel)
+	return st
+}
+
+// Write adds text to the stream and queues any sentences it completes
+// It always succeeds; text written after Cancel or Close is ignored.
+func (st *Stream) Write(p []byte) (int, error) {
+	st.WriteString(string(p))
+	return len(p), nil
+}
+
+// WriteString adds text to the stream and queues any sentences it completes
+func (st *Stream) WriteString(text string) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if st.closed {
+		return
+	}
+	st.buf.WriteString(text)
+
+	pending := st.buf.String()
+	if cut := completeUpTo(pending); cut > 0 {
+		st.buf.Reset()
+		st.buf.WriteString(pending[cut:])
+		st.speaker.enqueue(st.id, pending[:cut], st.priority)
+	}
+}
+
+// Close queues whatever text remains, even if it is not a full sentence
+func (st *Stream) Close() error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if st.closed {
+		return nil
+	}
+	st.closed = true
+
+	if rest := st.buf.String(); strings.TrimSpace(rest) != "" {
+		st.speaker.enqueue(st.id, rest, st.priority)
+	}
+	st.buf.Reset()
+	return nil
+}
+
+// Cancel discards buffered and queued text and interrupts the sentence
+// being spoken, if it belongs to this stream
+func (st *Stream) Cancel() {
+	st.mu.Lock()
+	st.closed = true
+	st.buf.Reset()
+	st.mu.Unlock()
+
+	st.stop()
+
+	st.speaker.mu.Lock()
+	defer st.speaker.mu.Unlock()
+	st.speaker.cancelMessage(st.id)
+}
+
+// completeUpTo returns the length of the longest prefix of text that ends at
+// a point where it is safe to start speaking: after a complete sentence,
+// after a heading, list item or table row, or before or after a code block.
+// It never cuts inside an open code fence. It returns 0 if there is no such
+// point yet.
+func completeUpTo(text string) int {
+	cut := 0
+	inFence := false
+	fence := ""
+	start := 0
+
+	for start < len(text) {
+		end := strings.IndexByte(text[start:], '\n')
+		complete := end >= 0
+		if complete {
+			end += start + 1
+		} else {
+			end = len(text)
+		}
+		line := strings.TrimSpace(text[start:end])
+
+		switch {
+		case inFence:
+			if complete && strings.HasPrefix(line, fence) {
+				inFence = false
+				cut = end
+			}
+		case strings.HasPrefix(line, "```") || strings.HasPrefix(line, "~~~"):
+			// Everything before a code block is complete
+			inFence = true
+			fence = line[:3]
+			cut = start
+		case complete && (line == "" || headingPattern.MatchString(line) ||
+			bulletPattern.MatchString(line) || strings.HasPrefix(line, "|")):
+			cut = end
+		default:
+			// Cut after the last sentence that ends in this line, but not at
+			// the number of an ordered list item ("1. ")
+			offset := 0
+			if loc := bulletPattern.FindStringIndex(text[start:end]); loc != nil {
+				offset = loc[1]
+			}
+			if i := lastSentenceEnd(text[start+offset : end]); i > 0 {
+				cut = start + offset + i
+			}
+		}
+
+		start = end
+	}
+
+	return cut
+}
+
+// lastSentenceEnd returns the offset just past the last sentence in line
+// that is followed by whitespace, or 0 if there is none. A sentence at the
+// very end of the line may still grow ("3." becoming "3.5"), so it only
+// counts once more text has arrived.
+func lastSentenceEnd(line string) int {
+	ends := sentenceEnds(line)
+	for i := len(ends) - 1; i >= 0; i-- {
+		if ends[i] < len(line) {
+			return ends[i]
+		}
+	}
+	return 0
+}
@@ -0,0 +1,98 @@
+package voice
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCompleteUpTo(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"no boundary yet", "Hello there", ""},
+		{"sentence may still grow", "It costs 3.", ""},
+		{"sentence then space", "Hello there. How", "Hello there."},
+		{"last of several", "One. Two! Thr", "One. Two!"},
+		{"abbreviation", "Ask Dr. Who", ""},
+		{"list number", "1. First", ""},
+		{"list item line", "- milk\n- eg", "- milk\n"},
+		{"heading line", "# Title\nBody", "# Title\n"},
+		{"wrapped paragraph", "The build\npassed. Next", "The build\npassed."},
+		{"open code fence", "Run:\n```sh\nmake. all\n", "Run:\n"},
+		{"closed code fence", "Run:\n```sh\nmake\n```\nThen", "Run:\n```sh\nmake\n```\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.text[:completeUpTo(tt.text)]; got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestStreamSpeaksSentencesAsTheyComplete(t *testing.T) {
+	backend := NewFakeBackend()
+	speaker := NewSpeakerWithBackend(DefaultConfig(), backend)
+	defer speaker.Close()
+
+	stream := speaker.NewStream(context.Background(), PriorityNormal)
+	for _, token := range []string{"The ", "model ", "is ", "ready", ". ", "It ", "uses ", "4GB"} {
+		stream.WriteString(token)
+	}
+
+	waitFor(t, func() bool { return len(backend.Texts()) == 1 })
+	if got := backend.Texts(); got[0] != "The model is ready." {
+		t.Errorf("Expected first sentence before the stream closed, got %v", got)
+	}
+
+	stream.Close()
+	waitFor(t, func() bool { return len(backend.Texts()) == 2 })
+
+	want := []string{"The model is ready.", "It uses 4 gigabytes"}
+	if got := backend.Texts(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestStreamCancelledByContext(t *testing.T) {
+	backend := &FakeBackend{Delay: 200 * time.Millisecond}
+	speaker := NewSpeakerWithBackend(DefaultConfig(), backend)
+	defer speaker.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := speaker.NewStream(ctx, PriorityNormal)
+	stream.WriteString("First sentence. Second sentence. Third")
+	waitFor(t, speaker.IsSpeaking)
+
+	cancel()
+	waitFor(t, func() bool { return !speaker.IsSpeaking() && speaker.Pending() == 0 })
+
+	stream.WriteString(" sentence. Fourth.")
+	stream.Close()
+	time.Sleep(50 * time.Millisecond)
+
+	if got := backend.Texts(); len(got) != 0 {
+		t.Errorf("Expected nothing spoken after cancel, got %v", got)
+	}
+}
+
+func TestStreamCancelKeepsOtherMessages(t *testing.T) {
+	backend := &FakeBackend{Delay: 50 * time.Millisecond}
+	speaker := NewSpeakerWithBackend(DefaultConfig(), backend)
+	defer speaker.Close()
+
+	speaker.Speak("Keep me.")
+	stream := speaker.NewStream(context.Background(), PriorityNormal)
+	stream.WriteString("Drop me. Drop me too. ")
+	stream.Cancel()
+
+	waitFor(t, func() bool { return !speaker.IsSpeaking() && speaker.Pending() == 0 })
+	if got := backend.Texts(); !reflect.DeepEqual(got, []string{"Keep me."}) {
+		t.Errorf("Expected only the other message, got %v", got)
+	}
+}
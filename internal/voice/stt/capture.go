@@ -0,0 +1,134 @@
+package stt
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"runtime"
+	"strconv"
+
+	"github.com/anicolao/emrys/internal/audit"
+)
+
+// PCMReader reads raw 16-bit little-endian mono samples, the format
+// 'sox -t raw -e signed -b 16 -c 1' and 'arecord -f S16_LE' produce
+type PCMReader struct {
+	r   io.Reader
+	buf []byte
+}
+
+// NewPCMReader creates a sample reader over raw PCM
+func NewPCMReader(r io.Reader) *PCMReader {
+	return &PCMReader{r: r}
+}
+
+// ReadSamples reads up to len(samples) samples. It blocks until at least
+// one whole sample is available and returns io.EOF at the end of input.
+func (p *PCMReader) ReadSamples(samples []int16) (int, error) {
+	need := len(samples) * 2
+	if need == 0 {
+		return 0, nil
+	}
+	if cap(p.buf) < need {
+		buf := make([]byte, len(p.buf), need)
+		copy(buf, p.buf)
+		p.buf = buf
+	}
+
+	// A previous read may have left half a sample behind
+	if len(p.buf) < 2 {
+		n, err := io.ReadAtLeast(p.r, p.buf[len(p.buf):need], 2-len(p.buf))
+		p.buf = p.buf[:len(p.buf)+n]
+		if err != nil && len(p.buf) < 2 {
+			if errors.Is(err, io.ErrUnexpectedEOF) {
+				err = io.EOF
+			}
+			return 0, err
+		}
+	}
+
+	n := min(len(samples), len(p.buf)/2)
+	for i := 0; i < n; i++ {
+		samples[i] = int16(binary.LittleEndian.Uint16(p.buf[i*2:]))
+	}
+	rest := copy(p.buf, p.buf[n*2:])
+	p.buf = p.buf[:rest]
+	return n, nil
+}
+
+// Recording is raw PCM captured from an input device
+type Recording struct {
+	io.Reader
+	cmd    *exec.Cmd
+	stdout *io.PipeReader
+	done   chan struct{}
+}
+
+// Close stops the recording
+func (r *Recording) Close() error {
+	// Closing the reader first lets the output copy finish once the
+	// process is gone, so the command can be waited for and audited
+	r.stdout.Close()
+	r.cmd.Process.Kill()
+	<-r.done
+	return nil
+}
+
+// Capture records raw 16-bit mono PCM at the given rate from an input
+// device, using sox or, on Linux, arecord. An empty device records from the
+// system default input.
+func Capture(ctx context.Context, device string, rate int) (*Recording, error) {
+	name, args, err := captureCommand(device, rate)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	stdout, pipe := io.Pipe()
+	cmd.Stdout = pipe
+	wait, err := audit.Start(auditInitiator, cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", name, err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// The reader sees EOF, or the error, once the process exits
+		pipe.CloseWithError(wait())
+	}()
+	return &Recording{Reader: stdout, cmd: cmd, stdout: stdout, done: done}, nil
+}
+
+// captureCommand returns the command that records from device
+func captureCommand(device string, rate int) (string, []string, error) {
+	if _, err := exec.LookPath("sox"); err == nil {
+		return "sox", soxCaptureArgs(runtime.GOOS, device, rate), nil
+	}
+	if _, err := exec.LookPath("arecord"); err == nil && runtime.GOOS == "linux" {
+		args := []string{"-q", "-f", "S16_LE", "-r", strconv.Itoa(rate), "-c", "1", "-t", "raw"}
+		if device != "" {
+			args = append(args, "-D", device)
+		}
+		return "arecord", args, nil
+	}
+	return "", nil, fmt.Errorf("no audio capture tool found: install sox")
+}
+
+// soxCaptureArgs builds the sox arguments to record from device on goos
+func soxCaptureArgs(goos, device string, rate int) []string {
+	input := []string{"-d"}
+	if device != "" {
+		driver := "alsa"
+		if goos == "darwin" {
+			driver = "coreaudio"
+		}
+		input = []string{"-t", driver, device}
+	}
+
+	args := append([]string{"-q"}, input...)
+	return append(args, "-t", "raw", "-r", strconv.Itoa(rate), "-e", "signed-integer", "-b", "16", "-c", "1", "-")
+}
@@ -0,0 +1,138 @@
+package stt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+)
+
+// DefaultEchoGuard is how long capture stays paused after Emrys stops
+// talking, so the tail of its own voice in the room is not transcribed
+const DefaultEchoGuard = 300 * time.Millisecond
+
+// Speaking reports whether Emrys is talking or has speech queued
+// *voice.Speaker satisfies it.
+type Speaking interface {
+	IsSpeaking() bool
+	Pending() int
+}
+
+// Handler receives each transcribed utterance
+type Handler func(ctx context.Context, transcript Transcript) error
+
+// Listener segments a stream of audio into utterances, transcribes each one
+// and passes the transcript to a handler
+type Listener struct {
+	Transcriber Transcriber
+	Handler     Handler
+	SampleRate  int // Rate of the input (0: SampleRate)
+	VAD         VADOptions
+	Speaker     Speaking      // Capture pauses while it talks (nil: never)
+	EchoGuard   time.Duration // Audio ignored after the speaker stops (0: DefaultEchoGuard)
+	Logger      *slog.Logger  // Receives transcription and handler errors (nil: slog.Default())
+}
+
+// Listen reads raw 16-bit mono PCM from r until it ends or ctx is cancelled
+// Utterances are transcribed and handled one at a time in the background
+// while capture continues; Listen waits for them before returning.
+func (l *Listener) Listen(ctx context.Context, r io.Reader) error {
+	rate := l.SampleRate
+	if rate <= 0 {
+		rate = SampleRate
+	}
+	echo := l.EchoGuard
+	if echo <= 0 {
+		echo = DefaultEchoGuard
+	}
+	segmenter := NewSegmenter(rate, l.VAD)
+
+	utterances := make(chan Audio, 4)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for audio := range utterances {
+			l.handle(ctx, audio)
+		}
+	}()
+	defer func() {
+		close(utterances)
+		<-done
+	}()
+
+	send := func(audio Audio) {
+		select {
+		case utterances <- audio:
+		case <-ctx.Done():
+		}
+	}
+
+	reader := NewPCMReader(r)
+	buf := make([]int16, segmenter.frameLen)
+	guard := 0 // Samples still to ignore after the speaker stopped
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		n, err := reader.ReadSamples(buf)
+		chunk := buf[:n]
+		if l.paused() {
+			// Drop anything heard while talking, including a partial utterance
+			segmenter.Reset()
+			guard = int(int64(rate) * int64(echo) / int64(time.Second))
+			chunk = nil
+		} else if guard > 0 {
+			skip := min(guard, len(chunk))
+			guard -= skip
+			chunk = chunk[skip:]
+		}
+		for _, audio := range segmenter.Write(chunk) {
+			send(audio)
+		}
+
+		if errors.Is(err, io.EOF) {
+			if audio, ok := segmenter.Flush(); ok {
+				send(audio)
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read audio: %w", err)
+		}
+	}
+}
+
+// paused reports whether the speaker is talking
+func (l *Listener) paused() bool {
+	return l.Speaker != nil && (l.Speaker.IsSpeaking() || l.Speaker.Pending() > 0)
+}
+
+// handle transcribes an utterance and passes it to the handler
+func (l *Listener) handle(ctx context.Context, audio Audio) {
+	if ctx.Err() != nil {
+		return
+	}
+	logger := l.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	transcript, err := l.Transcriber.TranscribeAudio(ctx, audio)
+	if errors.Is(err, ErrNoSpeech) || ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		logger.Error("transcription failed", "duration_ms", audio.Duration(), "error", err)
+		return
+	}
+	if transcript.Text() == "" {
+		return
+	}
+
+	if err := l.Handler(ctx, transcript); err != nil && ctx.Err() == nil {
+		logger.Error("handling utterance failed", "text", transcript.Text(), "error", err)
+	}
+}
@@ -0,0 +1,206 @@
+package stt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/iotest"
+)
+
+// scriptedTranscriber returns the next of a list of texts for each utterance
+type scriptedTranscriber struct {
+	mu     sync.Mutex
+	texts  []string
+	err    error
+	audios []Audio
+}
+
+func (s *scriptedTranscriber) Transcribe(ctx context.Context, path string) (Transcript, error) {
+	audio, err := ReadWAV(path)
+	if err != nil {
+		return Transcript{}, err
+	}
+	return s.TranscribeAudio(ctx, audio)
+}
+
+func (s *scriptedTranscriber) TranscribeAudio(_ context.Context, audio Audio) (Transcript, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.audios = append(s.audios, audio)
+	if s.err != nil {
+		return Transcript{}, s.err
+	}
+	if len(s.texts) == 0 {
+		return Transcript{Segments: []Segment{{Text: "[BLANK_AUDIO]"}}}, nil
+	}
+	text := s.texts[0]
+	s.texts = s.texts[1:]
+	return Transcript{Language: "en", Segments: []Segment{{Text: text}}}, nil
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n atomic.Int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+// talkingSpeaker pretends to speak while the input is between two offsets
+type talkingSpeaker struct {
+	input    *countingReader
+	from, to int64 // Byte offsets in the input
+}
+
+func (s *talkingSpeaker) IsSpeaking() bool {
+	n := s.input.n.Load()
+	return n >= s.from && n < s.to
+}
+
+func (s *talkingSpeaker) Pending() int {
+	return 0
+}
+
+// transcripts collects handled transcripts
+type transcripts struct {
+	mu    sync.Mutex
+	texts []string
+}
+
+func (t *transcripts) handle(_ context.Context, transcript Transcript) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.texts = append(t.texts, transcript.Text())
+	return nil
+}
+
+func openFixture(t *testing.T) *os.File {
+	t.Helper()
+	f, err := os.Open("testdata/two-utterances.pcm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+func TestListenerTranscribesUtterances(t *testing.T) {
+	transcriber := &scriptedTranscriber{texts: []string{"Hello Emrys.", "What's new?"}}
+	var got transcripts
+	listener := &Listener{Transcriber: transcriber, Handler: got.handle}
+
+	if err := listener.Listen(context.Background(), openFixture(t)); err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+
+	want := []string{"Hello Emrys.", "What's new?"}
+	if !reflect.DeepEqual(got.texts, want) {
+		t.Errorf("Expected %v, got %v", want, got.texts)
+	}
+	if len(transcriber.audios) != 2 {
+		t.Errorf("Expected 2 utterances transcribed, got %d", len(transcriber.audios))
+	}
+}
+
+func TestListenerPausesWhileSpeaking(t *testing.T) {
+	input := &countingReader{r: openFixture(t)}
+	// Emrys talks over the first utterance (0.5s-1.1s, bytes 16000-35200)
+	speaker := &talkingSpeaker{input: input, from: 12000, to: 40000}
+
+	transcriber := &scriptedTranscriber{texts: []string{"Only this."}}
+	var got transcripts
+	listener := &Listener{Transcriber: transcriber, Handler: got.handle, Speaker: speaker}
+
+	if err := listener.Listen(context.Background(), input); err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+
+	if len(transcriber.audios) != 1 {
+		t.Fatalf("Expected only the second utterance to be transcribed, got %d", len(transcriber.audios))
+	}
+	if !reflect.DeepEqual(got.texts, []string{"Only this."}) {
+		t.Errorf("Expected [Only this.], got %v", got.texts)
+	}
+}
+
+func TestListenerSkipsSilenceAndLogsErrors(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+
+	// Transcripts without words are not handled
+	var got transcripts
+	listener := &Listener{Transcriber: &scriptedTranscriber{}, Handler: got.handle, Logger: logger}
+	if err := listener.Listen(context.Background(), openFixture(t)); err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	if len(got.texts) != 0 {
+		t.Errorf("Expected nothing handled, got %v", got.texts)
+	}
+
+	// Transcription errors are logged and listening continues
+	listener.Transcriber = &scriptedTranscriber{err: errors.New("model missing")}
+	if err := listener.Listen(context.Background(), openFixture(t)); err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	if n := strings.Count(logs.String(), "model missing"); n != 2 {
+		t.Errorf("Expected 2 logged errors, got %d in %q", n, logs.String())
+	}
+}
+
+func TestListenerCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	listener := &Listener{Transcriber: &scriptedTranscriber{}, Handler: (&transcripts{}).handle}
+	if err := listener.Listen(ctx, openFixture(t)); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestPCMReaderOddReads(t *testing.T) {
+	data := []byte{1, 0, 2, 0, 255, 255, 7}
+	reader := NewPCMReader(iotest.OneByteReader(bytes.NewReader(data)))
+
+	var got []int16
+	buf := make([]int16, 2)
+	for {
+		n, err := reader.ReadSamples(buf)
+		got = append(got, buf[:n]...)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("ReadSamples failed: %v", err)
+		}
+	}
+
+	// The trailing half sample is dropped
+	if want := []int16{1, 2, -1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestSoxCaptureArgs(t *testing.T) {
+	got := soxCaptureArgs("darwin", "MacBook Pro Microphone", 16000)
+	want := []string{"-q", "-t", "coreaudio", "MacBook Pro Microphone", "-t", "raw", "-r", "16000", "-e", "signed-integer", "-b", "16", "-c", "1", "-"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	got = soxCaptureArgs("linux", "", 16000)
+	if got[1] != "-d" {
+		t.Errorf("Expected the default device, got %v", got)
+	}
+}
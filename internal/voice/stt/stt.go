@@ -0,0 +1,246 @@
+// Package stt transcribes speech with a local whisper.cpp installation
+package stt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anicolao/emrys/internal/audit"
+)
+
+// ErrNoSpeech is returned when a recording contains nothing to transcribe
+var ErrNoSpeech = errors.New("no speech in recording")
+
+// LanguageAuto asks whisper.cpp to detect the spoken language
+const LanguageAuto = "auto"
+
+// auditInitiator attributes transcription and capture commands in the audit log
+const auditInitiator = "listen"
+
+// binaries are the names whisper.cpp's command-line tool is installed under
+var binaries = []string{"whisper-cli", "whisper-cpp"}
+
+// DefaultModelDir returns the directory searched for whisper.cpp models
+func DefaultModelDir() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".config", "emrys", "whisper")
+}
+
+// Segment is a span of transcribed speech
+type Segment struct {
+	Start time.Duration // Offset from the start of the recording
+	End   time.Duration
+	Text  string
+}
+
+// Transcript is the result of transcribing a recording
+type Transcript struct {
+	Language string // Code of the spoken language, such as "en"
+	Segments []Segment
+}
+
+// Text returns the transcribed speech as one string, leaving out markers
+// such as [BLANK_AUDIO] that whisper emits for non-speech sounds
+func (t Transcript) Text() string {
+	var parts []string
+	for _, seg := range t.Segments {
+		text := strings.TrimSpace(seg.Text)
+		if text == "" || isMarker(text) {
+			continue
+		}
+		parts = append(parts, text)
+	}
+	return strings.Join(parts, " ")
+}
+
+// isMarker reports whether a segment is a non-speech annotation such as
+// "[BLANK_AUDIO]" or "(wind blowing)"
+func isMarker(text string) bool {
+	return (strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]")) ||
+		(strings.HasPrefix(text, "(") && strings.HasSuffix(text, ")"))
+}
+
+// Transcriber turns recorded speech into text
+type Transcriber interface {
+	// Transcribe transcribes a 16-bit PCM WAV file
+	Transcribe(ctx context.Context, path string) (Transcript, error)
+	// TranscribeAudio transcribes captured samples, such as a microphone chunk
+	TranscribeAudio(ctx context.Context, audio Audio) (Transcript, error)
+}
+
+// Whisper transcribes with the whisper.cpp command-line tool
+type Whisper struct {
+	Binary   string // Command to run (empty: whisper-cli or whisper-cpp on PATH)
+	Model    string // Path to a ggml model (empty: the first model in ModelDir)
+	ModelDir string
+	Language string // Language code, or LanguageAuto to detect it
+	Threads  int    // Threads to use (0: whisper.cpp's default)
+}
+
+// NewWhisper creates a transcriber using models in modelDir that detects
+// the spoken language. An empty modelDir uses DefaultModelDir.
+func NewWhisper(modelDir string) *Whisper {
+	if modelDir == "" {
+		modelDir = DefaultModelDir()
+	}
+	return &Whisper{ModelDir: modelDir, Language: LanguageAuto}
+}
+
+// Available reports whether whisper.cpp and a model are installed
+func (w *Whisper) Available() bool {
+	if _, err := w.binary(); err != nil {
+		return false
+	}
+	_, err := w.model()
+	return err == nil
+}
+
+// binary returns the whisper.cpp command to run
+func (w *Whisper) binary() (string, error) {
+	if w.Binary != "" {
+		return w.Binary, nil
+	}
+	for _, name := range binaries {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("whisper.cpp not found: install whisper-cpp and make sure whisper-cli is on PATH")
+}
+
+// model returns the path of the model to transcribe with
+func (w *Whisper) model() (string, error) {
+	if w.Model != "" {
+		return w.Model, nil
+	}
+
+	models, _ := filepath.Glob(filepath.Join(w.ModelDir, "ggml-*.bin"))
+	if len(models) == 0 {
+		return "", fmt.Errorf("no whisper model found in %s", w.ModelDir)
+	}
+	sort.Strings(models)
+	return models[0], nil
+}
+
+// Transcribe transcribes a 16-bit PCM WAV file of any sample rate and
+// channel count
+func (w *Whisper) Transcribe(ctx context.Context, path string) (Transcript, error) {
+	audio, err := ReadWAV(path)
+	if err != nil {
+		return Transcript{}, err
+	}
+	return w.TranscribeAudio(ctx, audio)
+}
+
+// TranscribeAudio transcribes mono samples, resampling them to the 16 kHz
+// whisper.cpp expects. It returns ErrNoSpeech for empty audio.
+func (w *Whisper) TranscribeAudio(ctx context.Context, audio Audio) (Transcript, error) {
+	if len(audio.Samples) == 0 {
+		return Transcript{}, ErrNoSpeech
+	}
+
+	binary, err := w.binary()
+	if err != nil {
+		return Transcript{}, err
+	}
+	model, err := w.model()
+	if err != nil {
+		return Transcript{}, err
+	}
+
+	dir, err := os.MkdirTemp("", "emrys-stt-*")
+	if err != nil {
+		return Transcript{}, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	input := filepath.Join(dir, "input.wav")
+	f, err := os.Create(input)
+	if err != nil {
+		return Transcript{}, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	err = audio.Resample(SampleRate).WriteWAV(f)
+	f.Close()
+	if err != nil {
+		return Transcript{}, err
+	}
+
+	output := filepath.Join(dir, "output")
+	cmd := exec.CommandContext(ctx, binary, w.args(model, input, output)...)
+	if out, err := audit.CombinedOutput(auditInitiator, cmd); err != nil {
+		if ctx.Err() != nil {
+			return Transcript{}, ctx.Err()
+		}
+		return Transcript{}, fmt.Errorf("whisper.cpp failed: %w (output: %s)", err, strings.TrimSpace(string(out)))
+	}
+
+	data, err := os.ReadFile(output + ".json")
+	if err != nil {
+		return Transcript{}, fmt.Errorf("failed to read transcription: %w", err)
+	}
+	return parseOutput(data)
+}
+
+// args builds the whisper.cpp arguments to transcribe input into output.json
+func (w *Whisper) args(model, input, output string) []string {
+	language := w.Language
+	if language == "" {
+		language = LanguageAuto
+	}
+	args := []string{
+		"-m", model,
+		"-f", input,
+		"-l", language,
+		"-oj", "-of", output,
+		"-np",
+	}
+	if w.Threads > 0 {
+		args = append(args, "-t", strconv.Itoa(w.Threads))
+	}
+	return args
+}
+
+// whisperOutput is the JSON whisper.cpp writes with -oj
+type whisperOutput struct {
+	Result struct {
+		Language string `json:"language"`
+	} `json:"result"`
+	Transcription []whisperSegment `json:"transcription"`
+}
+
+// whisperSegment is a segment of whisper.cpp JSON output, with offsets in
+// milliseconds
+type whisperSegment struct {
+	Offsets struct {
+		From int64 `json:"from"`
+		To   int64 `json:"to"`
+	} `json:"offsets"`
+	Text string `json:"text"`
+}
+
+// parseOutput converts whisper.cpp JSON output to a transcript
+func parseOutput(data []byte) (Transcript, error) {
+	var out whisperOutput
+	if err := json.Unmarshal(data, &out); err != nil {
+		return Transcript{}, fmt.Errorf("failed to parse transcription: %w", err)
+	}
+
+	t := Transcript{Language: out.Result.Language}
+	for _, seg := range out.Transcription {
+		t.Segments = append(t.Segments, Segment{
+			Start: time.Duration(seg.Offsets.From) * time.Millisecond,
+			End:   time.Duration(seg.Offsets.To) * time.Millisecond,
+			Text:  strings.TrimSpace(seg.Text),
+		})
+	}
+	return t, nil
+}
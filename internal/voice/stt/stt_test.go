@@ -0,0 +1,260 @@
+package stt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anicolao/emrys/internal/audit"
+)
+
+// TestMain lets the test binary stand in for whisper-cli. When
+// EMRYS_FAKE_WHISPER is set it transcribes its input as the segments listed
+// in EMRYS_FAKE_WHISPER_TEXT, spread evenly over the recording. Otherwise it
+// runs the tests with their commands kept out of the user's real audit log.
+func TestMain(m *testing.M) {
+	if os.Getenv("EMRYS_FAKE_WHISPER") != "" {
+		if err := fakeWhisper(os.Args[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	dir, err := os.MkdirTemp("", "emrys-audit")
+	if err != nil {
+		panic(err)
+	}
+	log, err := audit.Open(filepath.Join(dir, "audit.jsonl"))
+	if err != nil {
+		panic(err)
+	}
+	audit.SetDefault(log)
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// fakeWhisper implements the parts of whisper-cli that Whisper uses
+func fakeWhisper(args []string) error {
+	if path := os.Getenv("EMRYS_FAKE_WHISPER_ARGS"); path != "" {
+		os.WriteFile(path, []byte(strings.Join(args, "\n")), 0644)
+	}
+
+	opts := make(map[string]string)
+	for i := 0; i+1 < len(args); i++ {
+		if strings.HasPrefix(args[i], "-") && !strings.HasPrefix(args[i+1], "-") {
+			opts[args[i]] = args[i+1]
+		}
+	}
+
+	if _, err := os.Stat(opts["-m"]); err != nil {
+		return fmt.Errorf("failed to open model %s", opts["-m"])
+	}
+	audio, err := ReadWAV(opts["-f"])
+	if err != nil {
+		return err
+	}
+	if audio.SampleRate != SampleRate {
+		return fmt.Errorf("WAV file must be 16 kHz, got %d", audio.SampleRate)
+	}
+
+	texts := strings.Split(os.Getenv("EMRYS_FAKE_WHISPER_TEXT"), "|")
+	silent := true
+	for _, s := range audio.Samples {
+		if s != 0 {
+			silent = false
+			break
+		}
+	}
+	if silent {
+		texts = []string{"[BLANK_AUDIO]"}
+	}
+
+	var out whisperOutput
+	out.Result.Language = opts["-l"]
+	if out.Result.Language == LanguageAuto {
+		out.Result.Language = "en"
+	}
+	duration := audio.Duration()
+	for i, text := range texts {
+		var seg whisperSegment
+		seg.Offsets.From = duration * int64(i) / int64(len(texts))
+		seg.Offsets.To = duration * int64(i+1) / int64(len(texts))
+		seg.Text = " " + text
+		out.Transcription = append(out.Transcription, seg)
+	}
+
+	data, err := json.Marshal(out)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(opts["-of"]+".json", data, 0644)
+}
+
+// fakeWhisperFor returns a Whisper that runs the fake with a model in a
+// temporary directory and transcribes speech as texts
+func fakeWhisperFor(t *testing.T, texts ...string) *Whisper {
+	t.Helper()
+	t.Setenv("EMRYS_FAKE_WHISPER", "1")
+	t.Setenv("EMRYS_FAKE_WHISPER_TEXT", strings.Join(texts, "|"))
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "ggml-base.bin"), []byte("model"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	w := NewWhisper(dir)
+	w.Binary = os.Args[0]
+	return w
+}
+
+func TestWhisperTranscribe(t *testing.T) {
+	w := fakeWhisperFor(t, "Hello Emrys.", "What time is it?")
+
+	transcript, err := w.Transcribe(context.Background(), "testdata/tone-16k-mono.wav")
+	if err != nil {
+		t.Fatalf("Transcribe failed: %v", err)
+	}
+
+	want := Transcript{
+		Language: "en",
+		Segments: []Segment{
+			{Start: 0, End: 750 * time.Millisecond, Text: "Hello Emrys."},
+			{Start: 750 * time.Millisecond, End: 1500 * time.Millisecond, Text: "What time is it?"},
+		},
+	}
+	if !reflect.DeepEqual(transcript, want) {
+		t.Errorf("Expected %+v, got %+v", want, transcript)
+	}
+	if got := transcript.Text(); got != "Hello Emrys. What time is it?" {
+		t.Errorf("Expected joined text, got %q", got)
+	}
+}
+
+func TestWhisperTranscribeConvertsAudio(t *testing.T) {
+	w := fakeWhisperFor(t, "Converted.")
+
+	// The fixture is 8 kHz stereo; the fake only accepts 16 kHz mono
+	transcript, err := w.Transcribe(context.Background(), "testdata/tone-8k-stereo.wav")
+	if err != nil {
+		t.Fatalf("Transcribe failed: %v", err)
+	}
+	if len(transcript.Segments) != 1 || transcript.Segments[0].End != time.Second {
+		t.Errorf("Expected one segment ending at 1s, got %+v", transcript.Segments)
+	}
+}
+
+func TestWhisperLanguage(t *testing.T) {
+	w := fakeWhisperFor(t, "Bonjour.")
+	w.Language = "fr"
+	w.Threads = 4
+
+	argsFile := filepath.Join(t.TempDir(), "args")
+	t.Setenv("EMRYS_FAKE_WHISPER_ARGS", argsFile)
+
+	transcript, err := w.Transcribe(context.Background(), "testdata/tone-16k-mono.wav")
+	if err != nil {
+		t.Fatalf("Transcribe failed: %v", err)
+	}
+	if transcript.Language != "fr" {
+		t.Errorf("Expected language 'fr', got %q", transcript.Language)
+	}
+
+	data, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := string(data)
+	for _, want := range []string{"-l\nfr", "-t\n4", "-m\n" + filepath.Join(w.ModelDir, "ggml-base.bin"), "-oj"} {
+		if !strings.Contains(args, want) {
+			t.Errorf("Expected arguments to contain %q, got %q", want, args)
+		}
+	}
+}
+
+func TestWhisperSilence(t *testing.T) {
+	w := fakeWhisperFor(t, "unused")
+
+	transcript, err := w.Transcribe(context.Background(), "testdata/silence-16k-mono.wav")
+	if err != nil {
+		t.Fatalf("Transcribe failed: %v", err)
+	}
+	if got := transcript.Text(); got != "" {
+		t.Errorf("Expected no text for silence, got %q", got)
+	}
+}
+
+func TestWhisperEmptyAudio(t *testing.T) {
+	w := fakeWhisperFor(t)
+
+	_, err := w.TranscribeAudio(context.Background(), Audio{SampleRate: SampleRate})
+	if !errors.Is(err, ErrNoSpeech) {
+		t.Errorf("Expected ErrNoSpeech, got %v", err)
+	}
+}
+
+func TestWhisperFailure(t *testing.T) {
+	w := fakeWhisperFor(t, "unused")
+	w.Model = filepath.Join(t.TempDir(), "missing.bin")
+
+	_, err := w.Transcribe(context.Background(), "testdata/tone-16k-mono.wav")
+	if err == nil || !strings.Contains(err.Error(), "failed to open model") {
+		t.Errorf("Expected the whisper.cpp error output, got %v", err)
+	}
+}
+
+func TestWhisperModelDiscovery(t *testing.T) {
+	dir := t.TempDir()
+	w := NewWhisper(dir)
+	w.Binary = os.Args[0]
+
+	if w.Available() {
+		t.Error("Expected whisper to be unavailable without a model")
+	}
+
+	for _, name := range []string{"ggml-tiny.bin", "ggml-base.bin", "notes.txt"} {
+		os.WriteFile(filepath.Join(dir, name), nil, 0644)
+	}
+	if !w.Available() {
+		t.Error("Expected whisper to be available with a model")
+	}
+	if model, _ := w.model(); filepath.Base(model) != "ggml-base.bin" {
+		t.Errorf("Expected ggml-base.bin, got %s", model)
+	}
+}
+
+func TestParseOutput(t *testing.T) {
+	data := []byte(`{
+		"result": {"language": "de"},
+		"transcription": [
+			{"timestamps": {"from": "00:00:00,000", "to": "00:00:02,480"}, "offsets": {"from": 0, "to": 2480}, "text": " Guten Morgen."},
+			{"timestamps": {"from": "00:00:02,480", "to": "00:00:04,000"}, "offsets": {"from": 2480, "to": 4000}, "text": " [BLANK_AUDIO]"}
+		]
+	}`)
+
+	transcript, err := parseOutput(data)
+	if err != nil {
+		t.Fatalf("parseOutput failed: %v", err)
+	}
+	if transcript.Language != "de" {
+		t.Errorf("Expected language 'de', got %q", transcript.Language)
+	}
+	if len(transcript.Segments) != 2 || transcript.Segments[0].End != 2480*time.Millisecond {
+		t.Errorf("Expected two segments with millisecond offsets, got %+v", transcript.Segments)
+	}
+	if got := transcript.Text(); got != "Guten Morgen." {
+		t.Errorf("Expected markers to be left out, got %q", got)
+	}
+
+	if _, err := parseOutput([]byte("not json")); err == nil {
+		t.Error("Expected an error for invalid output")
+	}
+}
@@ -0,0 +1,200 @@
+package stt
+
+import (
+	"math"
+	"time"
+)
+
+// VADOptions configures energy-based voice activity detection
+type VADOptions struct {
+	Threshold    float64       // RMS level, as a fraction of full scale, above which a frame is speech
+	Frame        time.Duration // Length of the frames energy is measured over
+	MinSpeech    time.Duration // Continuous speech needed to start an utterance
+	MaxSilence   time.Duration // Silence that ends an utterance
+	Padding      time.Duration // Audio kept from before speech started
+	MaxUtterance time.Duration // Utterances longer than this are cut
+}
+
+// DefaultVADOptions returns options suited to a close microphone in a
+// quiet room
+func DefaultVADOptions() VADOptions {
+	return VADOptions{
+		Threshold:    0.02,
+		Frame:        30 * time.Millisecond,
+		MinSpeech:    90 * time.Millisecond,
+		MaxSilence:   800 * time.Millisecond,
+		Padding:      300 * time.Millisecond,
+		MaxUtterance: 30 * time.Second,
+	}
+}
+
+// withDefaults fills unset options from DefaultVADOptions
+func (o VADOptions) withDefaults() VADOptions {
+	d := DefaultVADOptions()
+	if o.Threshold <= 0 {
+		o.Threshold = d.Threshold
+	}
+	if o.Frame <= 0 {
+		o.Frame = d.Frame
+	}
+	if o.MinSpeech <= 0 {
+		o.MinSpeech = d.MinSpeech
+	}
+	if o.MaxSilence <= 0 {
+		o.MaxSilence = d.MaxSilence
+	}
+	if o.Padding <= 0 {
+		o.Padding = d.Padding
+	}
+	if o.MaxUtterance <= 0 {
+		o.MaxUtterance = d.MaxUtterance
+	}
+	return o
+}
+
+// Segmenter splits a stream of mono samples into utterances by frame energy
+type Segmenter struct {
+	opts VADOptions
+	rate int
+
+	frameLen      int // Samples per frame
+	startFrames   int // Speech frames that start an utterance
+	endFrames     int // Silent frames that end an utterance
+	paddingFrames int
+	maxSamples    int
+
+	partial  []int16   // Samples not yet making up a whole frame
+	preroll  [][]int16 // Recent frames from before the utterance
+	current  []int16   // The utterance so far
+	inSpeech bool
+	voiced   int // Consecutive speech frames before the utterance
+	silent   int // Consecutive silent frames in the utterance
+}
+
+// NewSegmenter creates a segmenter for audio at the given sample rate
+// Zero options are taken from DefaultVADOptions.
+func NewSegmenter(rate int, opts VADOptions) *Segmenter {
+	opts = opts.withDefaults()
+	frames := func(d time.Duration) int {
+		return int(math.Ceil(float64(d) / float64(opts.Frame)))
+	}
+
+	return &Segmenter{
+		opts:          opts,
+		rate:          rate,
+		frameLen:      max(1, int(int64(rate)*int64(opts.Frame)/int64(time.Second))),
+		startFrames:   max(1, frames(opts.MinSpeech)),
+		endFrames:     max(1, frames(opts.MaxSilence)),
+		paddingFrames: frames(opts.Padding),
+		maxSamples:    int(int64(rate) * int64(opts.MaxUtterance) / int64(time.Second)),
+	}
+}
+
+// Write adds samples and returns the utterances they complete
+func (s *Segmenter) Write(samples []int16) []Audio {
+	var done []Audio
+	s.partial = append(s.partial, samples...)
+	for len(s.partial) >= s.frameLen {
+		frame := append([]int16(nil), s.partial[:s.frameLen]...)
+		s.partial = s.partial[s.frameLen:]
+		if utterance, ok := s.frame(frame); ok {
+			done = append(done, utterance)
+		}
+	}
+	return done
+}
+
+// frame processes one frame and returns an utterance if it completed one
+func (s *Segmenter) frame(frame []int16) (Audio, bool) {
+	speech := RMS(frame) >= s.opts.Threshold
+
+	if !s.inSpeech {
+		s.preroll = append(s.preroll, frame)
+		if keep := s.paddingFrames + s.startFrames; len(s.preroll) > keep {
+			s.preroll = s.preroll[len(s.preroll)-keep:]
+		}
+		if !speech {
+			s.voiced = 0
+			return Audio{}, false
+		}
+		s.voiced++
+		if s.voiced < s.startFrames {
+			return Audio{}, false
+		}
+
+		s.inSpeech = true
+		s.silent = 0
+		for _, f := range s.preroll {
+			s.current = append(s.current, f...)
+		}
+		s.preroll = nil
+		return Audio{}, false
+	}
+
+	s.current = append(s.current, frame...)
+	if speech {
+		s.silent = 0
+	} else {
+		s.silent++
+	}
+
+	if s.silent >= s.endFrames {
+		utterance := s.take(s.speechEnd())
+		s.inSpeech = false
+		s.voiced = 0
+		return utterance, true
+	}
+	if s.maxSamples > 0 && len(s.current) >= s.maxSamples {
+		// Cut overlong utterances but keep listening to the speaker
+		return s.take(len(s.current)), true
+	}
+	return Audio{}, false
+}
+
+// speechEnd returns the length of the current utterance without trailing
+// silence beyond the padding
+func (s *Segmenter) speechEnd() int {
+	trim := max(0, s.silent-s.paddingFrames) * s.frameLen
+	return len(s.current) - trim
+}
+
+// take returns the first n samples of the current utterance and starts a
+// new one
+func (s *Segmenter) take(n int) Audio {
+	utterance := Audio{SampleRate: s.rate, Samples: s.current[:n]}
+	s.current = nil
+	return utterance
+}
+
+// Flush ends the utterance in progress, if any, such as at the end of input
+func (s *Segmenter) Flush() (Audio, bool) {
+	defer s.Reset()
+	if !s.inSpeech || len(s.current) == 0 {
+		return Audio{}, false
+	}
+	return s.take(s.speechEnd()), true
+}
+
+// Reset discards buffered audio and any utterance in progress
+func (s *Segmenter) Reset() {
+	s.partial = nil
+	s.preroll = nil
+	s.current = nil
+	s.inSpeech = false
+	s.voiced = 0
+	s.silent = 0
+}
+
+// RMS returns the root mean square level of samples as a fraction of full
+// scale
+func RMS(samples []int16) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, sample := range samples {
+		v := float64(sample) / 32768
+		sum += v * v
+	}
+	return math.Sqrt(sum / float64(len(samples)))
+}
@@ -0,0 +1,144 @@
+package stt
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// readPCM reads a raw 16 kHz PCM fixture
+func readPCM(t *testing.T, path string) []int16 {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var samples []int16
+	reader := NewPCMReader(f)
+	buf := make([]int16, 1000)
+	for {
+		n, err := reader.ReadSamples(buf)
+		samples = append(samples, buf[:n]...)
+		if err != nil {
+			return samples
+		}
+	}
+}
+
+// tone returns a constant-level square wave of the given length at 16 kHz
+func tone(d time.Duration) []int16 {
+	samples := make([]int16, int(d.Seconds()*SampleRate))
+	for i := range samples {
+		samples[i] = 5000
+		if i%40 < 20 {
+			samples[i] = -5000
+		}
+	}
+	return samples
+}
+
+func TestRMS(t *testing.T) {
+	if got := RMS(nil); got != 0 {
+		t.Errorf("Expected 0 for no samples, got %f", got)
+	}
+	if got := RMS([]int16{16384, -16384}); got != 0.5 {
+		t.Errorf("Expected 0.5 for half-scale samples, got %f", got)
+	}
+}
+
+func TestSegmenterFixture(t *testing.T) {
+	// 0.5s of noise, 0.6s tone, 1.2s noise, 0.45s tone, 0.5s noise
+	samples := readPCM(t, "testdata/two-utterances.pcm")
+	segmenter := NewSegmenter(SampleRate, DefaultVADOptions())
+
+	utterances := segmenter.Write(samples)
+	if flushed, ok := segmenter.Flush(); ok {
+		utterances = append(utterances, flushed)
+	}
+
+	if len(utterances) != 2 {
+		t.Fatalf("Expected 2 utterances, got %d", len(utterances))
+	}
+
+	// Each utterance is its speech plus up to 300ms of padding on each side
+	for i, speech := range []int64{600, 450} {
+		got := utterances[i].Duration()
+		if got < speech || got > speech+660 {
+			t.Errorf("Utterance %d: expected %d-%dms, got %dms", i, speech, speech+660, got)
+		}
+		if utterances[i].SampleRate != SampleRate {
+			t.Errorf("Utterance %d: expected rate %d, got %d", i, SampleRate, utterances[i].SampleRate)
+		}
+	}
+}
+
+func TestSegmenterChunkedInput(t *testing.T) {
+	samples := readPCM(t, "testdata/two-utterances.pcm")
+	whole := NewSegmenter(SampleRate, DefaultVADOptions()).Write(samples)
+
+	// Feeding the same audio in odd-sized pieces gives the same result
+	segmenter := NewSegmenter(SampleRate, DefaultVADOptions())
+	var chunked []Audio
+	for len(samples) > 0 {
+		n := min(333, len(samples))
+		chunked = append(chunked, segmenter.Write(samples[:n])...)
+		samples = samples[n:]
+	}
+
+	if len(chunked) != len(whole) {
+		t.Fatalf("Expected %d utterances, got %d", len(whole), len(chunked))
+	}
+	for i := range whole {
+		if len(chunked[i].Samples) != len(whole[i].Samples) {
+			t.Errorf("Utterance %d: expected %d samples, got %d", i, len(whole[i].Samples), len(chunked[i].Samples))
+		}
+	}
+}
+
+func TestSegmenterIgnoresShortNoise(t *testing.T) {
+	segmenter := NewSegmenter(SampleRate, DefaultVADOptions())
+
+	// A 40ms click is shorter than MinSpeech
+	audio := append(make([]int16, SampleRate), tone(40*time.Millisecond)...)
+	audio = append(audio, make([]int16, SampleRate)...)
+
+	if got := segmenter.Write(audio); len(got) != 0 {
+		t.Errorf("Expected no utterances, got %d", len(got))
+	}
+	if _, ok := segmenter.Flush(); ok {
+		t.Error("Expected nothing to flush")
+	}
+}
+
+func TestSegmenterMaxUtterance(t *testing.T) {
+	opts := DefaultVADOptions()
+	opts.MaxUtterance = time.Second
+	segmenter := NewSegmenter(SampleRate, opts)
+
+	utterances := segmenter.Write(tone(2500 * time.Millisecond))
+	if len(utterances) != 2 {
+		t.Fatalf("Expected 2 cut utterances, got %d", len(utterances))
+	}
+	for i, u := range utterances {
+		if got := u.Duration(); got < 990 || got > 1030 {
+			t.Errorf("Utterance %d: expected about 1s, got %dms", i, got)
+		}
+	}
+
+	rest, ok := segmenter.Flush()
+	if !ok || rest.Duration() < 400 {
+		t.Errorf("Expected the remainder at flush, got %dms", rest.Duration())
+	}
+}
+
+func TestSegmenterReset(t *testing.T) {
+	segmenter := NewSegmenter(SampleRate, DefaultVADOptions())
+	segmenter.Write(tone(500 * time.Millisecond))
+	segmenter.Reset()
+
+	if _, ok := segmenter.Flush(); ok {
+		t.Error("Expected Reset to discard the utterance in progress")
+	}
+}
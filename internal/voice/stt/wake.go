@@ -0,0 +1,243 @@
+package stt
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/anicolao/emrys/internal/voice"
+)
+
+// Wake gate defaults
+const (
+	DefaultWakeTimeout = 15 * time.Second // Follow-ups accepted without the wake phrase
+	DefaultWakeLead    = 3 * time.Second  // Leading speech searched for the wake phrase
+)
+
+// DefaultWakePhrases are the phrases that wake Emrys, including the ways
+// whisper commonly spells the name
+var DefaultWakePhrases = []string{"emrys", "emris", "emerys"}
+
+// greetings may come before a wake phrase, as in "Hey Emrys"
+var greetings = map[string]bool{"hey": true, "hi": true, "hello": true, "ok": true, "okay": true}
+
+// Announcer speaks changes in the gate's state; *voice.Speaker satisfies it
+type Announcer interface {
+	SpeakPriority(message string, priority voice.Priority)
+}
+
+// WakeGate passes utterances on only after a wake phrase such as "Emrys,
+// ..." and for a while after each exchange, so that Emrys does not answer
+// every conversation in the room
+type WakeGate struct {
+	Next          Handler
+	Phrases       []string      // Wake phrases, optionally after "hey" or "ok" (nil: DefaultWakePhrases)
+	Timeout       time.Duration // How long the gate stays open (0: DefaultWakeTimeout)
+	Lead          time.Duration // Only segments starting this early are searched (0: DefaultWakeLead)
+	Announcer     Announcer     // Told when the gate opens and closes (nil: silent)
+	ArmMessage    string        // Spoken when a bare wake phrase opens the gate
+	DisarmMessage string        // Spoken when the gate closes after the timeout
+	Clock         voice.Clock   // (nil: the system clock)
+
+	mu         sync.Mutex
+	armed      bool
+	generation int // Incremented on every arm, so stale timeouts are ignored
+}
+
+// NewWakeGate creates a gate in front of next using the default phrases
+func NewWakeGate(next Handler, announcer Announcer) *WakeGate {
+	return &WakeGate{
+		Next:          next,
+		Announcer:     announcer,
+		ArmMessage:    "Yes?",
+		DisarmMessage: "Going back to sleep.",
+	}
+}
+
+// Handle passes the transcript to Next if it starts with a wake phrase, with
+// the phrase removed, or if the gate is still open from an earlier exchange
+func (g *WakeGate) Handle(ctx context.Context, transcript Transcript) error {
+	rest, woke := g.match(transcript)
+	if !woke {
+		if !g.Armed() {
+			return nil
+		}
+		rest = transcript
+	}
+
+	wasArmed := g.arm()
+	if rest.Text() == "" {
+		if !wasArmed {
+			g.announce(g.ArmMessage, voice.PriorityNormal)
+		}
+		return nil
+	}
+
+	err := g.Next(ctx, rest)
+	// Count the timeout from the end of the exchange, not its start
+	g.arm()
+	return err
+}
+
+// Armed reports whether utterances currently pass without the wake phrase
+func (g *WakeGate) Armed() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.armed
+}
+
+// Disarm closes the gate without an announcement
+func (g *WakeGate) Disarm() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.armed = false
+	g.generation++
+}
+
+// arm opens the gate until the timeout and reports whether it was open
+func (g *WakeGate) arm() bool {
+	timeout := g.Timeout
+	if timeout <= 0 {
+		timeout = DefaultWakeTimeout
+	}
+	clock := g.Clock
+	if clock == nil {
+		clock = voice.SystemClock()
+	}
+
+	g.mu.Lock()
+	wasArmed := g.armed
+	g.armed = true
+	g.generation++
+	generation := g.generation
+	g.mu.Unlock()
+
+	expired := clock.After(timeout)
+	go func() {
+		<-expired
+		g.mu.Lock()
+		current := g.armed && g.generation == generation
+		if current {
+			g.armed = false
+		}
+		g.mu.Unlock()
+
+		if current {
+			g.announce(g.DisarmMessage, voice.PriorityChatter)
+		}
+	}()
+	return wasArmed
+}
+
+// announce speaks message if there is an announcer and a message
+func (g *WakeGate) announce(message string, priority voice.Priority) {
+	if g.Announcer != nil && message != "" {
+		g.Announcer.SpeakPriority(message, priority)
+	}
+}
+
+// match reports whether the leading segments of the transcript start with a
+// wake phrase and returns the transcript with the phrase removed
+func (g *WakeGate) match(transcript Transcript) (Transcript, bool) {
+	lead := g.Lead
+	if lead <= 0 {
+		lead = DefaultWakeLead
+	}
+	phrases := g.Phrases
+	if phrases == nil {
+		phrases = DefaultWakePhrases
+	}
+
+	var words []string
+	for i, seg := range transcript.Segments {
+		if i > 0 && seg.Start >= lead {
+			break
+		}
+		if !isMarker(strings.TrimSpace(seg.Text)) {
+			words = append(words, wakeWords(seg.Text)...)
+		}
+	}
+
+	skip := 0
+	if len(words) > 0 && greetings[words[0]] {
+		skip = 1
+	}
+
+	// Prefer longer phrases so that the whole phrase is removed
+	candidates := make([][]string, 0, len(phrases))
+	for _, phrase := range phrases {
+		if w := wakeWords(phrase); len(w) > 0 {
+			candidates = append(candidates, w)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return len(candidates[i]) > len(candidates[j]) })
+
+	for _, phrase := range candidates {
+		for start := 0; start <= skip; start++ {
+			end := start + len(phrase)
+			if end <= len(words) && equalWords(phrase, words[start:end]) {
+				return dropWords(transcript, end), true
+			}
+		}
+	}
+	return Transcript{}, false
+}
+
+// wakeWords splits text into lowercase words without punctuation
+func wakeWords(text string) []string {
+	var words []string
+	for _, token := range strings.Fields(text) {
+		if w := normalizeWord(token); w != "" {
+			words = append(words, w)
+		}
+	}
+	return words
+}
+
+// normalizeWord lowercases a token and removes everything but letters and
+// digits
+func normalizeWord(token string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, token)
+}
+
+// equalWords reports whether two word lists are the same
+func equalWords(a, b []string) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// dropWords returns the transcript without its first n words and the
+// punctuation that followed them
+func dropWords(transcript Transcript, n int) Transcript {
+	rest := Transcript{Language: transcript.Language}
+	for _, seg := range transcript.Segments {
+		if n > 0 && !isMarker(strings.TrimSpace(seg.Text)) {
+			tokens := strings.Fields(seg.Text)
+			for len(tokens) > 0 && n > 0 {
+				if normalizeWord(tokens[0]) != "" {
+					n--
+				}
+				tokens = tokens[1:]
+			}
+			seg.Text = strings.TrimLeftFunc(strings.Join(tokens, " "), func(r rune) bool {
+				return unicode.IsPunct(r) || unicode.IsSpace(r)
+			})
+		}
+		if seg.Text != "" {
+			rest.Segments = append(rest.Segments, seg)
+		}
+	}
+	return rest
+}
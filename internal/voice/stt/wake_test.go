@@ -0,0 +1,176 @@
+package stt
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anicolao/emrys/internal/voice"
+)
+
+// recordingAnnouncer records announcements instead of speaking them
+type recordingAnnouncer struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (a *recordingAnnouncer) SpeakPriority(message string, _ voice.Priority) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.messages = append(a.messages, message)
+}
+
+func (a *recordingAnnouncer) Messages() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]string(nil), a.messages...)
+}
+
+// said builds a transcript with one segment per second
+func said(texts ...string) Transcript {
+	var t Transcript
+	for i, text := range texts {
+		start := time.Duration(i) * time.Second
+		t.Segments = append(t.Segments, Segment{Start: start, End: start + time.Second, Text: text})
+	}
+	return t
+}
+
+// newTestGate returns a gate with a fake clock that records what it passes on
+func newTestGate() (*WakeGate, *transcripts, *recordingAnnouncer, *voice.FakeClock) {
+	var got transcripts
+	announcer := &recordingAnnouncer{}
+	clock := voice.NewFakeClock(time.Date(2025, 1, 6, 12, 0, 0, 0, time.UTC))
+	gate := NewWakeGate(got.handle, announcer)
+	gate.Clock = clock
+	return gate, &got, announcer, clock
+}
+
+// eventually polls cond until it is true or the deadline passes
+func eventually(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for condition")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestWakeGateIgnoresUtterancesWithoutPhrase(t *testing.T) {
+	gate, got, announcer, _ := newTestGate()
+
+	gate.Handle(context.Background(), said("What time is it?"))
+	gate.Handle(context.Background(), said("I told Emrys about it."))
+
+	if len(got.texts) != 0 {
+		t.Errorf("Expected nothing passed on, got %v", got.texts)
+	}
+	if gate.Armed() || len(announcer.Messages()) != 0 {
+		t.Error("Expected the gate to stay closed and silent")
+	}
+}
+
+func TestWakeGateStripsPhrase(t *testing.T) {
+	tests := []struct {
+		transcript Transcript
+		want       string
+	}{
+		{said("Emrys, what time is it?"), "what time is it?"},
+		{said("Hey Emrys. Turn off the lights."), "Turn off the lights."},
+		{said("OK, Emris!", "Play some music."), "Play some music."},
+		{said("[BLANK_AUDIO]", "Emerys what's the weather?"), "what's the weather?"},
+	}
+
+	for _, tt := range tests {
+		gate, got, _, _ := newTestGate()
+		gate.Handle(context.Background(), tt.transcript)
+
+		if !reflect.DeepEqual(got.texts, []string{tt.want}) {
+			t.Errorf("For %q expected [%s], got %v", tt.transcript.Text(), tt.want, got.texts)
+		}
+	}
+}
+
+func TestWakeGateOnlySearchesLeadingSegments(t *testing.T) {
+	gate, got, _, _ := newTestGate()
+	gate.Lead = 2 * time.Second
+
+	gate.Handle(context.Background(), said("Well,", "you know,", "Emrys, hello."))
+	if len(got.texts) != 0 {
+		t.Errorf("Expected a late phrase to be ignored, got %v", got.texts)
+	}
+}
+
+func TestWakeGateCustomPhrases(t *testing.T) {
+	gate, got, _, _ := newTestGate()
+	gate.Phrases = []string{"computer"}
+
+	gate.Handle(context.Background(), said("Emrys, hello."))
+	gate.Handle(context.Background(), said("Computer, hello."))
+
+	if !reflect.DeepEqual(got.texts, []string{"hello."}) {
+		t.Errorf("Expected only the custom phrase to wake the gate, got %v", got.texts)
+	}
+}
+
+func TestWakeGateArmsAndTimesOut(t *testing.T) {
+	gate, got, announcer, clock := newTestGate()
+	ctx := context.Background()
+
+	// A bare wake phrase opens the gate and is acknowledged
+	gate.Handle(ctx, said("Emrys."))
+	if !gate.Armed() {
+		t.Fatal("Expected the wake phrase to arm the gate")
+	}
+	if !reflect.DeepEqual(announcer.Messages(), []string{"Yes?"}) {
+		t.Errorf("Expected the arm announcement, got %v", announcer.Messages())
+	}
+
+	// Follow-ups pass without the phrase and keep the gate open
+	clock.Advance(10 * time.Second)
+	gate.Handle(ctx, said("What time is it?"))
+	clock.Advance(10 * time.Second)
+	gate.Handle(ctx, said("And tomorrow?"))
+
+	want := []string{"What time is it?", "And tomorrow?"}
+	if !reflect.DeepEqual(got.texts, want) {
+		t.Errorf("Expected %v, got %v", want, got.texts)
+	}
+	if !gate.Armed() {
+		t.Error("Expected follow-ups to keep the gate open")
+	}
+
+	// Silence for the whole timeout closes it
+	clock.Advance(DefaultWakeTimeout)
+	eventually(t, func() bool { return !gate.Armed() })
+	eventually(t, func() bool { return len(announcer.Messages()) == 2 })
+	if msgs := announcer.Messages(); msgs[1] != "Going back to sleep." {
+		t.Errorf("Expected the disarm announcement, got %v", msgs)
+	}
+
+	gate.Handle(ctx, said("Are you there?"))
+	if len(got.texts) != 2 {
+		t.Errorf("Expected the closed gate to ignore utterances, got %v", got.texts)
+	}
+}
+
+func TestWakeGateDisarm(t *testing.T) {
+	gate, _, announcer, clock := newTestGate()
+
+	gate.Handle(context.Background(), said("Emrys."))
+	gate.Disarm()
+	if gate.Armed() {
+		t.Error("Expected Disarm to close the gate")
+	}
+
+	// The pending timeout must not announce a gate that is already closed
+	clock.Advance(DefaultWakeTimeout)
+	time.Sleep(20 * time.Millisecond)
+	if msgs := announcer.Messages(); len(msgs) != 1 {
+		t.Errorf("Expected only the arm announcement, got %v", msgs)
+	}
+}
@@ -0,0 +1,143 @@
+package stt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+)
+
+// SampleRate is the sample rate whisper.cpp expects
+const SampleRate = 16000
+
+// Audio is mono 16-bit PCM
+type Audio struct {
+	SampleRate int
+	Samples    []int16
+}
+
+// Duration returns the length of the audio in milliseconds
+func (a Audio) Duration() int64 {
+	if a.SampleRate == 0 {
+		return 0
+	}
+	return int64(len(a.Samples)) * 1000 / int64(a.SampleRate)
+}
+
+// ReadWAV reads a 16-bit PCM WAV file, mixing multiple channels down to mono
+func ReadWAV(path string) (Audio, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Audio{}, fmt.Errorf("failed to read audio: %w", err)
+	}
+	audio, err := decodeWAV(data)
+	if err != nil {
+		return Audio{}, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return audio, nil
+}
+
+// decodeWAV decodes a 16-bit PCM WAV file
+func decodeWAV(data []byte) (Audio, error) {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		return Audio{}, fmt.Errorf("not a WAV file")
+	}
+
+	var channels, rate int
+	for offset := 12; offset+8 <= len(data); {
+		id := string(data[offset : offset+4])
+		size := int(binary.LittleEndian.Uint32(data[offset+4:]))
+		body := offset + 8
+		end := body + size
+		if end > len(data) {
+			// Streaming writers may leave the data size unset
+			end = len(data)
+		}
+
+		switch id {
+		case "fmt ":
+			if size < 16 {
+				return Audio{}, fmt.Errorf("invalid fmt chunk")
+			}
+			format := binary.LittleEndian.Uint16(data[body:])
+			bits := binary.LittleEndian.Uint16(data[body+14:])
+			if format != 1 || bits != 16 {
+				return Audio{}, fmt.Errorf("only 16-bit PCM is supported")
+			}
+			channels = int(binary.LittleEndian.Uint16(data[body+2:]))
+			rate = int(binary.LittleEndian.Uint32(data[body+4:]))
+		case "data":
+			if channels == 0 {
+				return Audio{}, fmt.Errorf("data before fmt chunk")
+			}
+			return Audio{SampleRate: rate, Samples: mixDown(data[body:end], channels)}, nil
+		}
+
+		// Chunks are padded to an even size
+		offset = end + size%2
+	}
+
+	return Audio{}, fmt.Errorf("no data chunk")
+}
+
+// mixDown averages interleaved little-endian samples into one channel
+func mixDown(data []byte, channels int) []int16 {
+	frames := len(data) / (2 * channels)
+	samples := make([]int16, frames)
+	for i := range samples {
+		sum := 0
+		for c := 0; c < channels; c++ {
+			sum += int(int16(binary.LittleEndian.Uint16(data[(i*channels+c)*2:])))
+		}
+		samples[i] = int16(sum / channels)
+	}
+	return samples
+}
+
+// Resample converts the audio to the given rate by linear interpolation
+func (a Audio) Resample(rate int) Audio {
+	if a.SampleRate == rate || a.SampleRate == 0 || len(a.Samples) == 0 {
+		return Audio{SampleRate: rate, Samples: a.Samples}
+	}
+
+	n := int(int64(len(a.Samples)) * int64(rate) / int64(a.SampleRate))
+	out := make([]int16, n)
+	step := float64(a.SampleRate) / float64(rate)
+	for i := range out {
+		pos := float64(i) * step
+		j := int(pos)
+		if j+1 >= len(a.Samples) {
+			out[i] = a.Samples[len(a.Samples)-1]
+			continue
+		}
+		frac := pos - float64(j)
+		out[i] = int16(float64(a.Samples[j])*(1-frac) + float64(a.Samples[j+1])*frac)
+	}
+	return Audio{SampleRate: rate, Samples: out}
+}
+
+// WriteWAV writes the audio as a mono 16-bit PCM WAV file
+func (a Audio) WriteWAV(w io.Writer) error {
+	var buf bytes.Buffer
+	dataSize := uint32(len(a.Samples) * 2)
+
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, 36+dataSize)
+	buf.WriteString("WAVEfmt ")
+	binary.Write(&buf, binary.LittleEndian, uint32(16))
+	binary.Write(&buf, binary.LittleEndian, uint16(1)) // PCM
+	binary.Write(&buf, binary.LittleEndian, uint16(1)) // Mono
+	binary.Write(&buf, binary.LittleEndian, uint32(a.SampleRate))
+	binary.Write(&buf, binary.LittleEndian, uint32(a.SampleRate*2))
+	binary.Write(&buf, binary.LittleEndian, uint16(2))
+	binary.Write(&buf, binary.LittleEndian, uint16(16))
+	buf.WriteString("data")
+	binary.Write(&buf, binary.LittleEndian, dataSize)
+	binary.Write(&buf, binary.LittleEndian, a.Samples)
+
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write audio: %w", err)
+	}
+	return nil
+}
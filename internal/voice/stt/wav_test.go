@@ -0,0 +1,80 @@
+package stt
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestReadWAV(t *testing.T) {
+	tests := []struct {
+		path     string
+		rate     int
+		duration int64
+	}{
+		{"testdata/tone-16k-mono.wav", 16000, 1500},
+		{"testdata/tone-8k-stereo.wav", 8000, 1000},
+		{"testdata/silence-16k-mono.wav", 16000, 500},
+	}
+
+	for _, tt := range tests {
+		audio, err := ReadWAV(tt.path)
+		if err != nil {
+			t.Errorf("ReadWAV(%s) failed: %v", tt.path, err)
+			continue
+		}
+		if audio.SampleRate != tt.rate {
+			t.Errorf("%s: expected rate %d, got %d", tt.path, tt.rate, audio.SampleRate)
+		}
+		if got := audio.Duration(); got != tt.duration {
+			t.Errorf("%s: expected %dms, got %dms", tt.path, tt.duration, got)
+		}
+	}
+
+	if _, err := ReadWAV("stt.go"); err == nil {
+		t.Error("Expected an error for a file that is not WAV")
+	}
+}
+
+func TestMixDown(t *testing.T) {
+	// Two stereo frames: (100, 300) and (-200, 0)
+	data := []byte{100, 0, 44, 1, 56, 255, 0, 0}
+	got := mixDown(data, 2)
+	if want := []int16{200, -100}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestResample(t *testing.T) {
+	audio := Audio{SampleRate: 8000, Samples: []int16{0, 100, 200, 300}}
+
+	up := audio.Resample(16000)
+	if want := []int16{0, 50, 100, 150, 200, 250, 300, 300}; !reflect.DeepEqual(up.Samples, want) {
+		t.Errorf("Expected %v, got %v", want, up.Samples)
+	}
+	if up.Duration() != audio.Duration() {
+		t.Errorf("Expected resampling to keep the duration, got %dms and %dms", up.Duration(), audio.Duration())
+	}
+
+	same := audio.Resample(8000)
+	if !reflect.DeepEqual(same.Samples, audio.Samples) {
+		t.Errorf("Expected samples unchanged at the same rate, got %v", same.Samples)
+	}
+}
+
+func TestWriteWAVRoundTrip(t *testing.T) {
+	audio := Audio{SampleRate: SampleRate, Samples: []int16{1, -1, 32767, -32768}}
+
+	var buf bytes.Buffer
+	if err := audio.WriteWAV(&buf); err != nil {
+		t.Fatalf("WriteWAV failed: %v", err)
+	}
+
+	got, err := decodeWAV(buf.Bytes())
+	if err != nil {
+		t.Fatalf("decodeWAV failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, audio) {
+		t.Errorf("Expected %+v, got %+v", audio, got)
+	}
+}
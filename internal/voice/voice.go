@@ -1,53 +1,143 @@
 package voice
 
 import (
+	"context"
 	"fmt"
-	"os/exec"
+	"log/slog"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/anicolao/emrys/internal/logging"
 )
 
-// Config holds voice output configuration
+// Config holds voice output configuration, read from voice.conf or the
+// voice section of config.yaml
 type Config struct {
-	Enabled    bool    // Whether voice output is enabled
-	Voice      string  // Voice name (e.g., "Jamie")
-	Rate       int     // Speech rate in words per minute (default: 200)
-	Volume     float64 // Volume from 0.0 to 1.0 (default: 0.7)
-	QuietHours bool    // Whether quiet hours are enabled
-	QuietStart int     // Quiet hours start (hour in 24h format)
-	QuietEnd   int     // Quiet hours end (hour in 24h format)
+	Enabled    bool    `yaml:"enabled"`     // Whether voice output is enabled
+	Voice      string  `yaml:"voice"`       // Voice name (e.g., "Jamie")
+	Rate       int     `yaml:"rate"`        // Speech rate in words per minute (default: 200)
+	Volume     float64 `yaml:"volume"`      // Volume from 0.0 to 1.0 relative to the system volume (default: 0.7)
+	QuietHours bool    `yaml:"quiet_hours"` // Whether quiet hours are enabled
+	QuietStart int     `yaml:"quiet_start"` // Quiet hours start (hour in 24h format)
+	QuietEnd   int     `yaml:"quiet_end"`   // Quiet hours end (hour in 24h format)
+
+	Backend       string `yaml:"backend"`         // Preferred TTS backend: "auto", "say", "espeak-ng" or "piper"
+	PiperModelDir string `yaml:"piper_model_dir"` // Directory of Piper .onnx voice models (default: ~/.config/emrys/piper)
+
+	QueueSize int    `yaml:"queue_size"` // Maximum queued messages (default: 100)
+	Overflow  string `yaml:"overflow"`   // What to drop when the queue is full: "drop-oldest" or "drop-new"
+
+	QuietWindows string `yaml:"quiet_windows"` // Quiet windows such as "mon-fri 22:30-07:00; sat,sun 23:00-09:00" (overrides QuietStart/QuietEnd)
+	Timezone     string `yaml:"timezone"`      // IANA time zone for quiet hours (default: local time)
+	Holidays     string `yaml:"holidays"`      // Comma-separated YYYY-MM-DD dates that are quiet all day
+	QuietPolicy  string `yaml:"quiet_policy"`  // What happens to messages during quiet hours: "drop", "defer" or "notify"
+
+	CriticalVolume float64 `yaml:"critical_volume"` // Volume for critical messages (0: Volume)
+	ChatterVolume  float64 `yaml:"chatter_volume"`  // Volume for chatter (0: Volume)
+
+	Cache      bool   `yaml:"cache"`        // Whether repeated phrases are played from a cache (default: true)
+	CacheDir   string `yaml:"cache_dir"`    // Speech cache directory (default: DefaultCacheDir)
+	CacheMaxMB int    `yaml:"cache_max_mb"` // Speech cache size limit in megabytes (default: 100)
+}
+
+// VolumeFor returns the volume for messages of the given priority
+func (c Config) VolumeFor(priority Priority) float64 {
+	switch {
+	case priority == PriorityCritical && c.CriticalVolume > 0:
+		return c.CriticalVolume
+	case priority == PriorityChatter && c.ChatterVolume > 0:
+		return c.ChatterVolume
+	default:
+		return c.Volume
+	}
 }
 
 // DefaultConfig returns the default voice configuration
 func DefaultConfig() Config {
 	return Config{
-		Enabled:    true,
-		Voice:      "Jamie",
-		Rate:       200,
-		Volume:     0.7,
-		QuietHours: false,
-		QuietStart: 22, // 10 PM
-		QuietEnd:   7,  // 7 AM
+		Enabled:     true,
+		Voice:       "Jamie",
+		Rate:        200,
+		Volume:      0.7,
+		QuietHours:  false,
+		QuietStart:  22, // 10 PM
+		QuietEnd:    7,  // 7 AM
+		Backend:     BackendAuto,
+		QueueSize:   DefaultQueueSize,
+		Overflow:    OverflowDropOldest,
+		QuietPolicy: QuietDrop,
+		Cache:       true,
+		CacheMaxMB:  DefaultCacheMaxMB,
 	}
 }
 
-// Speaker manages voice output with message queuing
+// Speaker manages voice output with a priority message queue
 type Speaker struct {
-	config    Config
-	queue     chan string
-	wg        sync.WaitGroup
-	mu        sync.RWMutex
-	stop      chan struct{}
-	closeOnce sync.Once
+	config       Config
+	backend      Backend
+	fixedBackend bool // Backend was supplied by the caller and is never reselected
+	schedule     Schedule
+	clock        Clock
+	notifier     Notifier
+	logger       *slog.Logger
+	history      *history
+	cache        *Cache
+	phrases      *phraseMemory
+	play         func(ctx context.Context, path string, gain float64) error
+	pipeline     Pipeline
+	queue        messageQueue
+	nextID       uint64
+	wake         chan struct{}
+	wg           sync.WaitGroup
+	mu           sync.RWMutex
+	stop         chan struct{}
+	closeOnce    sync.Once
+
+	// The utterance currently being spoken from the queue
+	speaking        bool
+	currentID       uint64
+	currentPriority Priority
+	cancelCurrent   context.CancelFunc
+
+	// The configured voice as resolved for the current backend
+	voice resolvedVoice
+}
+
+// resolvedVoice remembers the voice passed to a backend for a configured
+// voice, so the backend's voices are not listed before every utterance
+type resolvedVoice struct {
+	backend    Backend
+	configured string
+	voice      string
 }
 
 // NewSpeaker creates a new Speaker with the given configuration
+// The backend is chosen from config.Backend, falling back to any other
+// available backend when the preferred one is missing.
 func NewSpeaker(config Config) *Speaker {
+	s := NewSpeakerWithBackend(config, SelectBackend(config))
+	s.fixedBackend = false
+	return s
+}
+
+// NewSpeakerWithBackend creates a new Speaker that uses the given backend
+func NewSpeakerWithBackend(config Config, backend Backend) *Speaker {
 	s := &Speaker{
-		config: config,
-		queue:  make(chan string, 100), // Buffer up to 100 messages
-		stop:   make(chan struct{}),
+		config:       config,
+		backend:      backend,
+		fixedBackend: true,
+		schedule:     scheduleFor(config),
+		clock:        SystemClock(),
+		notifier:     SystemNotifier{},
+		logger:       logging.Component(slog.Default(), "voice"),
+		history:      newHistory(DefaultHistorySize),
+		cache:        cacheFor(config),
+		phrases:      newPhraseMemory(),
+		play:         playFile,
+		pipeline:     DefaultPipeline(),
+		wake:         make(chan struct{}, 1),
+		stop:         make(chan struct{}),
 	}
 
 	// Start the message processing goroutine
@@ -57,98 +147,524 @@ func NewSpeaker(config Config) *Speaker {
 	return s
 }
 
-// processQueue processes queued messages one at a time
+// processQueue speaks queued messages one at a time, highest priority first
 func (s *Speaker) processQueue() {
 	defer s.wg.Done()
 
 	for {
 		select {
-		case msg := <-s.queue:
-			if err := s.speakNow(msg); err != nil {
-				// Log error but continue processing
-				fmt.Printf("Voice output error: %v\n", err)
-			}
 		case <-s.stop:
 			return
+		default:
 		}
+
+		s.mu.Lock()
+		if next, ok := s.queue.peek(); ok && next.priority < PriorityCritical && s.quiet() {
+			switch s.config.QuietPolicy {
+			case QuietDefer:
+				until := s.schedule.QuietUntil(s.clock.Now())
+				s.mu.Unlock()
+				s.waitUntil(until)
+				continue
+			case QuietNotify:
+				chunks := s.queue.take(next.id)
+				s.mu.Unlock()
+				s.notify(chunks)
+				continue
+			}
+		}
+
+		msg, ok := s.queue.pop()
+		var ctx context.Context
+		var rec Record
+		if ok {
+			ctx, s.cancelCurrent = context.WithCancel(context.Background())
+			s.speaking = true
+			s.currentID = msg.id
+			s.currentPriority = msg.priority
+			rec = msg.record(StatusSpeaking)
+			rec.Backend = s.backend.Name()
+			rec.Started = s.clock.Now()
+		}
+		s.mu.Unlock()
+
+		if !ok {
+			select {
+			case <-s.wake:
+				continue
+			case <-s.stop:
+				return
+			}
+		}
+
+		s.history.publish(EventStarted, rec)
+		var err error
+		rec.Status, rec.Cached, err = s.speakNow(ctx, msg.text, msg.priority)
+		if err != nil && rec.Status == StatusFailed {
+			rec.Error = err.Error()
+		}
+
+		s.mu.Lock()
+		s.cancelCurrent()
+		s.cancelCurrent = nil
+		s.speaking = false
+		s.mu.Unlock()
+
+		s.finish(rec)
 	}
 }
 
-// Speak queues a message for voice output
-// Returns immediately, message will be spoken asynchronously
-func (s *Speaker) Speak(message string) {
+// finish logs failures and publishes a finished utterance
+func (s *Speaker) finish(rec Record) {
 	s.mu.RLock()
-	enabled := s.config.Enabled
+	rec.Finished = s.clock.Now()
+	logger := s.logger
 	s.mu.RUnlock()
 
-	if !enabled {
-		return
+	switch rec.Status {
+	case StatusFailed:
+		logger.Error("voice output failed", "backend", rec.Backend, "text", rec.Text, "error", rec.Error)
+	case StatusDropped:
+		logger.Warn("voice message dropped", "priority", rec.Priority.String(), "text", rec.Text)
+	}
+	s.history.publish(EventFinished, rec)
+}
+
+// quiet reports whether it is currently quiet hours. The caller must hold s.mu.
+func (s *Speaker) quiet() bool {
+	return s.config.QuietHours && s.schedule.IsQuiet(s.clock.Now())
+}
+
+// waitUntil blocks until the given time, a new message or a config change
+// arrives, or the speaker stops. A zero time waits without a deadline.
+func (s *Speaker) waitUntil(until time.Time) {
+	s.mu.RLock()
+	clock := s.clock
+	s.mu.RUnlock()
+
+	var deadline <-chan time.Time
+	if !until.IsZero() {
+		deadline = clock.After(until.Sub(clock.Now()))
+	}
+
+	select {
+	case <-deadline:
+	case <-s.wake:
+	case <-s.stop:
+	}
+}
+
+// notify shows the chunks of a message as a single notification
+func (s *Speaker) notify(chunks []queuedMessage) {
+	var texts []string
+	for _, chunk := range chunks {
+		texts = append(texts, chunk.text)
+	}
+
+	s.mu.RLock()
+	notifier := s.notifier
+	s.mu.RUnlock()
+
+	err := notifier.Notify(context.Background(), "Emrys", strings.Join(texts, " "))
+	for _, chunk := range chunks {
+		rec := chunk.record(StatusNotified)
+		if err != nil {
+			rec.Status = StatusFailed
+			rec.Error = err.Error()
+		}
+		s.finish(rec)
 	}
+}
 
-	// Non-blocking send to queue
+// wakeQueue wakes the queue processor without blocking if it is already awake
+func (s *Speaker) wakeQueue() {
 	select {
-	case s.queue <- message:
-		// Message queued successfully
+	case s.wake <- struct{}{}:
 	default:
-		// Queue is full, drop the message
-		fmt.Println("Voice queue full, message dropped")
 	}
 }
 
+// Speak queues a message for voice output at normal priority
+// Returns immediately, message will be spoken asynchronously
+func (s *Speaker) Speak(message string) {
+	s.SpeakPriority(message, PriorityNormal)
+}
+
+// SpeakPriority queues a message for voice output at the given priority
+// The message is normalized and split into sentences by the speaker's
+// pipeline, and each sentence is queued separately. A critical message
+// interrupts any less urgent utterance that is playing. When the queue is
+// full the configured overflow policy decides which sentences are dropped.
+func (s *Speaker) SpeakPriority(message string, priority Priority) {
+	s.enqueue(s.newMessageID(), message, priority)
+}
+
+// newMessageID returns an id for the chunks of a new message
+func (s *Speaker) newMessageID() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	return s.nextID
+}
+
+// enqueue runs message through the pipeline and queues its chunks under id
+func (s *Speaker) enqueue(id uint64, message string, priority Priority) {
+	s.mu.Lock()
+	if !s.config.Enabled {
+		s.mu.Unlock()
+		return
+	}
+
+	size := s.config.QueueSize
+	if size <= 0 {
+		size = DefaultQueueSize
+	}
+
+	var queued, dropped []queuedMessage
+	for _, chunk := range s.pipeline.Process(message) {
+		msg := queuedMessage{text: chunk, priority: priority, id: id, queued: s.clock.Now()}
+		d := s.queue.push(msg, size, s.config.Overflow)
+		if d == nil || *d != msg {
+			queued = append(queued, msg)
+		}
+		if d != nil {
+			dropped = append(dropped, *d)
+		}
+	}
+
+	if priority == PriorityCritical && s.speaking && s.currentPriority < PriorityCritical {
+		s.cancelCurrent()
+	}
+	s.mu.Unlock()
+
+	for _, msg := range queued {
+		s.history.publish(EventQueued, msg.record(StatusQueued))
+	}
+	for _, d := range dropped {
+		s.finish(d.record(StatusDropped))
+	}
+
+	s.wakeQueue()
+}
+
+// Skip interrupts the message currently being spoken from the queue,
+// including its sentences that have not been spoken yet
+// It returns false if nothing was playing.
+func (s *Speaker) Skip() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.speaking {
+		return false
+	}
+	s.cancelMessage(s.currentID)
+	return true
+}
+
+// cancelMessage removes the queued chunks of a message and interrupts it if
+// it is playing. The caller must hold s.mu.
+func (s *Speaker) cancelMessage(id uint64) {
+	for _, msg := range s.queue.remove(id) {
+		s.history.publish(EventFinished, s.dropped(msg))
+	}
+	if s.speaking && s.currentID == id {
+		s.cancelCurrent()
+	}
+}
+
+// Flush discards all queued messages and interrupts the current utterance
+// It returns the number of queued messages that were discarded.
+func (s *Speaker) Flush() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.speaking {
+		s.cancelCurrent()
+	}
+	discarded := s.queue.clear()
+	for _, msg := range discarded {
+		s.history.publish(EventFinished, s.dropped(msg))
+	}
+	return len(discarded)
+}
+
+// dropped returns the record of a message removed from the queue on
+// purpose. Unlike overflow drops these are not logged. The caller must hold s.mu.
+func (s *Speaker) dropped(msg queuedMessage) Record {
+	rec := msg.record(StatusDropped)
+	rec.Finished = s.clock.Now()
+	return rec
+}
+
+// Pending returns the number of messages waiting to be spoken
+func (s *Speaker) Pending() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.queue.items)
+}
+
+// IsSpeaking reports whether a queued message is being spoken right now
+func (s *Speaker) IsSpeaking() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.speaking
+}
+
+// waitPollInterval is how often Wait checks whether the speaker is idle
+const waitPollInterval = 20 * time.Millisecond
+
+// Wait blocks until the speaker has nothing left to say for now: its queue
+// is empty or holds only messages deferred until quiet hours end. It
+// returns ctx.Err() if ctx is done first.
+func (s *Speaker) Wait(ctx context.Context) error {
+	ticker := time.NewTicker(waitPollInterval)
+	defer ticker.Stop()
+
+	for !s.idle() {
+		select {
+		case <-ticker.C:
+		case <-s.stop:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// idle reports whether nothing can be spoken until quiet hours end
+func (s *Speaker) idle() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.speaking {
+		return false
+	}
+	next, ok := s.queue.peek()
+	return !ok || (next.priority < PriorityCritical && s.config.QuietPolicy == QuietDefer && s.quiet())
+}
+
 // SpeakSync speaks a message synchronously (waits for completion)
 func (s *Speaker) SpeakSync(message string) error {
 	s.mu.RLock()
 	enabled := s.config.Enabled
+	pipeline := s.pipeline
 	s.mu.RUnlock()
 
 	if !enabled {
 		return nil
 	}
 
-	return s.speakNow(message)
+	for _, chunk := range pipeline.Process(message) {
+		s.mu.RLock()
+		now := s.clock.Now()
+		rec := Record{Text: chunk, Priority: PriorityNormal, Backend: s.backend.Name(), Queued: now, Started: now}
+		s.mu.RUnlock()
+
+		var err error
+		rec.Status, rec.Cached, err = s.speakNow(context.Background(), chunk, PriorityNormal)
+		if err != nil {
+			rec.Error = err.Error()
+		}
+		s.history.publish(EventFinished, s.stamp(rec))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stamp sets the finish time of a record
+func (s *Speaker) stamp(rec Record) Record {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	rec.Finished = s.clock.Now()
+	return rec
 }
 
-// speakNow executes the voice output using macOS 'say' command
-func (s *Speaker) speakNow(message string) error {
+// SetPipeline replaces the text pipeline used for new messages
+// A zero Pipeline speaks messages unchanged as a single utterance.
+func (s *Speaker) SetPipeline(p Pipeline) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pipeline = p
+}
+
+// speakNow speaks a message with the speaker's backend and reports what
+// happened to it and whether it was played from the cache
+// Critical messages are spoken even during quiet hours. Other messages are
+// shown as a notification under the notify policy and skipped otherwise.
+func (s *Speaker) speakNow(ctx context.Context, message string, priority Priority) (Status, bool, error) {
 	s.mu.RLock()
 	config := s.config
+	backend := s.backend
+	notifier := s.notifier
+	logger := s.logger
+	cache := s.cache
+	play := s.play
+	quiet := priority < PriorityCritical && s.quiet()
 	s.mu.RUnlock()
 
-	// Check if we're in quiet hours
-	if config.QuietHours && isQuietHours(config.QuietStart, config.QuietEnd) {
-		return nil // Silently skip during quiet hours
+	if quiet {
+		if config.QuietPolicy == QuietNotify {
+			if err := notifier.Notify(ctx, "Emrys", message); err != nil {
+				return StatusFailed, false, err
+			}
+			return StatusNotified, false, nil
+		}
+		return StatusQuiet, false, nil
 	}
 
-	// Build the say command with options
-	args := []string{}
+	volume := config.VolumeFor(priority)
+	if volume <= 0 {
+		return StatusMuted, false, nil
+	}
 
-	// Add voice if specified
-	if config.Voice != "" {
-		args = append(args, "-v", config.Voice)
+	u := Utterance{
+		Text:   message,
+		Voice:  s.backendVoice(ctx, backend, config.Voice),
+		Rate:   config.Rate,
+		Volume: volume,
 	}
 
-	// Add rate if not default
-	if config.Rate != 0 && config.Rate != 200 {
-		args = append(args, "-r", fmt.Sprintf("%d", config.Rate))
+	if path, ok := s.cachedSpeech(ctx, backend, cache, u); ok {
+		err := play(ctx, path, u.gain())
+		if err == nil || ctx.Err() != nil {
+			return speechStatus(ctx, err, true)
+		}
+		logger.Warn("cached speech failed to play, speaking directly", "backend", backend.Name(), "error", err)
+	} else if ctx.Err() != nil {
+		return StatusInterrupted, false, ctx.Err()
 	}
 
-	// Add volume (say doesn't support volume directly, we use audio output)
-	// Note: macOS 'say' doesn't have a volume flag, but we can control it via system volume
-	// For now, we'll just document this limitation
+	return speechStatus(ctx, backend.Speak(ctx, u), false)
+}
+
+// cachedSpeech returns the cached rendering of u to play, if the backend
+// can render to a file. A phrase that is not cached yet is rendered only
+// if it repeats or was marked with CachePhrases, so repeated phrases are
+// not synthesized again while one-off sentences start without delay.
+func (s *Speaker) cachedSpeech(ctx context.Context, backend Backend, cache *Cache, u Utterance) (string, bool) {
+	renderer, ok := backend.(Renderer)
+	if !ok || cache == nil {
+		return "", false
+	}
 
-	// Add the message
-	args = append(args, message)
+	repeated := s.phrases.repeat(u.Text)
+	if path, ok := cache.Lookup(renderer, u); ok {
+		return path, true
+	}
+	if !repeated {
+		return "", false
+	}
 
-	// Execute the say command
-	cmd := exec.Command("say", args...)
-	return cmd.Run()
+	path, _, err := cache.Get(ctx, renderer, u)
+	if err != nil {
+		if ctx.Err() == nil {
+			s.mu.RLock()
+			logger := s.logger
+			s.mu.RUnlock()
+			logger.Warn("voice cache failed, speaking directly", "backend", backend.Name(), "error", err)
+		}
+		return "", false
+	}
+	return path, true
+}
+
+// CachePhrases marks phrases, such as fixed announcements, to be rendered
+// into the speech cache the first time they are spoken rather than the
+// second. Each phrase is run through the pipeline like a spoken message.
+func (s *Speaker) CachePhrases(phrases ...string) {
+	s.mu.RLock()
+	pipeline := s.pipeline
+	s.mu.RUnlock()
+
+	for _, phrase := range phrases {
+		for _, chunk := range pipeline.Process(phrase) {
+			s.phrases.mark(chunk)
+		}
+	}
+}
+
+// backendVoice returns the voice to pass to backend for the configured voice
+// See BackendVoice.
+func (s *Speaker) backendVoice(ctx context.Context, backend Backend, configured string) string {
+	s.mu.RLock()
+	resolved := s.voice
+	logger := s.logger
+	s.mu.RUnlock()
+	if resolved.backend == backend && resolved.configured == configured {
+		return resolved.voice
+	}
+
+	voice := BackendVoice(ctx, backend, configured)
+	if voice == "" && configured != "" && backend.Capabilities().Voices {
+		logger.Warn("voice is not available, using the backend's default voice", "voice", configured, "backend", backend.Name())
+	}
+
+	s.mu.Lock()
+	s.voice = resolvedVoice{backend: backend, configured: configured, voice: voice}
+	s.mu.Unlock()
+	return voice
+}
+
+// speechStatus classifies the result of speaking an utterance
+func speechStatus(ctx context.Context, err error, cached bool) (Status, bool, error) {
+	switch {
+	case ctx.Err() != nil:
+		return StatusInterrupted, cached, ctx.Err()
+	case err != nil:
+		return StatusFailed, cached, err
+	default:
+		return StatusSpoken, cached, nil
+	}
+}
+
+// Backend returns the backend the speaker is using
+func (s *Speaker) Backend() Backend {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.backend
 }
 
 // UpdateConfig updates the speaker configuration
+// If the backend settings changed, the backend is selected again, unless
+// it was supplied explicitly with NewSpeakerWithBackend.
 func (s *Speaker) UpdateConfig(config Config) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+
+	backendChanged := config.Backend != s.config.Backend || config.PiperModelDir != s.config.PiperModelDir
+	if backendChanged && !s.fixedBackend {
+		s.backend = SelectBackend(config)
+	}
 	s.config = config
+	s.schedule = scheduleFor(config)
+	s.cache = cacheFor(config)
+
+	// Deferred messages may be speakable under the new quiet hours
+	s.wakeQueue()
+}
+
+// SetClock replaces the clock used for quiet hours
+func (s *Speaker) SetClock(clock Clock) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clock = clock
+}
+
+// SetLogger replaces the logger that receives voice output errors
+func (s *Speaker) SetLogger(logger *slog.Logger) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.logger = logger
+}
+
+// SetNotifier replaces the notifier used by the notify quiet-hours policy
+func (s *Speaker) SetNotifier(notifier Notifier) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.notifier = notifier
 }
 
 // GetConfig returns a copy of the current configuration
@@ -179,8 +695,8 @@ func (s *Speaker) IsEnabled() bool {
 	return s.config.Enabled
 }
 
-// Close stops the speaker and waits for queued messages to complete
-// It is safe to call Close multiple times
+// Close stops the speaker and waits for the current utterance to complete
+// Messages still queued are discarded. It is safe to call Close multiple times
 func (s *Speaker) Close() {
 	s.closeOnce.Do(func() {
 		close(s.stop)
@@ -188,37 +704,26 @@ func (s *Speaker) Close() {
 	})
 }
 
-// isQuietHours checks if the current time is within quiet hours
-func isQuietHours(start, end int) bool {
-	// Get current hour (0-23)
-	hour := time.Now().Hour()
-
-	// Handle case where quiet hours span midnight (e.g., 22:00 to 07:00)
-	if start > end {
-		// Quiet hours span midnight
-		return hour >= start || hour < end
+// scheduleFor returns the quiet-hours schedule for config, falling back to
+// the whole-hour QuietStart and QuietEnd window if the schedule is invalid
+func scheduleFor(config Config) Schedule {
+	schedule, err := config.Schedule()
+	if err != nil {
+		return Schedule{Windows: []Window{{Start: config.QuietStart * 60, End: config.QuietEnd * 60}}}
 	}
-
-	// Normal case: quiet hours don't span midnight
-	return hour >= start && hour < end
+	return schedule
 }
 
-// IsVoiceAvailable checks if a specific voice is available on the system
+// IsVoiceAvailable checks if a specific voice is available with the
+// system's default backend
 func IsVoiceAvailable(voiceName string) bool {
-	// Run 'say -v ?' to list available voices
-	cmd := exec.Command("say", "-v", "?")
-	output, err := cmd.Output()
+	voices, err := ListAvailableVoices(DefaultConfig())
 	if err != nil {
 		return false
 	}
 
-	// Check if the voice name appears at the start of a line
-	// Voice listing format: "VoiceName    language    # comment"
-	lines := strings.Split(string(output), "\n")
-	for _, line := range lines {
-		// Get the first field (voice name)
-		fields := strings.Fields(line)
-		if len(fields) > 0 && fields[0] == voiceName {
+	for _, v := range voices {
+		if v == voiceName {
 			return true
 		}
 	}
@@ -226,44 +731,25 @@ func IsVoiceAvailable(voiceName string) bool {
 	return false
 }
 
-// ListAvailableVoices returns a list of available voices on the system
-func ListAvailableVoices() ([]string, error) {
-	cmd := exec.Command("say", "-v", "?")
-	output, err := cmd.Output()
-	if err != nil {
-		return nil, fmt.Errorf("failed to list voices: %w", err)
-	}
-
-	var voices []string
-	lines := strings.Split(string(output), "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
-
-		// Extract voice name (first field before whitespace)
-		fields := strings.Fields(line)
-		if len(fields) > 0 {
-			voices = append(voices, fields[0])
-		}
-	}
-
-	return voices, nil
+// ListAvailableVoices returns the voices of the backend config selects
+func ListAvailableVoices(config Config) ([]string, error) {
+	return SelectBackend(config).ListVoices(context.Background())
 }
 
-// Test speaks a test message to verify voice output is working
-func Test(voiceName string) error {
+// Test speaks a test message with the backend, voice, rate and volume in
+// config to verify voice output is working
+func Test(config Config) error {
 	testMessage := "Emrys voice output is working correctly."
 
-	args := []string{}
-	if voiceName != "" {
-		args = append(args, "-v", voiceName)
+	ctx := context.Background()
+	backend := SelectBackend(config)
+	voice := BackendVoice(ctx, backend, config.Voice)
+	if voice == "" && config.Voice != "" && backend.Capabilities().Voices {
+		return fmt.Errorf("voice %s is not available with %s", config.Voice, backend.Name())
 	}
-	args = append(args, testMessage)
 
-	cmd := exec.Command("say", args...)
-	if err := cmd.Run(); err != nil {
+	u := Utterance{Text: testMessage, Voice: voice, Rate: config.Rate, Volume: config.Volume}
+	if err := backend.Speak(ctx, u); err != nil {
 		return fmt.Errorf("voice test failed: %w", err)
 	}
 
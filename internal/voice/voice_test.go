@@ -1,6 +1,12 @@
 package voice
 
 import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -167,40 +173,78 @@ func TestSpeakerCloseMultipleTimes(t *testing.T) {
 	// Test passed if we get here without panic
 }
 
+func TestSpeakerWait(t *testing.T) {
+	backend := NewFakeBackend()
+	backend.Delay = 20 * time.Millisecond
+	speaker := NewSpeakerWithBackend(DefaultConfig(), backend)
+	defer speaker.Close()
+
+	speaker.Speak("One. Two.")
+	if err := speaker.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait failed: %v", err)
+	}
+	if got := backend.Texts(); len(got) != 2 {
+		t.Errorf("Expected both sentences spoken before Wait returned, got %v", got)
+	}
+
+	// Text with nothing to say queues nothing, so there is nothing to wait for
+	speaker.Speak("   ")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := speaker.Wait(ctx); err != nil {
+		t.Errorf("Expected Wait to return at once, got %v", err)
+	}
+}
+
+func TestSpeakerWaitTimeout(t *testing.T) {
+	backend := NewFakeBackend()
+	backend.Delay = time.Second
+	speaker := NewSpeakerWithBackend(DefaultConfig(), backend)
+	defer speaker.Close()
+
+	speaker.Speak("A long story.")
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := speaker.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected Wait to give up, got %v", err)
+	}
+}
+
 func TestIsQuietHours(t *testing.T) {
-	// Test quiet hours logic with current time
-	// Note: This test validates the logic but results depend on current time
-	
-	// Test case 1: Quiet hours spanning midnight (22:00 to 07:00)
-	// If current hour is 23 or 0-6, should be quiet
-	hour := time.Now().Hour()
-	
-	result1 := isQuietHours(22, 7)
-	expectedQuiet1 := hour >= 22 || hour < 7
-	if result1 != expectedQuiet1 {
-		t.Errorf("isQuietHours(22, 7) = %v, expected %v (current hour: %d)", result1, expectedQuiet1, hour)
-	}
-	
-	// Test case 2: Normal quiet hours (1:00 to 5:00)
-	result2 := isQuietHours(1, 5)
-	expectedQuiet2 := hour >= 1 && hour < 5
-	if result2 != expectedQuiet2 {
-		t.Errorf("isQuietHours(1, 5) = %v, expected %v (current hour: %d)", result2, expectedQuiet2, hour)
-	}
-	
-	// Test case 3: Same start and end (0:00 to 0:00) - edge case
-	// When start equals end, no time period is selected, so always not quiet
-	result3 := isQuietHours(0, 0)
-	// This should always be false since hour >= 0 && hour < 0 is always false
-	if result3 {
-		t.Error("isQuietHours(0, 0) should be false (no time period selected)")
-	}
-	
-	// Test case 4: Same non-zero start and end (12:00 to 12:00)
-	result4 := isQuietHours(12, 12)
-	// This should always be false since hour >= 12 && hour < 12 is always false
-	if result4 {
-		t.Error("isQuietHours(12, 12) should be false (no time period selected)")
+	at := func(hour int) time.Time {
+		return time.Date(2026, time.March, 4, hour, 30, 0, 0, time.Local)
+	}
+
+	tests := []struct {
+		start, end int
+		hour       int
+		quiet      bool
+	}{
+		// Quiet hours spanning midnight (22:00 to 07:00)
+		{22, 7, 23, true},
+		{22, 7, 3, true},
+		{22, 7, 7, false},
+		{22, 7, 12, false},
+		// Normal quiet hours (1:00 to 5:00)
+		{1, 5, 1, true},
+		{1, 5, 5, false},
+		// Same start and end means quiet all day
+		{0, 0, 13, true},
+		{12, 12, 0, true},
+	}
+
+	for _, tt := range tests {
+		config := DefaultConfig()
+		config.QuietStart = tt.start
+		config.QuietEnd = tt.end
+
+		schedule, err := config.Schedule()
+		if err != nil {
+			t.Fatalf("Schedule failed: %v", err)
+		}
+		if got := schedule.IsQuiet(at(tt.hour)); got != tt.quiet {
+			t.Errorf("Quiet hours %d-%d at %d:30 = %v, expected %v", tt.start, tt.end, tt.hour, got, tt.quiet)
+		}
 	}
 }
 
@@ -221,31 +265,68 @@ func TestIsVoiceAvailable(t *testing.T) {
 	}
 }
 
-// TestListAvailableVoices tests listing available voices
+// fakePiper puts stand-ins for piper and the audio players first on PATH
+// and returns a config that selects piper with the models a and b. The
+// stand-ins log their arguments to piper.log and play.log in dir.
+func fakePiper(t *testing.T) (config Config, dir string) {
+	dir = t.TempDir()
+	scripts := map[string]string{
+		"piper": "echo \"$@\" >> " + dir + "/piper.log\ncat > /dev/null\n: > \"$4\"\n",
+	}
+	for _, player := range []string{"afplay", "paplay", "aplay", "ffplay"} {
+		scripts[player] = "echo \"$@\" >> " + dir + "/play.log\n"
+	}
+	for name, script := range scripts {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	models := filepath.Join(dir, "models")
+	os.Mkdir(models, 0755)
+	for _, model := range []string{"a", "b"} {
+		os.WriteFile(filepath.Join(models, model+".onnx"), nil, 0644)
+	}
+
+	config = DefaultConfig()
+	config.Backend = BackendPiper
+	config.PiperModelDir = models
+	return config, dir
+}
+
+// TestListAvailableVoices tests that the configured backend's voices are listed
 func TestListAvailableVoices(t *testing.T) {
-	// Skip this test on non-macOS systems
-	t.Skip("Skipping voice listing test (requires macOS)")
+	config, _ := fakePiper(t)
 
-	voices, err := ListAvailableVoices()
+	voices, err := ListAvailableVoices(config)
 	if err != nil {
 		t.Fatalf("Failed to list voices: %v", err)
 	}
-
-	if len(voices) == 0 {
-		t.Error("Expected at least one voice to be available")
+	if !reflect.DeepEqual(voices, []string{"a", "b"}) {
+		t.Errorf("Expected the piper models [a b], got %v", voices)
 	}
-
-	t.Logf("Found %d voices", len(voices))
 }
 
-// TestTest tests the voice testing utility
+// TestTest tests that the voice test speaks with the configured settings
 func TestTest(t *testing.T) {
-	// Skip this test on non-macOS systems
-	t.Skip("Skipping voice test (requires macOS)")
+	config, dir := fakePiper(t)
+	config.Voice = "b"
+	config.Volume = 0.5
+
+	if err := Test(config); err != nil {
+		t.Fatalf("Voice test failed: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "piper.log")); !strings.Contains(string(data), "b.onnx") {
+		t.Errorf("Expected piper to use the configured voice, got %q", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "play.log")); len(data) == 0 {
+		t.Error("Expected the test message to be played")
+	}
 
-	// Test with default voice
-	if err := Test(""); err != nil {
-		t.Errorf("Voice test failed: %v", err)
+	config.Voice = "Jamie"
+	if err := Test(config); err == nil || !strings.Contains(err.Error(), "not available") {
+		t.Errorf("Expected an unavailable voice to fail the test, got %v", err)
 	}
 }
 
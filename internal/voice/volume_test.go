@@ -0,0 +1,139 @@
+package voice
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSayArgsVolume(t *testing.T) {
+	got := sayArgs(Utterance{Text: "hi", Volume: 0.5})
+	want := []string{"[[volm 0.50]] hi"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	got = sayArgs(Utterance{Text: "hi", Volume: 1})
+	if !reflect.DeepEqual(got, []string{"hi"}) {
+		t.Errorf("Expected no volume command at full volume, got %v", got)
+	}
+}
+
+func TestEspeakArgsVolume(t *testing.T) {
+	got := espeakArgs(Utterance{Text: "hi", Voice: "en", Volume: 0.7})
+	want := []string{"-v", "en", "-a", "70", "hi"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+// writeWAV writes a mono 16-bit PCM WAV file with the given samples
+func writeWAV(t *testing.T, path string, samples []int16) {
+	t.Helper()
+	var data bytes.Buffer
+	binary.Write(&data, binary.LittleEndian, samples)
+
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(36+data.Len()))
+	buf.WriteString("WAVE")
+	buf.WriteString("fmt ")
+	binary.Write(&buf, binary.LittleEndian, []uint32{16})
+	binary.Write(&buf, binary.LittleEndian, []uint16{1, 1})         // PCM, mono
+	binary.Write(&buf, binary.LittleEndian, []uint32{16000, 32000}) // rate, byte rate
+	binary.Write(&buf, binary.LittleEndian, []uint16{2, 16})        // block align, bits
+	buf.WriteString("data")
+	binary.Write(&buf, binary.LittleEndian, uint32(data.Len()))
+	buf.Write(data.Bytes())
+
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestApplyGain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "speech.wav")
+	writeWAV(t, path, []int16{1000, -1000, 32767, -32768, 0})
+
+	if err := applyGain(path, 0.5); err != nil {
+		t.Fatalf("applyGain failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := wavData(data)
+	if err != nil {
+		t.Fatalf("wavData failed: %v", err)
+	}
+	got := make([]int16, len(raw)/2)
+	binary.Read(bytes.NewReader(raw), binary.LittleEndian, got)
+
+	want := []int16{500, -500, 16384, -16384, 0}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestApplyGainRejectsNonWAV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "speech.aiff")
+	os.WriteFile(path, []byte("FORM....AIFF"), 0644)
+
+	if err := applyGain(path, 0.5); err == nil {
+		t.Error("Expected error for non-WAV file")
+	}
+}
+
+func TestVolumeFor(t *testing.T) {
+	config := DefaultConfig()
+	config.CriticalVolume = 1.0
+
+	if got := config.VolumeFor(PriorityCritical); got != 1.0 {
+		t.Errorf("Expected critical override 1.0, got %g", got)
+	}
+	if got := config.VolumeFor(PriorityChatter); got != config.Volume {
+		t.Errorf("Expected chatter to use the default volume, got %g", got)
+	}
+}
+
+func TestSpeakerAppliesPriorityVolume(t *testing.T) {
+	config := DefaultConfig()
+	config.Volume = 0.6
+	config.ChatterVolume = 0.3
+	backend := NewFakeBackend()
+	speaker := NewSpeakerWithBackend(config, backend)
+	defer speaker.Close()
+
+	speaker.SpeakPriority("Psst.", PriorityChatter)
+	waitFor(t, func() bool { return len(backend.Spoken()) == 1 })
+	speaker.Speak("Hello.")
+	waitFor(t, func() bool { return len(backend.Spoken()) == 2 })
+
+	spoken := backend.Spoken()
+	if spoken[0].Volume != 0.3 || spoken[1].Volume != 0.6 {
+		t.Errorf("Expected volumes 0.3 and 0.6, got %g and %g", spoken[0].Volume, spoken[1].Volume)
+	}
+}
+
+func TestSpeakerMuted(t *testing.T) {
+	config := DefaultConfig()
+	config.Volume = 0
+	config.CriticalVolume = 0.9
+	backend := NewFakeBackend()
+	speaker := NewSpeakerWithBackend(config, backend)
+	defer speaker.Close()
+
+	if err := speaker.SpeakSync("Muted."); err != nil {
+		t.Fatalf("SpeakSync failed: %v", err)
+	}
+	speaker.SpeakPriority("Alarm!", PriorityCritical)
+	waitFor(t, func() bool { return len(backend.Spoken()) == 1 })
+
+	if got := backend.Texts(); !reflect.DeepEqual(got, []string{"Alarm!"}) {
+		t.Errorf("Expected only the critical message, got %v", got)
+	}
+}
@@ -0,0 +1,115 @@
+package voice
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultWatchInterval is how often a ConfigWatcher checks the file
+const DefaultWatchInterval = 2 * time.Second
+
+// ConfigWatcher reloads the voice settings into a Speaker when the file
+// they are read from changes. If they fail to load, the error is reported
+// and the speaker keeps its last good configuration.
+type ConfigWatcher struct {
+	path     string
+	load     func() (Config, error)
+	speaker  *Speaker
+	interval time.Duration
+	onError  func(error)
+
+	last      []byte
+	loaded    bool // last holds the contents of a load
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// WatchConfig applies the settings returned by load to speaker and calls
+// load again whenever path changes, so that a file such as config.yaml can
+// hold more than the voice settings. A missing path counts as empty.
+// onError receives load errors; if nil they are logged through the
+// speaker's logger.
+// The initial load must succeed.
+func WatchConfig(path string, load func() (Config, error), speaker *Speaker, interval time.Duration, onError func(error)) (*ConfigWatcher, error) {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	if onError == nil {
+		onError = func(err error) {
+			speaker.mu.RLock()
+			logger := speaker.logger
+			speaker.mu.RUnlock()
+			logger.Error("voice configuration error", "path", path, "error", err)
+		}
+	}
+
+	w := &ConfigWatcher{
+		path:     path,
+		load:     load,
+		speaker:  speaker,
+		interval: interval,
+		onError:  onError,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	if err := w.reload(); err != nil {
+		return nil, err
+	}
+
+	go w.run()
+	return w, nil
+}
+
+// run polls the file until Close is called
+func (w *ConfigWatcher) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := w.reload(); err != nil {
+				w.onError(err)
+			}
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+// reload applies the settings to the speaker if the file changed
+func (w *ConfigWatcher) reload() error {
+	data, err := os.ReadFile(w.path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read voice configuration: %w", err)
+	}
+	if w.loaded && bytes.Equal(data, w.last) {
+		return nil
+	}
+	// Remember the contents even if they are invalid, so the same error is
+	// reported once rather than on every poll
+	w.last, w.loaded = data, true
+
+	config, err := w.load()
+	if err != nil {
+		return err
+	}
+
+	w.speaker.UpdateConfig(config)
+	return nil
+}
+
+// Close stops watching. It is safe to call Close multiple times.
+func (w *ConfigWatcher) Close() {
+	w.closeOnce.Do(func() {
+		close(w.stop)
+		<-w.done
+	})
+}
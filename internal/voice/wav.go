@@ -0,0 +1,72 @@
+package voice
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+)
+
+// applyGain scales the samples of a 16-bit PCM WAV file in place
+func applyGain(path string, gain float64) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read audio: %w", err)
+	}
+
+	samples, err := wavData(data)
+	if err != nil {
+		return fmt.Errorf("failed to apply volume to %s: %w", path, err)
+	}
+
+	for i := 0; i+1 < len(samples); i += 2 {
+		sample := float64(int16(binary.LittleEndian.Uint16(samples[i:])))
+		scaled := math.Max(math.MinInt16, math.Min(math.MaxInt16, math.Round(sample*gain)))
+		binary.LittleEndian.PutUint16(samples[i:], uint16(int16(scaled)))
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write audio: %w", err)
+	}
+	return nil
+}
+
+// wavData returns the sample bytes of a 16-bit PCM WAV file, sharing
+// memory with data
+func wavData(data []byte) ([]byte, error) {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		return nil, fmt.Errorf("not a WAV file")
+	}
+
+	pcm16 := false
+	for offset := 12; offset+8 <= len(data); {
+		id := string(data[offset : offset+4])
+		size := int(binary.LittleEndian.Uint32(data[offset+4:]))
+		body := offset + 8
+		end := body + size
+		if end > len(data) {
+			// Streaming writers may leave the data size unset
+			end = len(data)
+		}
+
+		switch id {
+		case "fmt ":
+			if size < 16 {
+				return nil, fmt.Errorf("invalid fmt chunk")
+			}
+			format := binary.LittleEndian.Uint16(data[body:])
+			bits := binary.LittleEndian.Uint16(data[body+14:])
+			pcm16 = format == 1 && bits == 16
+		case "data":
+			if !pcm16 {
+				return nil, fmt.Errorf("only 16-bit PCM is supported")
+			}
+			return data[body:end], nil
+		}
+
+		// Chunks are padded to an even size
+		offset = end + size%2
+	}
+
+	return nil, fmt.Errorf("no data chunk")
+}